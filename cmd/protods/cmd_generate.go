@@ -1,27 +1,65 @@
 package main
 
 import (
+	"os"
+
 	"github.com/paralin/protods/generate"
+	_ "github.com/paralin/protods/generate/ctrie"
+	_ "github.com/paralin/protods/generate/deepcopy"
+	_ "github.com/paralin/protods/generate/descriptor"
+	_ "github.com/paralin/protods/generate/diff"
+	_ "github.com/paralin/protods/generate/equal"
 	_ "github.com/paralin/protods/generate/itypes"
+	_ "github.com/paralin/protods/generate/journal"
+	_ "github.com/paralin/protods/generate/kv"
+	_ "github.com/paralin/protods/generate/merge"
+	"github.com/paralin/protods/generate/plugin"
+	_ "github.com/paralin/protods/generate/readonly"
+	_ "github.com/paralin/protods/generate/scaffold"
+	_ "github.com/paralin/protods/generate/sql"
+	_ "github.com/paralin/protods/generate/sync"
+	_ "github.com/paralin/protods/generate/template"
+	_ "github.com/paralin/protods/generate/watch"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
 
 var generateOutputPath = "."
+var generateOpts generate.Options
+var generateParams cli.StringSlice
+var generatePluginPath string
+
+// runGenerate runs a generator with the proto file given as the first argument.
+func runGenerate(c *cli.Context, gen generate.Generator) error {
+	protoPathArg := c.Args().Get(0)
+	if protoPathArg == "" {
+		return errors.New("specify proto file to use")
+	}
+
+	params, err := generate.ParseParams(generateParams)
+	if err != nil {
+		return err
+	}
+	generateOpts.Params = params
+
+	return generate.GenerateWithOptions(gen, protoPathArg, generateOutputPath, generateOpts)
+}
 
 func init() {
+	// protods-gen-* executables on the PATH are available as generators.
+	for name, execPath := range plugin.Discover(os.Getenv("PATH")) {
+		if generate.GetGenerator(name) == nil {
+			generate.RegisterGenerator(name, plugin.NewGenerator(execPath))
+		}
+	}
+
 	var subCommands []cli.Command
 	generate.ForEachGenerator(func(name string, gen generate.Generator) bool {
 		subCommands = append(subCommands, cli.Command{
 			Name:  name,
 			Usage: gen.GetUsage(),
 			Action: func(c *cli.Context) error {
-				protoPathArg := c.Args().Get(0)
-				if protoPathArg == "" {
-					return errors.New("specify proto file to use")
-				}
-
-				return generate.Generate(gen, protoPathArg, generateOutputPath)
+				return runGenerate(c, gen)
 			},
 		})
 		return true
@@ -31,6 +69,13 @@ func init() {
 		Name:        "generate",
 		Usage:       "generate protods go structures",
 		Subcommands: subCommands,
+		Action: func(c *cli.Context) error {
+			if generatePluginPath == "" {
+				return cli.ShowAppHelp(c)
+			}
+
+			return runGenerate(c, plugin.NewGenerator(generatePluginPath))
+		},
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:        "go_out, o",
@@ -38,6 +83,26 @@ func init() {
 				Destination: &generateOutputPath,
 				Value:       generateOutputPath,
 			},
+			cli.BoolFlag{
+				Name:        "allow-invalid",
+				Usage:       "write generated code even if it fails to format or type check",
+				Destination: &generateOpts.AllowInvalid,
+			},
+			cli.BoolFlag{
+				Name:        "skip-typecheck",
+				Usage:       "skip type checking against the protoc-gen-go output",
+				Destination: &generateOpts.SkipTypeCheck,
+			},
+			cli.StringSliceFlag{
+				Name:  "param, p",
+				Usage: "pass a `KEY=VALUE` parameter to the generator",
+				Value: &generateParams,
+			},
+			cli.StringFlag{
+				Name:        "plugin",
+				Usage:       "run the external generator at `PATH`",
+				Destination: &generatePluginPath,
+			},
 		},
 	})
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+
+	"github.com/paralin/protods/inspect"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var inspectFormat = "tree"
+
+func init() {
+	rootCommands = append(rootCommands, cli.Command{
+		Name:      "inspect",
+		Usage:     "print the parsed protods representation of a proto file",
+		ArgsUsage: "<file.proto>",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "format, f",
+				Usage:       "output `FORMAT`: tree or json",
+				Destination: &inspectFormat,
+				Value:       inspectFormat,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			protoPathArg := c.Args().Get(0)
+			if protoPathArg == "" {
+				return errors.New("specify proto file to use")
+			}
+
+			pp, err := parser.ReadProto(protoPathArg)
+			if err != nil {
+				return err
+			}
+
+			pf, err := parser.Parse(pp)
+			if err != nil {
+				return err
+			}
+
+			switch inspectFormat {
+			case "json":
+				return inspect.WriteJSON(os.Stdout, pf)
+			case "tree":
+				return inspect.WriteTree(os.Stdout, pf)
+			default:
+				return errors.Errorf("unknown output format: %s", inspectFormat)
+			}
+		},
+	})
+}
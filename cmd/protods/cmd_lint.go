@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/paralin/protods/lint"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var lintFormat = "text"
+
+func init() {
+	rootCommands = append(rootCommands, cli.Command{
+		Name:      "lint",
+		Usage:     "report proto constructs protods cannot generate correctly",
+		ArgsUsage: "<file.proto>",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:        "format, f",
+				Usage:       "output `FORMAT`: text or json",
+				Destination: &lintFormat,
+				Value:       lintFormat,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			protoPathArg := c.Args().Get(0)
+			if protoPathArg == "" {
+				return errors.New("specify proto file to use")
+			}
+
+			pp, err := parser.ReadProto(protoPathArg)
+			if err != nil {
+				return err
+			}
+
+			problems, err := lint.Lint(pp)
+			if err != nil {
+				return err
+			}
+
+			switch lintFormat {
+			case "json":
+				if problems == nil {
+					problems = []*lint.Problem{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(problems); err != nil {
+					return err
+				}
+			case "text":
+				for _, problem := range problems {
+					_, _ = os.Stdout.WriteString(problem.String())
+					_, _ = os.Stdout.WriteString("\n")
+				}
+			default:
+				return errors.Errorf("unknown output format: %s", lintFormat)
+			}
+
+			for _, problem := range problems {
+				if problem.Severity == lint.SeverityError {
+					return errors.New("lint found errors")
+				}
+			}
+			return nil
+		},
+	})
+}
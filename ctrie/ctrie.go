@@ -0,0 +1,212 @@
+// Package ctrie implements a concurrent hash trie of values addressed by key
+// path, used by the code generated with the ctrie generator.
+//
+// The trie is persistent: every update builds a new root sharing the
+// unchanged nodes with the previous root, and installs it with a
+// compare-and-swap. Readers never block, writers retry on contention, and
+// snapshots are constant time.
+package ctrie
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Trie is a concurrent hash trie of values addressed by key path.
+// Key paths are / separated, for example /lower_kv/test/value. Each path
+// segment below the root is a subtree, which holds the fields of an object
+// or the entries of a map.
+type Trie struct {
+	// root contains the current *node.
+	root atomic.Value
+}
+
+// New builds a new empty trie.
+func New() *Trie {
+	return newTrie(emptyNode)
+}
+
+// newTrie builds a trie with the root node.
+func newTrie(root *node) *Trie {
+	t := &Trie{}
+	t.root.Store(root)
+	return t
+}
+
+// Snapshot returns an independent copy of the trie in constant time.
+func (t *Trie) Snapshot() *Trie {
+	return newTrie(t.load())
+}
+
+// Subtrie returns an independent copy of the subtree at the key path in
+// constant time. Returns nil if there is no subtree at the key path.
+func (t *Trie) Subtrie(keyPath string) *Trie {
+	n, ok := lookup(t.load(), splitPath(keyPath)).(*node)
+	if !ok {
+		return nil
+	}
+	return newTrie(n)
+}
+
+// Has checks if there is a subtree at the key path.
+func (t *Trie) Has(keyPath string) bool {
+	_, ok := lookup(t.load(), splitPath(keyPath)).(*node)
+	return ok
+}
+
+// Load returns the value at the key path.
+// Returns false if there is no value or the key path is a subtree.
+func (t *Trie) Load(keyPath string) (interface{}, bool) {
+	val := lookup(t.load(), splitPath(keyPath))
+	if _, isNode := val.(*node); isNode || val == nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// Store stores the value at the key path, creating subtrees as needed.
+// Storing a nil value deletes the key path.
+func (t *Trie) Store(keyPath string, val interface{}) {
+	if val == nil {
+		t.Delete(keyPath)
+		return
+	}
+
+	segs := splitPath(keyPath)
+	if len(segs) == 0 {
+		return
+	}
+	t.swap(func(root *node) *node {
+		return store(root, segs, val)
+	})
+}
+
+// Graft stores the current contents of sub as the subtree at the key path.
+// The subtree shares structure with sub, later changes to either trie are
+// independent. Grafting at the root replaces the entire trie.
+func (t *Trie) Graft(keyPath string, sub *Trie) {
+	subRoot := sub.load()
+	segs := splitPath(keyPath)
+	t.swap(func(root *node) *node {
+		if len(segs) == 0 {
+			return subRoot
+		}
+		return store(root, segs, subRoot)
+	})
+}
+
+// Delete removes the value or subtree at the key path.
+// Deleting the root clears the trie.
+func (t *Trie) Delete(keyPath string) {
+	segs := splitPath(keyPath)
+	t.swap(func(root *node) *node {
+		if len(segs) == 0 {
+			return emptyNode
+		}
+		return remove(root, segs)
+	})
+}
+
+// Keys returns the sorted keys directly below the key path.
+func (t *Trie) Keys(keyPath string) []string {
+	n, ok := lookup(t.load(), splitPath(keyPath)).(*node)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	n.each(func(l *leaf) {
+		keys = append(keys, l.key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// load returns the current root.
+func (t *Trie) load() *node {
+	return t.root.Load().(*node)
+}
+
+// swap replaces the root with the result of fn, retrying on contention.
+func (t *Trie) swap(fn func(root *node) *node) {
+	for {
+		old := t.load()
+		next := fn(old)
+		if next == old || t.root.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// splitPath splits a key path into segments.
+func splitPath(keyPath string) []string {
+	keyPath = strings.Trim(keyPath, "/")
+	if keyPath == "" {
+		return nil
+	}
+	return strings.Split(keyPath, "/")
+}
+
+// lookup returns the value or subtree at the path segments, or nil.
+func lookup(n *node, segs []string) interface{} {
+	var val interface{} = n
+	for _, seg := range segs {
+		n, ok := val.(*node)
+		if !ok {
+			return nil
+		}
+		val, ok = n.get(hashKey(seg), seg, 0)
+		if !ok {
+			return nil
+		}
+	}
+	return val
+}
+
+// store returns a copy of n with the value at the path segments.
+func store(n *node, segs []string, val interface{}) *node {
+	seg := segs[0]
+	hash := hashKey(seg)
+	if len(segs) == 1 {
+		return n.put(hash, seg, val, 0)
+	}
+
+	existing, _ := n.get(hash, seg, 0)
+	child, ok := existing.(*node)
+	if !ok {
+		child = emptyNode
+	}
+	return n.put(hash, seg, store(child, segs[1:], val), 0)
+}
+
+// remove returns a copy of n without the value at the path segments.
+// Returns n if there is no value at the path.
+func remove(n *node, segs []string) *node {
+	seg := segs[0]
+	hash := hashKey(seg)
+	if len(segs) == 1 {
+		return n.remove(hash, seg, 0)
+	}
+
+	existing, _ := n.get(hash, seg, 0)
+	child, ok := existing.(*node)
+	if !ok {
+		return n
+	}
+	nextChild := remove(child, segs[1:])
+	if nextChild == child {
+		return n
+	}
+	return n.put(hash, seg, nextChild, 0)
+}
+
+// hashKey hashes a path segment with 32-bit FNV-1a.
+func hashKey(key string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return hash
+}
@@ -0,0 +1,150 @@
+package ctrie
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestTrieConcurrent tests concurrent stores and loads, run with -race.
+func TestTrieConcurrent(t *testing.T) {
+	tr := New()
+	const writers, keys = 8, 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				tr.Store("/w"+strconv.Itoa(w)+"/"+strconv.Itoa(i), i)
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				if val, ok := tr.Load("/w" + strconv.Itoa(w) + "/" + strconv.Itoa(i)); ok && val != i {
+					t.Errorf("unexpected value %v for %d", val, i)
+				}
+				_ = tr.Snapshot().Keys("/w" + strconv.Itoa(w))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < writers; w++ {
+		prefix := "/w" + strconv.Itoa(w)
+		if n := len(tr.Keys(prefix)); n != keys {
+			t.Fatalf("%s: expected %d keys, got %d", prefix, keys, n)
+		}
+		for i := 0; i < keys; i++ {
+			if val, ok := tr.Load(prefix + "/" + strconv.Itoa(i)); !ok || val != i {
+				t.Fatalf("%s/%d: unexpected value %v", prefix, i, val)
+			}
+		}
+	}
+}
+
+// TestTrieSnapshot tests a snapshot is isolated from grafts and deletes.
+func TestTrieSnapshot(t *testing.T) {
+	tr := New()
+	tr.Store("/a/x", 1)
+	tr.Store("/a/y", 2)
+	snap := tr.Snapshot()
+
+	sub := New()
+	sub.Store("/z", 3)
+	tr.Graft("/b", sub)
+	tr.Delete("/a/x")
+	sub.Store("/z", 4)
+
+	if val, ok := snap.Load("/a/x"); !ok || val != 1 {
+		t.Fatalf("expected snapshot to keep /a/x, got %v", val)
+	}
+	if snap.Has("/b") {
+		t.Fatal("expected snapshot not to contain the graft")
+	}
+	if val, ok := tr.Load("/b/z"); !ok || val != 3 {
+		t.Fatalf("expected graft to be independent of the source, got %v", val)
+	}
+	if _, ok := tr.Load("/a/x"); ok {
+		t.Fatal("expected /a/x to be deleted")
+	}
+
+	subtrie := tr.Subtrie("/a")
+	tr.Store("/a/y", 5)
+	if val, ok := subtrie.Load("/y"); !ok || val != 2 {
+		t.Fatalf("expected subtrie to be isolated, got %v", val)
+	}
+	if tr.Subtrie("/missing") != nil {
+		t.Fatal("expected nil subtrie for a missing key path")
+	}
+
+	tr.Delete("/")
+	if keys := tr.Keys(""); len(keys) != 0 {
+		t.Fatalf("expected empty trie, got %v", keys)
+	}
+	if keys := strings.Join(snap.Keys("/a"), ","); keys != "x,y" {
+		t.Fatalf("unexpected snapshot keys: %s", keys)
+	}
+}
+
+// TestNodeCollision tests keys with equal hashes are stored side by side.
+func TestNodeCollision(t *testing.T) {
+	const hash = uint32(0xdeadbeef)
+	n := emptyNode.
+		put(hash, "a", 1, 0).
+		put(hash, "b", 2, 0).
+		put(hash, "c", 3, 0).
+		put(hash, "b", 4, 0)
+
+	for key, expected := range map[string]int{"a": 1, "b": 4, "c": 3} {
+		if val, ok := n.get(hash, key, 0); !ok || val != expected {
+			t.Fatalf("%s: expected %d, got %v", key, expected, val)
+		}
+	}
+	if _, ok := n.get(hash, "d", 0); ok {
+		t.Fatal("expected d to be missing")
+	}
+
+	var count int
+	n.each(func(l *leaf) { count++ })
+	if count != 3 {
+		t.Fatalf("expected 3 leaves, got %d", count)
+	}
+
+	n = n.remove(hash, "a", 0).remove(hash, "c", 0)
+	if val, ok := n.get(hash, "b", 0); !ok || val != 4 {
+		t.Fatalf("expected b after removing the colliding keys, got %v", val)
+	}
+	if l := n.single(); l == nil || l.key != "b" {
+		t.Fatal("expected the collision to collapse to a single leaf")
+	}
+	if n = n.remove(hash, "b", 0); !n.isEmpty() {
+		t.Fatal("expected empty node")
+	}
+}
+
+// TestNodePartialCollision tests keys sharing the low hash bits.
+func TestNodePartialCollision(t *testing.T) {
+	n := emptyNode.
+		put(0x00000001, "a", 1, 0).
+		put(0x00000021, "b", 2, 0).
+		put(0x80000001, "c", 3, 0)
+
+	for _, c := range []struct {
+		hash uint32
+		key  string
+		val  int
+	}{{0x00000001, "a", 1}, {0x00000021, "b", 2}, {0x80000001, "c", 3}} {
+		if val, ok := n.get(c.hash, c.key, 0); !ok || val != c.val {
+			t.Fatalf("%s: expected %d, got %v", c.key, c.val, val)
+		}
+	}
+
+	n = n.remove(0x00000021, "b", 0).remove(0x80000001, "c", 0)
+	if len(n.slots) != 1 || n.slots[0].child != nil {
+		t.Fatal("expected the remaining leaf to be pulled up")
+	}
+}
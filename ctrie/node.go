@@ -0,0 +1,205 @@
+package ctrie
+
+import (
+	"math/bits"
+)
+
+const (
+	// bitsPerLevel is the number of hash bits consumed per trie level.
+	bitsPerLevel = 5
+	// levelMask masks the hash bits of a level.
+	levelMask = 1<<bitsPerLevel - 1
+	// hashBits is the number of bits in a hash.
+	hashBits = 32
+)
+
+// emptyNode is the empty node.
+var emptyNode = &node{}
+
+// leaf is a key and value in the trie.
+type leaf struct {
+	hash  uint32
+	key   string
+	value interface{}
+}
+
+// slot is an entry in a node, either a leaf or a child node.
+type slot struct {
+	leaf  *leaf
+	child *node
+}
+
+// node is an immutable hash array mapped trie node.
+// Nodes below the last hash level hold colliding leaves in a list instead.
+type node struct {
+	bitmap    uint32
+	slots     []slot
+	collision []*leaf
+}
+
+// isCollision checks if depth is below the last hash level.
+func isCollision(depth uint) bool {
+	return depth*bitsPerLevel >= hashBits
+}
+
+// position returns the bit and slot index for the hash at depth.
+func (n *node) position(hash uint32, depth uint) (uint32, int) {
+	bit := uint32(1) << ((hash >> (depth * bitsPerLevel)) & levelMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// get returns the value for the key.
+func (n *node) get(hash uint32, key string, depth uint) (interface{}, bool) {
+	for {
+		if isCollision(depth) {
+			for _, l := range n.collision {
+				if l.key == key {
+					return l.value, true
+				}
+			}
+			return nil, false
+		}
+
+		bit, idx := n.position(hash, depth)
+		if n.bitmap&bit == 0 {
+			return nil, false
+		}
+		s := n.slots[idx]
+		if s.child == nil {
+			if s.leaf.key == key {
+				return s.leaf.value, true
+			}
+			return nil, false
+		}
+		n = s.child
+		depth++
+	}
+}
+
+// put returns a copy of the node with the key set to the value.
+func (n *node) put(hash uint32, key string, value interface{}, depth uint) *node {
+	if isCollision(depth) {
+		collision := make([]*leaf, 0, len(n.collision)+1)
+		for _, l := range n.collision {
+			if l.key != key {
+				collision = append(collision, l)
+			}
+		}
+		collision = append(collision, &leaf{hash: hash, key: key, value: value})
+		return &node{collision: collision}
+	}
+
+	bit, idx := n.position(hash, depth)
+	if n.bitmap&bit == 0 {
+		slots := make([]slot, len(n.slots)+1)
+		copy(slots, n.slots[:idx])
+		slots[idx] = slot{leaf: &leaf{hash: hash, key: key, value: value}}
+		copy(slots[idx+1:], n.slots[idx:])
+		return &node{bitmap: n.bitmap | bit, slots: slots}
+	}
+
+	s := n.slots[idx]
+	switch {
+	case s.child != nil:
+		s = slot{child: s.child.put(hash, key, value, depth+1)}
+	case s.leaf.key == key:
+		s = slot{leaf: &leaf{hash: hash, key: key, value: value}}
+	default:
+		// split the existing leaf into a child node.
+		child := emptyNode.
+			put(s.leaf.hash, s.leaf.key, s.leaf.value, depth+1).
+			put(hash, key, value, depth+1)
+		s = slot{child: child}
+	}
+	return n.withSlot(idx, s)
+}
+
+// remove returns a copy of the node without the key.
+// Returns n if the key is not present.
+func (n *node) remove(hash uint32, key string, depth uint) *node {
+	if isCollision(depth) {
+		for i, l := range n.collision {
+			if l.key == key {
+				collision := make([]*leaf, 0, len(n.collision)-1)
+				collision = append(collision, n.collision[:i]...)
+				collision = append(collision, n.collision[i+1:]...)
+				return &node{collision: collision}
+			}
+		}
+		return n
+	}
+
+	bit, idx := n.position(hash, depth)
+	if n.bitmap&bit == 0 {
+		return n
+	}
+
+	s := n.slots[idx]
+	if s.child == nil {
+		if s.leaf.key != key {
+			return n
+		}
+		return n.withoutSlot(idx, bit)
+	}
+
+	child := s.child.remove(hash, key, depth+1)
+	if child == s.child {
+		return n
+	}
+	if l := child.single(); l != nil {
+		return n.withSlot(idx, slot{leaf: l})
+	}
+	if child.isEmpty() {
+		return n.withoutSlot(idx, bit)
+	}
+	return n.withSlot(idx, slot{child: child})
+}
+
+// withSlot returns a copy of the node with the slot at idx replaced.
+func (n *node) withSlot(idx int, s slot) *node {
+	slots := make([]slot, len(n.slots))
+	copy(slots, n.slots)
+	slots[idx] = s
+	return &node{bitmap: n.bitmap, slots: slots}
+}
+
+// withoutSlot returns a copy of the node with the slot at idx removed.
+func (n *node) withoutSlot(idx int, bit uint32) *node {
+	if len(n.slots) == 1 {
+		return emptyNode
+	}
+	slots := make([]slot, 0, len(n.slots)-1)
+	slots = append(slots, n.slots[:idx]...)
+	slots = append(slots, n.slots[idx+1:]...)
+	return &node{bitmap: n.bitmap &^ bit, slots: slots}
+}
+
+// single returns the leaf if the node contains exactly one leaf.
+func (n *node) single() *leaf {
+	if len(n.collision) == 1 {
+		return n.collision[0]
+	}
+	if len(n.slots) == 1 && n.slots[0].child == nil {
+		return n.slots[0].leaf
+	}
+	return nil
+}
+
+// isEmpty checks if the node contains no leaves.
+func (n *node) isEmpty() bool {
+	return len(n.slots) == 0 && len(n.collision) == 0
+}
+
+// each calls cb for each leaf in the node.
+func (n *node) each(cb func(l *leaf)) {
+	for _, l := range n.collision {
+		cb(l)
+	}
+	for _, s := range n.slots {
+		if s.child != nil {
+			s.child.each(cb)
+		} else {
+			cb(s.leaf)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package diff contains the change type returned by the code generated with
+// the diff generator.
+package diff
+
+import (
+	"fmt"
+)
+
+// Kind is the kind of a change.
+type Kind int
+
+const (
+	// Added indicates a value is set in the new object and not in the old.
+	Added Kind = iota
+	// Removed indicates a value is set in the old object and not in the new.
+	Removed
+	// Modified indicates a value differs between the objects.
+	Modified
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Change is a difference between two objects.
+type Change struct {
+	// Path is the key path of the value, in the key/value layout, for
+	// example /lower_kv/test/value. Map keys are path escaped.
+	Path string
+	// Kind is the kind of change.
+	Kind Kind
+	// Old is the value in the old object, nil if Added.
+	// Message and map values are the interface types of the old object.
+	Old interface{}
+	// New is the value in the new object, nil if Removed.
+	// Message and map values are the interface types of the new object.
+	New interface{}
+}
+
+// String formats the change for display.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added %s: %s", c.Path, formatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("removed %s: %s", c.Path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// formatValue formats a value for display.
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", val)
+	case []byte:
+		return fmt.Sprintf("%x", val)
+	case nil:
+		return "nil"
+	case bool, int32, int64, uint32, uint64, float32, float64:
+		return fmt.Sprint(val)
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
@@ -2,24 +2,41 @@ package gettingstarted
 
 // IStringExampleMap is the map type for map<string, IExample>
 type IStringExampleMap interface {
+	IStringExampleMapReader
+	IStringExampleMapWriter
 	Get(key string) IExample
-	Set(key string, val IExample)
 	ForEach(cb func(key string, val IExample) bool) bool
 }
 
+// IStringExampleMapReader is the read-only part of IStringExampleMap.
+type IStringExampleMapReader interface {
+	GetReader(key string) IExampleReader
+	ForEachReader(cb func(key string, val IExampleReader) bool) bool
+}
+
+// IStringExampleMapWriter is the mutating part of IStringExampleMap.
+type IStringExampleMapWriter interface {
+	Set(key string, val IExample)
+}
+
 // StringExampleMap satisfies IStringExampleMap.
 type StringExampleMap map[string]*Example
 
 // Get returns a value from the map.
 func (m StringExampleMap) Get(key string) IExample {
-	if m == nil {
+	v := m[key]
+	if v == nil {
 		return nil
 	}
-	return m[key]
+	return v
 }
 
 // Set sets a value in the map.
 func (m StringExampleMap) Set(key string, value IExample) {
+	if value == nil {
+		m[key] = nil
+		return
+	}
 	m[key] = value.(*Example)
 }
 
@@ -34,20 +51,56 @@ func (m StringExampleMap) ForEach(cb func(key string, val IExample) bool) bool {
 	return true
 }
 
+// GetReader returns a value from the map as an IExampleReader.
+func (m StringExampleMap) GetReader(key string) IExampleReader {
+	return m.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as IExampleReader.
+func (m StringExampleMap) ForEachReader(cb func(key string, val IExampleReader) bool) bool {
+	return m.ForEach(func(key string, val IExample) bool {
+		return cb(key, val)
+	})
+}
+
 // IExample is the interface type for Example.
 type IExample interface {
+	IExampleReader
+	IExampleWriter
+}
+
+// IExampleReader is the read-only part of IExample.
+type IExampleReader interface {
+}
+
+// IExampleWriter is the mutating part of IExample.
+type IExampleWriter interface {
 }
 
 func (m *Example) ToIExample() IExample {
 	return (IExample)(m)
 }
 
+// _ is a type assertion
+var _ IExample = &Example{}
+
 // IHello is the interface type for Hello.
 // Hello is a hello message.
 type IHello interface {
+	IHelloReader
+	IHelloWriter
+	GetMapFieldInter() IStringExampleMap
+}
+
+// IHelloReader is the read-only part of IHello.
+type IHelloReader interface {
 	GetSubject() string
+	GetMapFieldReader() IStringExampleMapReader
+}
+
+// IHelloWriter is the mutating part of IHello.
+type IHelloWriter interface {
 	SetSubject(val string)
-	GetMapFieldInter() IStringExampleMap
 	SetMapField(val IStringExampleMap)
 	NewMapField() IStringExampleMap
 }
@@ -60,11 +113,8 @@ func (m *Hello) SetSubject(val string) {
 	m.Subject = val
 }
 
-// _ is a type assertion
-var _ IHello = &Hello{}
-
 func (m *Hello) NewMapField() IStringExampleMap {
-	return &StringExampleMap{}
+	return make(StringExampleMap)
 }
 
 func (m *Hello) GetMapFieldInter() IStringExampleMap {
@@ -72,8 +122,17 @@ func (m *Hello) GetMapFieldInter() IStringExampleMap {
 }
 
 func (m *Hello) SetMapField(val IStringExampleMap) {
+	if val == nil {
+		m.MapField = nil
+		return
+	}
 	m.MapField = (map[string]*Example)(val.(StringExampleMap))
 }
 
+// GetMapFieldReader returns map_field as an IStringExampleMapReader.
+func (m *Hello) GetMapFieldReader() IStringExampleMapReader {
+	return m.GetMapFieldInter()
+}
+
 // _ is a type assertion
 var _ IHello = &Hello{}
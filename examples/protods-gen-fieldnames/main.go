@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/paralin/protods/generate/plugin"
+	"github.com/paralin/protods/parser"
+)
+
+// generator generates a list of the field names of each message.
+type generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *generator) GetUsage() string {
+	return "generates lists of message field names"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *generator) GetShortName() string {
+	return "fieldnames"
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	var outp bytes.Buffer
+	outp.WriteString("package ")
+	outp.WriteString(pf.PackageName)
+	outp.WriteString("\n")
+
+	for _, message := range pf.Messages {
+		outp.WriteString("\n// ")
+		outp.WriteString(message.Name)
+		outp.WriteString("FieldNames are the proto field names of ")
+		outp.WriteString(message.Name)
+		outp.WriteString(".\nvar ")
+		outp.WriteString(message.Name)
+		outp.WriteString("FieldNames = []string{\n")
+		for _, field := range message.Fields {
+			outp.WriteString("\t")
+			outp.WriteString(strconv.Quote(field.Name))
+			outp.WriteString(",\n")
+		}
+		outp.WriteString("}\n")
+	}
+
+	return outp.Bytes(), nil
+}
+
+func main() {
+	plugin.Serve(&generator{})
+}
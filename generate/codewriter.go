@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"bytes"
+
+	"github.com/paralin/protods/parser"
+)
+
+// CodeWriter accumulates generated Go code for a proto file.
+// It tracks the source map and the imports of the code.
+type CodeWriter struct {
+	bytes.Buffer
+
+	// Imports are the imports referenced by the code.
+	Imports *Imports
+	// SourceMap attributes the code to proto constructs.
+	SourceMap *SourceMap
+}
+
+// NewCodeWriter builds a new code writer and writes the package clause.
+// The type names declared for the proto file are reserved in the imports.
+func NewCodeWriter(pf *parser.File) *CodeWriter {
+	w := &CodeWriter{
+		Imports:   NewImports(),
+		SourceMap: NewSourceMap(),
+	}
+
+	w.Imports.Reserve(pf.PackageName)
+	for i := range pf.Messages {
+		w.Imports.Reserve(pf.Messages[i].Name, pf.Messages[i].InterName)
+	}
+	for i := range pf.Maps {
+		w.Imports.Reserve(pf.Maps[i].TypeName, pf.Maps[i].ImplName)
+	}
+
+	w.WriteString("package ")
+	w.WriteString(pf.PackageName)
+	w.WriteString("\n")
+	return w
+}
+
+// Mark records that the code written next was generated from origin.
+func (w *CodeWriter) Mark(origin Origin) {
+	w.SourceMap.Mark(w.Len(), origin)
+}
+
+// Qualify returns the qualified identifier for ident in the package at
+// importPath, for example "sync.RWMutex", and records the import.
+func (w *CodeWriter) Qualify(importPath, ident string) string {
+	return w.Imports.Qualify(importPath, ident)
+}
+
+// Finish inserts the import block and returns the code and source map.
+func (w *CodeWriter) Finish() ([]byte, *SourceMap, error) {
+	return InsertImports(w.Bytes(), w.Imports, w.SourceMap), w.SourceMap, nil
+}
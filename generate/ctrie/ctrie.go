@@ -0,0 +1,343 @@
+package ctrie
+
+import (
+	"strconv"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "ctrie"
+
+// runtimePath is the import path of the ctrie runtime package.
+const runtimePath = "github.com/paralin/protods/ctrie"
+
+// Generator generates implementations of the interfaces stored in a
+// concurrent hash trie, with constant time snapshots.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates concurrent hash trie backed types with constant time snapshots"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMap(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessage(outp, &pf.Messages[mi])
+	}
+
+	return outp.Finish()
+}
+
+// ctrieName returns the name of the ctrie type for a message or map
+// implementation type name.
+func ctrieName(name string) string {
+	return name + "Ctrie"
+}
+
+// writeStruct writes a ctrie struct type and its constructors.
+// copyFields writes the body copying val into c for a non-ctrie value.
+func writeStruct(outp *generate.CodeWriter, name, inter string, copyFields func()) {
+	trieType := outp.Qualify(runtimePath, "Trie")
+	newTrie := outp.Qualify(runtimePath, "New")
+
+	// FooCtrie is an IFoo stored in a concurrent hash trie.
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is an ")
+	outp.WriteString(inter)
+	outp.WriteString(" stored in a concurrent hash trie.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\ttrie   *")
+	outp.WriteString(trieType)
+	outp.WriteString("\n\tprefix string\n")
+	outp.WriteString("}\n")
+
+	// func NewFooCtrie() *FooCtrie
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a new empty ")
+	outp.WriteString(name)
+	outp.WriteString(".\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("() *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{trie: ")
+	outp.WriteString(newTrie)
+	outp.WriteString("()}\n}\n")
+
+	// func NewFooCtrieFrom(val IFoo) *FooCtrie
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString("From copies val into a new ")
+	outp.WriteString(name)
+	outp.WriteString(".\n")
+	outp.WriteString("// Shares structure with val in constant time if it is a ")
+	outp.WriteString(name)
+	outp.WriteString(".\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("From(val ")
+	outp.WriteString(inter)
+	outp.WriteString(") *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n")
+	outp.WriteString("\tif v, ok := val.(*")
+	outp.WriteString(name)
+	outp.WriteString("); ok {\n")
+	outp.WriteString("\t\tif sub := v.trie.Subtrie(v.prefix); sub != nil {\n")
+	outp.WriteString("\t\t\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{trie: sub}\n\t\t}\n\t}\n\n")
+	outp.WriteString("\tc := New")
+	outp.WriteString(name)
+	outp.WriteString("()\n")
+	copyFields()
+	outp.WriteString("\treturn c\n}\n")
+
+	// _ is a type assertion
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(inter)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeLoad writes a function body returning the value at the key path.
+// Message and map values are a view of the subtree at the key path.
+// Byte slices are copied, the trie shares values with its snapshots.
+func writeLoad(outp *generate.CodeWriter, pathExpr string, kind parser.FieldKind, goType, valueCtrie string) {
+	if kind == parser.FieldKindScalar {
+		outp.WriteString("\tv, _ := s.trie.Load(")
+		outp.WriteString(pathExpr)
+		outp.WriteString(")\n")
+		outp.WriteString("\tval, _ := v.(")
+		outp.WriteString(goType)
+		outp.WriteString(")\n")
+		if goType == "[]byte" {
+			outp.WriteString("\treturn append([]byte(nil), val...)\n")
+		} else {
+			outp.WriteString("\treturn val\n")
+		}
+		return
+	}
+
+	outp.WriteString("\tkeyPath := ")
+	outp.WriteString(pathExpr)
+	outp.WriteString("\n")
+	outp.WriteString("\tif !s.trie.Has(keyPath) {\n\t\treturn nil\n\t}\n")
+	outp.WriteString("\treturn &")
+	outp.WriteString(valueCtrie)
+	outp.WriteString("{trie: s.trie, prefix: keyPath}\n")
+}
+
+// writeStore writes a function body storing val at the key path.
+// Message and map values are copied into the trie as a subtree.
+// Byte slices are copied, the caller may modify val afterwards.
+func writeStore(outp *generate.CodeWriter, pathExpr string, kind parser.FieldKind, goType, valueCtrie string) {
+	if kind == parser.FieldKindScalar {
+		outp.WriteString("\ts.trie.Store(")
+		outp.WriteString(pathExpr)
+		if goType == "[]byte" {
+			outp.WriteString(", append([]byte(nil), val...))\n")
+		} else {
+			outp.WriteString(", val)\n")
+		}
+		return
+	}
+
+	outp.WriteString("\tkeyPath := ")
+	outp.WriteString(pathExpr)
+	outp.WriteString("\n")
+	outp.WriteString("\tif val == nil {\n\t\ts.trie.Delete(keyPath)\n\t\treturn\n\t}\n")
+	outp.WriteString("\ts.trie.Graft(keyPath, New")
+	outp.WriteString(valueCtrie)
+	outp.WriteString("From(val).trie)\n")
+}
+
+// writeMap writes the ctrie type for a map type.
+func writeMap(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := ctrieName(mapt.ImplName)
+	writeStruct(outp, name, mapt.TypeName, func() {
+		outp.WriteString("\tval.ForEach(func(key string, v ")
+		outp.WriteString(mapt.Value)
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tc.Set(key, v)\n\t\treturn true\n\t})\n")
+	})
+
+	pathEscape := outp.Qualify("net/url", "PathEscape")
+	pathUnescape := outp.Qualify("net/url", "PathUnescape")
+	var valueCtrie string
+	if mapt.ValueKind == parser.FieldKindMessage {
+		valueCtrie = ctrieName(mapt.ValueMessage)
+	}
+	pathExpr := "s.prefix + \"/\" + " + pathEscape + "(key)"
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	writeLoad(outp, pathExpr, mapt.ValueKind, mapt.Value, valueCtrie)
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	writeStore(outp, pathExpr, mapt.ValueKind, mapt.Value, valueCtrie)
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over a snapshot of the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	outp.WriteString("\tsnap := New")
+	outp.WriteString(name)
+	outp.WriteString("From(s)\n")
+	outp.WriteString("\tfor _, k := range snap.trie.Keys(\"\") {\n")
+	outp.WriteString("\t\tkey, err := ")
+	outp.WriteString(pathUnescape)
+	outp.WriteString("(k)\n")
+	outp.WriteString("\t\tif err != nil {\n\t\t\tcontinue\n\t\t}\n")
+	outp.WriteString("\t\tif !cb(key, snap.Get(key)) {\n\t\t\treturn false\n\t\t}\n")
+	outp.WriteString("\t}\n\n\treturn true\n}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeMessage writes the ctrie type for a message.
+func writeMessage(outp *generate.CodeWriter, message *parser.Message) {
+	name := ctrieName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+	writeStruct(outp, name, message.InterName, func() {
+		for fi := range message.Fields {
+			field := &message.Fields[fi]
+			if !field.IsSupported() {
+				continue
+			}
+			outp.WriteString("\tc.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(val.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("())\n")
+		}
+	})
+
+	// func (s *FooCtrie) Snapshot() IFoo
+	outp.WriteString("\n// Snapshot returns an independent copy of the object in constant time.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Snapshot() ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(" {\n\treturn New")
+	outp.WriteString(name)
+	outp.WriteString("From(s)\n}\n")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		pathExpr := "s.prefix + " + strconv.Quote(field.KeyPath)
+		var fieldCtrie string
+		switch field.Kind {
+		case parser.FieldKindMessage:
+			fieldCtrie = ctrieName(field.Message)
+		case parser.FieldKindMap:
+			fieldCtrie = ctrieName(field.Map.ImplName)
+		}
+
+		// func (s *HelloCtrie) GetSubject() string
+		outp.WriteString("\n// ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString(" returns ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n")
+		writeLoad(outp, pathExpr, field.Kind, field.GoType, fieldCtrie)
+		outp.WriteString("}\n")
+
+		// func (s *HelloCtrie) SetSubject(val string)
+		outp.WriteString("\n// ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString(" sets ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(") {\n")
+		writeStore(outp, pathExpr, field.Kind, field.GoType, fieldCtrie)
+		outp.WriteString("}\n")
+
+		// func (s *HelloCtrie) NewExample() IExample
+		if field.NewName != "" {
+			outp.WriteString("\n// ")
+			outp.WriteString(field.NewName)
+			outp.WriteString(" builds a new empty ")
+			outp.WriteString(field.Name)
+			outp.WriteString(".\n")
+			outp.WriteString("func (s *")
+			outp.WriteString(name)
+			outp.WriteString(") ")
+			outp.WriteString(field.NewName)
+			outp.WriteString("() ")
+			outp.WriteString(field.GoType)
+			outp.WriteString(" {\n\treturn New")
+			outp.WriteString(fieldCtrie)
+			outp.WriteString("()\n}\n")
+		}
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,201 @@
+package deepcopy
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "copy"
+
+// Generator generates functions deep copying objects through the interface
+// types, so any backend can be populated from any other.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates deep copy functions between any two implementations of the interfaces"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapCopy(outp, mapt, CopyName)
+	}
+	for mi := range pf.Messages {
+		message := &pf.Messages[mi]
+		writeMessageCopy(outp, message, CopyName)
+		writeToProto(outp, message)
+	}
+	return outp.Finish()
+}
+
+// WriteCopyFuncs writes the copy functions for the maps and messages, named
+// with copyName, for generators copying values between implementations.
+func WriteCopyFuncs(outp *generate.CodeWriter, pf *parser.File, copyName func(name string) string) {
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapCopy(outp, mapt, copyName)
+	}
+	for mi := range pf.Messages {
+		writeMessageCopy(outp, &pf.Messages[mi], copyName)
+	}
+}
+
+// CopyName returns the name of the copy function for a message or map
+// implementation type name.
+func CopyName(name string) string {
+	return "Copy" + name
+}
+
+// copyValue returns the expression copying a scalar value.
+// Byte slices are cloned, the other scalar types are values.
+func copyValue(goType, expr string) string {
+	if goType == "[]byte" {
+		return "append([]byte(nil), " + expr + "...)"
+	}
+	return expr
+}
+
+// writeMapCopy writes the copy function for a map type.
+func writeMapCopy(outp *generate.CodeWriter, mapt *parser.Map, copyName func(string) string) {
+	name := copyName(mapt.ImplName)
+
+	// func CopyStringExampleMap(dst, src IStringExampleMap)
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" sets the entries of src in dst.\n")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString("// Message values are set to an empty ")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString(" and filled in through dst.Get,\n")
+		outp.WriteString("// so they are stored by the implementation of dst.\n")
+	}
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(dst, src ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") {\n")
+	outp.WriteString("\tsrc.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString("\t\tif val == nil {\n\t\t\tdst.Set(key, nil)\n\t\t\treturn true\n\t\t}\n")
+		outp.WriteString("\t\tdst.Set(key, &")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("{})\n")
+		outp.WriteString("\t\tif v := dst.Get(key); v != nil {\n")
+		outp.WriteString("\t\t\t")
+		outp.WriteString(copyName(mapt.ValueMessage))
+		outp.WriteString("(v, val)\n")
+		outp.WriteString("\t\t}\n")
+	} else {
+		outp.WriteString("\t\tdst.Set(key, ")
+		outp.WriteString(copyValue(mapt.Value, "val"))
+		outp.WriteString(")\n")
+	}
+	outp.WriteString("\t\treturn true\n\t})\n}\n")
+}
+
+// writeMessageCopy writes the copy function for a message.
+func writeMessageCopy(outp *generate.CodeWriter, message *parser.Message, copyName func(string) string) {
+	name := copyName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+
+	// func CopyExample(dst, src IExample)
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" sets the fields of dst to deep copies of the fields of src.\n")
+	outp.WriteString("// Message and map fields are built with the constructors of dst.\n")
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(dst, src ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") {\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\tdst.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(")
+			outp.WriteString(copyValue(field.GoType, "src."+field.GetterName+"()"))
+			outp.WriteString(")\n")
+			continue
+		}
+
+		nestedName := copyName(field.Message)
+		if field.Map != nil {
+			nestedName = copyName(field.Map.ImplName)
+		}
+		outp.WriteString("\tif v := src.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(); v != nil {\n")
+		outp.WriteString("\t\tc := dst.")
+		outp.WriteString(field.NewName)
+		outp.WriteString("()\n")
+		outp.WriteString("\t\t")
+		outp.WriteString(nestedName)
+		outp.WriteString("(c, v)\n")
+		outp.WriteString("\t\tdst.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(c)\n")
+		outp.WriteString("\t} else {\n\t\tdst.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(nil)\n\t}\n")
+	}
+	outp.WriteString("}\n")
+}
+
+// writeToProto writes the function materializing a message as the proto type.
+func writeToProto(outp *generate.CodeWriter, message *parser.Message) {
+	// func ToProtoExample(src IExample) *Example
+	outp.Mark(generate.MessageOrigin(message))
+	outp.WriteString("\n// ToProto")
+	outp.WriteString(message.Name)
+	outp.WriteString(" returns a deep copy of src as a ")
+	outp.WriteString(message.Name)
+	outp.WriteString(", or nil if src is nil.\n")
+	outp.WriteString("func ToProto")
+	outp.WriteString(message.Name)
+	outp.WriteString("(src ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") *")
+	outp.WriteString(message.Name)
+	outp.WriteString(" {\n")
+	outp.WriteString("\tif src == nil {\n\t\treturn nil\n\t}\n")
+	outp.WriteString("\tm := &")
+	outp.WriteString(message.Name)
+	outp.WriteString("{}\n\t")
+	outp.WriteString(CopyName(message.Name))
+	outp.WriteString("(m, src)\n")
+	outp.WriteString("\treturn m\n}\n")
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,315 @@
+package descriptor
+
+import (
+	"strconv"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "descriptor"
+
+// runtimePath is the import path of the runtime package.
+const runtimePath = "github.com/paralin/protods/runtime"
+
+// Generator generates descriptor tables for the messages and maps, registered
+// with the runtime package for generic access to the fields.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates field descriptor tables for generic access with the runtime package"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+
+	// the descriptors reference each other, and messages can be recursive, so
+	// the variables are declared first and filled in the init function.
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapVar(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		message := &pf.Messages[mi]
+		outp.Mark(generate.MessageOrigin(message))
+		writeMessageVar(outp, message)
+	}
+
+	outp.WriteString("\nfunc init() {\n")
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapInit(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageInit(outp, &pf.Messages[mi])
+	}
+	outp.WriteString("}\n")
+	return outp.Finish()
+}
+
+// DescriptorName returns the name of the descriptor variable for a message
+// or map implementation type name.
+func DescriptorName(name string) string {
+	return name + "Descriptor"
+}
+
+// kindConst returns the runtime kind constant for a field kind.
+func kindConst(outp *generate.CodeWriter, kind parser.FieldKind) string {
+	switch kind {
+	case parser.FieldKindMessage:
+		return outp.Qualify(runtimePath, "KindMessage")
+	case parser.FieldKindMap:
+		return outp.Qualify(runtimePath, "KindMap")
+	default:
+		return outp.Qualify(runtimePath, "KindScalar")
+	}
+}
+
+// writeMapVar writes the descriptor variable for a map type.
+func writeMapVar(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := DescriptorName(mapt.ImplName)
+
+	// var StringExampleMapDescriptor = &runtime.MapDescriptor{...}
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" describes ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(".\n")
+	outp.WriteString("var ")
+	outp.WriteString(name)
+	outp.WriteString(" = &")
+	outp.WriteString(outp.Qualify(runtimePath, "MapDescriptor"))
+	outp.WriteString("{\n\tName:        ")
+	outp.WriteString(strconv.Quote(mapt.ImplName))
+	outp.WriteString(",\n\tValueKind:   ")
+	outp.WriteString(kindConst(outp, mapt.ValueKind))
+	outp.WriteString(",\n\tValueGoType: ")
+	outp.WriteString(strconv.Quote(mapt.Value))
+	outp.WriteString(",\n}\n")
+}
+
+// writeMessageVar writes the descriptor variable for a message.
+func writeMessageVar(outp *generate.CodeWriter, message *parser.Message) {
+	name := DescriptorName(message.Name)
+
+	// var ExampleDescriptor = &runtime.MessageDescriptor{...}
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" describes ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(".\n")
+	outp.WriteString("var ")
+	outp.WriteString(name)
+	outp.WriteString(" = &")
+	outp.WriteString(outp.Qualify(runtimePath, "MessageDescriptor"))
+	outp.WriteString("{Name: ")
+	outp.WriteString(strconv.Quote(message.Name))
+	outp.WriteString("}\n")
+}
+
+// writeCheckValue writes converting val to the Go type into v, returning an
+// invalid value error otherwise. Message and map values may be nil.
+func writeCheckValue(outp *generate.CodeWriter, indent, name, goType string, nillable bool) {
+	if nillable {
+		outp.WriteString(indent)
+		outp.WriteString("var v ")
+		outp.WriteString(goType)
+		outp.WriteString("\n")
+		outp.WriteString(indent)
+		outp.WriteString("if val != nil {\n")
+		outp.WriteString(indent)
+		outp.WriteString("\tvar ok bool\n")
+		outp.WriteString(indent)
+		outp.WriteString("\tif v, ok = val.(")
+		outp.WriteString(goType)
+		outp.WriteString("); !ok {\n")
+		outp.WriteString(indent)
+		outp.WriteString("\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidValue"))
+		outp.WriteString("(")
+		outp.WriteString(strconv.Quote(name))
+		outp.WriteString(", val)\n")
+		outp.WriteString(indent)
+		outp.WriteString("\t}\n")
+		outp.WriteString(indent)
+		outp.WriteString("}\n")
+		return
+	}
+	outp.WriteString(indent)
+	outp.WriteString("v, ok := val.(")
+	outp.WriteString(goType)
+	outp.WriteString(")\n")
+	outp.WriteString(indent)
+	outp.WriteString("if !ok {\n")
+	outp.WriteString(indent)
+	outp.WriteString("\treturn ")
+	outp.WriteString(outp.Qualify(runtimePath, "InvalidValue"))
+	outp.WriteString("(")
+	outp.WriteString(strconv.Quote(name))
+	outp.WriteString(", val)\n")
+	outp.WriteString(indent)
+	outp.WriteString("}\n")
+}
+
+// writeMapInit writes filling the descriptor of a map type.
+func writeMapInit(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := DescriptorName(mapt.ImplName)
+	isMsg := mapt.ValueKind == parser.FieldKindMessage
+
+	if isMsg {
+		outp.WriteString("\t")
+		outp.WriteString(name)
+		outp.WriteString(".ValueMessage = ")
+		outp.WriteString(DescriptorName(mapt.ValueMessage))
+		outp.WriteString("\n")
+	}
+
+	// Get
+	outp.WriteString("\t")
+	outp.WriteString(name)
+	outp.WriteString(".Get = func(m interface{}, key string) interface{} {\n")
+	outp.WriteString("\t\treturn m.(")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(").Get(key)\n\t}\n")
+
+	// Set
+	outp.WriteString("\t")
+	outp.WriteString(name)
+	outp.WriteString(".Set = func(m interface{}, key string, val interface{}) error {\n")
+	writeCheckValue(outp, "\t\t", mapt.ImplName, mapt.Value, isMsg)
+	outp.WriteString("\t\tm.(")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(").Set(key, v)\n\t\treturn nil\n\t}\n")
+
+	// Range
+	outp.WriteString("\t")
+	outp.WriteString(name)
+	outp.WriteString(".Range = func(m interface{}, cb func(key string, val interface{}) bool) bool {\n")
+	outp.WriteString("\t\treturn m.(")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(").ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\t\t\treturn cb(key, val)\n\t\t})\n\t}\n")
+}
+
+// writeMessageInit writes filling the descriptor of a message, and registering
+// it for the proto type.
+func writeMessageInit(outp *generate.CodeWriter, message *parser.Message) {
+	name := DescriptorName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+
+	// Is
+	outp.WriteString("\t")
+	outp.WriteString(name)
+	outp.WriteString(".Is = func(obj interface{}) bool {\n")
+	outp.WriteString("\t\t_, ok := obj.(")
+	outp.WriteString(message.InterName)
+	outp.WriteString(")\n\t\treturn ok\n\t}\n")
+
+	// Fields
+	outp.WriteString("\t")
+	outp.WriteString(name)
+	outp.WriteString(".Fields = []*")
+	outp.WriteString(outp.Qualify(runtimePath, "FieldDescriptor"))
+	outp.WriteString("{\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeField(outp, message, field)
+	}
+	outp.WriteString("\t}\n")
+
+	outp.Mark(generate.MessageOrigin(message))
+	outp.WriteString("\t")
+	outp.WriteString(outp.Qualify(runtimePath, "Register"))
+	outp.WriteString("(")
+	outp.WriteString(name)
+	outp.WriteString(", (*")
+	outp.WriteString(message.Name)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeField writes the descriptor of a field.
+func writeField(outp *generate.CodeWriter, message *parser.Message, field *parser.Field) {
+	obj := "obj.(" + message.InterName + ")"
+
+	outp.WriteString("\t\t{\n")
+	outp.WriteString("\t\t\tName:    ")
+	outp.WriteString(strconv.Quote(field.Name))
+	outp.WriteString(",\n\t\t\tNumber:  ")
+	outp.WriteString(strconv.Itoa(field.Number))
+	outp.WriteString(",\n\t\t\tKind:    ")
+	outp.WriteString(kindConst(outp, field.Kind))
+	outp.WriteString(",\n\t\t\tGoType:  ")
+	outp.WriteString(strconv.Quote(field.GoType))
+	outp.WriteString(",\n\t\t\tKeyPath: ")
+	outp.WriteString(strconv.Quote(field.KeyPath))
+	outp.WriteString(",\n")
+	switch {
+	case field.Map != nil:
+		outp.WriteString("\t\t\tMap:     ")
+		outp.WriteString(DescriptorName(field.Map.ImplName))
+		outp.WriteString(",\n")
+	case field.Kind == parser.FieldKindMessage:
+		outp.WriteString("\t\t\tMessage: ")
+		outp.WriteString(DescriptorName(field.Message))
+		outp.WriteString(",\n")
+	}
+
+	// Get
+	outp.WriteString("\t\t\tGet: func(obj interface{}) interface{} {\n")
+	outp.WriteString("\t\t\t\treturn ")
+	outp.WriteString(obj)
+	outp.WriteString(".")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("()\n\t\t\t},\n")
+
+	// Set
+	outp.WriteString("\t\t\tSet: func(obj, val interface{}) error {\n")
+	writeCheckValue(outp, "\t\t\t\t", message.Name+"."+field.Name, field.GoType, field.Kind != parser.FieldKindScalar)
+	outp.WriteString("\t\t\t\t")
+	outp.WriteString(obj)
+	outp.WriteString(".")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(v)\n\t\t\t\treturn nil\n\t\t\t},\n")
+
+	// New
+	if field.NewName != "" {
+		outp.WriteString("\t\t\tNew: func(obj interface{}) interface{} {\n")
+		outp.WriteString("\t\t\t\treturn ")
+		outp.WriteString(obj)
+		outp.WriteString(".")
+		outp.WriteString(field.NewName)
+		outp.WriteString("()\n\t\t\t},\n")
+	}
+	outp.WriteString("\t\t},\n")
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,227 @@
+package diff
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/equal"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "diff"
+
+// runtimePath is the import path of the diff runtime package.
+const runtimePath = "github.com/paralin/protods/diff"
+
+// Generator generates functions computing the structural differences
+// between two objects through the interface types.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates structural diff functions between any two implementations of the interfaces"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapDiff(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageDiff(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// appendName returns the name of the function appending the changes for a
+// message or map implementation type name.
+func appendName(name string) string {
+	return "appendDiff" + name
+}
+
+// writeChange writes an append of a change to the changes slice.
+func writeChange(outp *generate.CodeWriter, indent, path, kind, oldVal, newVal string) {
+	outp.WriteString(indent)
+	outp.WriteString("changes = append(changes, ")
+	outp.WriteString(outp.Qualify(runtimePath, "Change"))
+	outp.WriteString("{Path: ")
+	outp.WriteString(path)
+	outp.WriteString(", Kind: ")
+	outp.WriteString(outp.Qualify(runtimePath, kind))
+	if oldVal != "" {
+		outp.WriteString(", Old: ")
+		outp.WriteString(oldVal)
+	}
+	if newVal != "" {
+		outp.WriteString(", New: ")
+		outp.WriteString(newVal)
+	}
+	outp.WriteString("})\n")
+}
+
+// writeMapDiff writes the function appending the changes between two maps.
+func writeMapDiff(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := appendName(mapt.ImplName)
+	changeType := outp.Qualify(runtimePath, "Change")
+
+	// func appendDiffStringExampleMap(changes []diff.Change, path string, a, b IStringExampleMap) []diff.Change
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" appends the changes from a to b below the path, in key order.\n")
+	outp.WriteString("// A nil map is equal to an empty map.\n")
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(changes []")
+	outp.WriteString(changeType)
+	outp.WriteString(", path string, a, b ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") []")
+	outp.WriteString(changeType)
+	outp.WriteString(" {\n")
+	for _, side := range []string{"a", "b"} {
+		outp.WriteString("\t")
+		outp.WriteString(side)
+		outp.WriteString("Entries := make(map[string]")
+		outp.WriteString(mapt.Value)
+		outp.WriteString(")\n\tif ")
+		outp.WriteString(side)
+		outp.WriteString(" != nil {\n\t\t")
+		outp.WriteString(side)
+		outp.WriteString(".ForEach(func(key string, val ")
+		outp.WriteString(mapt.Value)
+		outp.WriteString(") bool {\n\t\t\t")
+		outp.WriteString(side)
+		outp.WriteString("Entries[key] = val\n\t\t\treturn true\n\t\t})\n\t}\n")
+	}
+
+	outp.WriteString("\tkeys := make([]string, 0, len(aEntries)+len(bEntries))\n")
+	outp.WriteString("\tfor key := range aEntries {\n\t\tkeys = append(keys, key)\n\t}\n")
+	outp.WriteString("\tfor key := range bEntries {\n")
+	outp.WriteString("\t\tif _, ok := aEntries[key]; !ok {\n\t\t\tkeys = append(keys, key)\n\t\t}\n\t}\n")
+	outp.WriteString("\t")
+	outp.WriteString(outp.Qualify("sort", "Strings"))
+	outp.WriteString("(keys)\n\n")
+
+	outp.WriteString("\tfor _, key := range keys {\n")
+	outp.WriteString("\t\tkeyPath := path + \"/\" + ")
+	outp.WriteString(outp.Qualify("net/url", "PathEscape"))
+	outp.WriteString("(key)\n")
+	outp.WriteString("\t\tav, aok := aEntries[key]\n")
+	outp.WriteString("\t\tbv, bok := bEntries[key]\n")
+	outp.WriteString("\t\tswitch {\n")
+	outp.WriteString("\t\tcase !bok:\n")
+	writeChange(outp, "\t\t\t", "keyPath", "Removed", "av", "")
+	outp.WriteString("\t\tcase !aok:\n")
+	writeChange(outp, "\t\t\t", "keyPath", "Added", "", "bv")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString("\t\tdefault:\n")
+		outp.WriteString("\t\t\tchanges = ")
+		outp.WriteString(appendName(mapt.ValueMessage))
+		outp.WriteString("(changes, keyPath, av, bv)\n")
+	} else {
+		outp.WriteString("\t\tcase ")
+		outp.WriteString(equal.ScalarNotEqual(outp, mapt.Value, "av", "bv"))
+		outp.WriteString(":\n")
+		writeChange(outp, "\t\t\t", "keyPath", "Modified", "av", "bv")
+	}
+	outp.WriteString("\t\t}\n\t}\n")
+	outp.WriteString("\treturn changes\n}\n")
+}
+
+// writeMessageDiff writes the diff functions for a message.
+func writeMessageDiff(outp *generate.CodeWriter, message *parser.Message) {
+	name := appendName(message.Name)
+	changeType := outp.Qualify(runtimePath, "Change")
+	outp.Mark(generate.MessageOrigin(message))
+
+	// func DiffExample(a, b IExample) []diff.Change
+	outp.WriteString("\n// Diff")
+	outp.WriteString(message.Name)
+	outp.WriteString(" returns the changes from a to b.\n")
+	outp.WriteString("// Paths use the key/value layout, for example /lower_kv/test/value.\n")
+	outp.WriteString("func Diff")
+	outp.WriteString(message.Name)
+	outp.WriteString("(a, b ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") []")
+	outp.WriteString(changeType)
+	outp.WriteString(" {\n\treturn ")
+	outp.WriteString(name)
+	outp.WriteString("(nil, \"\", a, b)\n}\n")
+
+	// func appendDiffExample(changes []diff.Change, path string, a, b IExample) []diff.Change
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" appends the changes from a to b below the path.\n")
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(changes []")
+	outp.WriteString(changeType)
+	outp.WriteString(", path string, a, b ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") []")
+	outp.WriteString(changeType)
+	outp.WriteString(" {\n")
+	outp.WriteString("\tif a == nil || b == nil {\n")
+	outp.WriteString("\t\tif a != nil {\n\t")
+	writeChange(outp, "\t\t", "path", "Removed", "a", "")
+	outp.WriteString("\t\t} else if b != nil {\n\t")
+	writeChange(outp, "\t\t", "path", "Added", "", "b")
+	outp.WriteString("\t\t}\n\t\treturn changes\n\t}\n")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		keyPath := "path + \"" + field.KeyPath + "\""
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\tif av, bv := a.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(), b.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(); ")
+			outp.WriteString(equal.ScalarNotEqual(outp, field.GoType, "av", "bv"))
+			outp.WriteString(" {\n")
+			writeChange(outp, "\t\t", keyPath, "Modified", "av", "bv")
+			outp.WriteString("\t}\n")
+			continue
+		}
+
+		fnName := appendName(field.Message)
+		if field.Map != nil {
+			fnName = appendName(field.Map.ImplName)
+		}
+		outp.WriteString("\tchanges = ")
+		outp.WriteString(fnName)
+		outp.WriteString("(changes, ")
+		outp.WriteString(keyPath)
+		outp.WriteString(", a.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(), b.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("())\n")
+	}
+	outp.WriteString("\treturn changes\n}\n")
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
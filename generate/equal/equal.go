@@ -0,0 +1,183 @@
+package equal
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "equal"
+
+// Generator generates functions comparing objects through the interface
+// types, so any two backends can be compared.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates deep equality functions between any two implementations of the interfaces"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapEqual(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageEqual(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// EqualName returns the name of the equality function for a message or map
+// implementation type name.
+func EqualName(name string) string {
+	return "Equal" + name
+}
+
+// ScalarEqual returns an expression comparing two scalar values, importing
+// the packages it uses.
+// Bytes are compared with bytes.Equal and NaN floats are equal, like
+// proto.Equal.
+func ScalarEqual(outp *generate.CodeWriter, goType, a, b string) string {
+	switch goType {
+	case "[]byte":
+		return outp.Qualify("bytes", "Equal") + "(" + a + ", " + b + ")"
+	case "float32", "float64":
+		isNaN := outp.Qualify("math", "IsNaN")
+		conv := func(v string) string {
+			if goType == "float32" {
+				return "float64(" + v + ")"
+			}
+			return v
+		}
+		return "(" + a + " == " + b + " || " + isNaN + "(" + conv(a) + ") && " + isNaN + "(" + conv(b) + "))"
+	default:
+		return a + " == " + b
+	}
+}
+
+// ScalarNotEqual returns the negation of the ScalarEqual expression.
+func ScalarNotEqual(outp *generate.CodeWriter, goType, a, b string) string {
+	switch goType {
+	case "[]byte", "float32", "float64":
+		return "!" + ScalarEqual(outp, goType, a, b)
+	default:
+		return a + " != " + b
+	}
+}
+
+// writeMapEqual writes the equality function for a map type.
+func writeMapEqual(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := EqualName(mapt.ImplName)
+
+	// func EqualStringExampleMap(a, b IStringExampleMap) bool
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" checks if a and b have the same entries.\n")
+	outp.WriteString("// A nil map is equal to an empty map.\n")
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(a, b ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\tentries := make(map[string]")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(")\n")
+	outp.WriteString("\tif b != nil {\n")
+	outp.WriteString("\t\tb.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n\t\t\tentries[key] = val\n\t\t\treturn true\n\t\t})\n")
+	outp.WriteString("\t}\n")
+	outp.WriteString("\tif a == nil {\n\t\treturn len(entries) == 0\n\t}\n")
+	outp.WriteString("\tcount := 0\n")
+	outp.WriteString("\tequal := a.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\t\tcount++\n")
+	outp.WriteString("\t\tother, ok := entries[key]\n")
+	outp.WriteString("\t\treturn ok && ")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString(EqualName(mapt.ValueMessage))
+		outp.WriteString("(val, other)")
+	} else {
+		outp.WriteString(ScalarEqual(outp, mapt.Value, "val", "other"))
+	}
+	outp.WriteString("\n\t})\n")
+	outp.WriteString("\treturn equal && count == len(entries)\n}\n")
+}
+
+// writeMessageEqual writes the equality function for a message.
+func writeMessageEqual(outp *generate.CodeWriter, message *parser.Message) {
+	name := EqualName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+
+	// func EqualExample(a, b IExample) bool
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" checks if the fields of a and b are deeply equal.\n")
+	outp.WriteString("// Two nil values are equal, a nil value is not equal to an empty value.\n")
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(a, b ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\tif a == nil || b == nil {\n\t\treturn a == nil && b == nil\n\t}\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\tif av, bv := a.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(), b.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(); ")
+			outp.WriteString(ScalarNotEqual(outp, field.GoType, "av", "bv"))
+			outp.WriteString(" {\n\t\treturn false\n\t}\n")
+			continue
+		}
+
+		outp.WriteString("\tif !")
+		switch field.Kind {
+		case parser.FieldKindMap:
+			outp.WriteString(EqualName(field.Map.ImplName))
+			outp.WriteString("(a.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(), b.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("())")
+		default:
+			outp.WriteString(EqualName(field.Message))
+			outp.WriteString("(a.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(), b.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("())")
+		}
+		outp.WriteString(" {\n\t\treturn false\n\t}\n")
+	}
+	outp.WriteString("\treturn true\n}\n")
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -1,14 +1,15 @@
 package generate
 
 import (
+	"bytes"
 	"fmt"
-	"go/format"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
 
-	"github.com/emicklei/proto"
 	"github.com/paralin/protods/parser"
 	"github.com/pkg/errors"
 )
@@ -23,6 +24,63 @@ type Generator interface {
 	GetShortName() string
 }
 
+// MappedGenerator is a Generator that can attribute the generated code to the
+// proto constructs it was generated from, for positioned diagnostics.
+type MappedGenerator interface {
+	Generator
+	// GenerateMappedCode generates code and a source map given the input proto file.
+	GenerateMappedCode(*parser.File) ([]byte, *SourceMap, error)
+}
+
+// FilesGenerator is a Generator producing a set of output files.
+type FilesGenerator interface {
+	Generator
+	// GenerateFiles generates files given the input proto file.
+	// baseName is the proto filename without the .proto suffix.
+	// The output file names are relative to the output directory.
+	GenerateFiles(pf *parser.File, baseName string) ([]*OutputFile, error)
+}
+
+// ParamGenerator is a Generator accepting parameters.
+// On the command line they are passed with --param key=value.
+type ParamGenerator interface {
+	Generator
+	// SetParam sets a generator parameter.
+	// Returns an error if the parameter is unknown or invalid.
+	SetParam(key, value string) error
+	// CloneGenerator returns a copy of the generator.
+	// Parameters set on the copy do not affect the original.
+	CloneGenerator() ParamGenerator
+}
+
+// Options are options for Generate.
+type Options struct {
+	// AllowInvalid writes the generated code even if it fails verification.
+	AllowInvalid bool
+	// SkipTypeCheck skips type checking the generated code against the
+	// protoc-gen-go output in the output directory.
+	SkipTypeCheck bool
+	// Params are parameters passed to a ParamGenerator.
+	Params map[string]string
+	// PackageFS contains the other files of the Go package, such as the
+	// protoc-gen-go output, for type checking the generated code.
+	// GenerateWithOptions defaults to the output directory.
+	PackageFS fs.FS
+}
+
+// ParseParams parses a list of key=value parameters.
+func ParseParams(params []string) (map[string]string, error) {
+	m := make(map[string]string, len(params))
+	for _, param := range params {
+		eq := strings.Index(param, "=")
+		if eq < 1 {
+			return nil, errors.Errorf("expected key=value parameter: %q", param)
+		}
+		m[param[:eq]] = param[eq+1:]
+	}
+	return m, nil
+}
+
 var registeredGenerators = make(map[string]Generator)
 
 // RegisterGenerator registers a generator.
@@ -44,47 +102,183 @@ func ForEachGenerator(cb func(name string, gen Generator) bool) {
 	}
 }
 
-// Generate uses files to generate the proto output.
+// ApplyParams returns a copy of a ParamGenerator with the parameters set.
+// The registered generator is not modified, so calls can run concurrently.
+// Returns the generator itself if there are no params.
+// Returns an error if there are params and the generator does not accept them.
+func ApplyParams(gen Generator, params map[string]string) (Generator, error) {
+	if len(params) == 0 {
+		return gen, nil
+	}
+
+	pgen, ok := gen.(ParamGenerator)
+	if !ok {
+		return nil, errors.Errorf("generator %s does not accept parameters", gen.GetShortName())
+	}
+	pgen = pgen.CloneGenerator()
+	for key, value := range params {
+		if err := pgen.SetParam(key, value); err != nil {
+			return nil, errors.Wrapf(err, "param %s", key)
+		}
+	}
+	return pgen, nil
+}
+
+// Generate uses files to generate the proto output with the default options.
 func Generate(gen Generator, protoPath, outputPath string) error {
-	// Open the protobuf
+	return GenerateWithOptions(gen, protoPath, outputPath, Options{})
+}
+
+// GenerateWithOptions uses files to generate the proto output.
+// The output is type checked against the Go files in outputPath.
+func GenerateWithOptions(gen Generator, protoPath, outputPath string, opts Options) error {
+	f, err := os.Open(protoPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if opts.PackageFS == nil {
+		opts.PackageFS = os.DirFS(outputPath)
+	}
+	outFiles, err := generate(gen, protoPath, f, outputPath, opts)
+	if err != nil {
+		if outFiles == nil {
+			return err
+		}
+		// AllowInvalid is set: report the problems and write the files anyway.
+		_, _ = os.Stderr.WriteString(err.Error())
+		_, _ = os.Stderr.WriteString("\n")
+	}
+
+	// write the output
+	for _, outFile := range outFiles {
+		if err := os.MkdirAll(path.Dir(outFile.Name), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(outFile.Name, outFile.Content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateSource generates code from proto source in memory.
+// protoPath is used for the output filenames and positions in diagnostics.
+// Returns the generated files with names relative to the output directory.
+// Options.PackageFS can provide the protoc-gen-go output for type checking.
+// If Options.AllowInvalid is set, invalid files are returned along with the
+// verification error.
+func GenerateSource(gen Generator, protoPath string, src []byte, opts Options) ([]*OutputFile, error) {
+	return generate(gen, protoPath, bytes.NewReader(src), "", opts)
+}
+
+// GenerateFS generates code from a proto file in a filesystem.
+// Use fstest.MapFS for sources in a map.
+// Returns the generated files with names relative to the output directory.
+// Options.PackageFS can provide the protoc-gen-go output for type checking.
+// If Options.AllowInvalid is set, invalid files are returned along with the
+// verification error.
+func GenerateFS(gen Generator, fsys fs.FS, protoPath string, opts Options) ([]*OutputFile, error) {
+	f, err := fsys.Open(protoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return generate(gen, protoPath, f, "", opts)
+}
+
+// generate parses the proto and runs the generator, verifying the output.
+// The output file names are joined with outputDir.
+// If verification fails and AllowInvalid is set, returns the files and the error.
+func generate(gen Generator, protoPath string, r io.Reader, outputDir string, opts Options) ([]*OutputFile, error) {
 	protoFilename := path.Base(protoPath)
 	if !strings.HasSuffix(protoFilename, ".proto") {
-		return errors.Errorf("expected .proto suffix: %v", protoFilename)
+		return nil, errors.Errorf("expected .proto suffix: %v", protoFilename)
 	}
 
 	protoBaseName := strings.TrimSuffix(protoFilename, ".proto")
 
-	f, err := os.Open(protoPath)
+	gen, err := ApplyParams(gen, opts.Params)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
 
-	pparser := proto.NewParser(f)
-	pparser.Filename(protoFilename)
-	parsedProto, err := pparser.Parse()
+	parsedProto, err := parser.ParseProto(protoPath, r)
 	if err != nil {
-		return errors.Wrap(err, "parse proto")
+		return nil, err
 	}
-	_ = f.Close()
 
 	pf, err := parser.Parse(parsedProto)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	generatedCode, err := gen.GenerateCode(pf)
+	outFiles, err := GenerateFiles(gen, pf, protoBaseName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmtSrc, err := format.Source(generatedCode)
+	// verify with the names relative to the package filesystem.
+	var verifyOpts VerifyOptions
+	if !opts.SkipTypeCheck {
+		verifyOpts.PackageFS = opts.PackageFS
+	}
+	verifyErr := VerifyFiles(outFiles, verifyOpts)
+	if diags, ok := verifyErr.(Diagnostics); ok {
+		for _, diag := range diags {
+			diag.GoPosition.Filename = path.Join(outputDir, diag.GoPosition.Filename)
+		}
+	}
+	if verifyErr != nil && !opts.AllowInvalid {
+		return nil, verifyErr
+	}
+	for _, outFile := range outFiles {
+		outFile.Name = path.Join(outputDir, outFile.Name)
+	}
+
+	return outFiles, verifyErr
+}
+
+// GenerateFiles runs the generator on a parsed proto file without verifying
+// the output. baseName is the proto filename without the .proto suffix.
+// Returns the generated files with names relative to the output directory.
+func GenerateFiles(gen Generator, pf *parser.File, baseName string) ([]*OutputFile, error) {
+	if fgen, ok := gen.(FilesGenerator); ok {
+		outFiles, err := fgen.GenerateFiles(pf, baseName)
+		if err != nil {
+			return nil, err
+		}
+		for _, outFile := range outFiles {
+			if err := checkOutputName(outFile.Name); err != nil {
+				return nil, err
+			}
+		}
+		return outFiles, nil
+	}
+
+	outFile := &OutputFile{
+		Name: fmt.Sprintf("%s.%s.go", baseName, gen.GetShortName()),
+	}
+	var err error
+	if mgen, ok := gen.(MappedGenerator); ok {
+		outFile.Content, outFile.SourceMap, err = mgen.GenerateMappedCode(pf)
+	} else {
+		outFile.Content, err = gen.GenerateCode(pf)
+	}
 	if err != nil {
-		// return err
-		fmtSrc = generatedCode
+		return nil, err
 	}
+	return []*OutputFile{outFile}, nil
+}
 
-	// write the output
-	outputFile := path.Join(outputPath, fmt.Sprintf("%s.%s.go", protoBaseName, gen.GetShortName()))
-	return ioutil.WriteFile(outputFile, fmtSrc, 0644)
+// checkOutputName checks an output file name stays within the output directory.
+func checkOutputName(name string) error {
+	clean := path.Clean(name)
+	if name == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return errors.Errorf("output file name must be relative to the output directory: %q", name)
+	}
+	return nil
 }
@@ -0,0 +1,143 @@
+package generate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+)
+
+const testProto = `syntax = "proto3";
+package example;
+
+message Example {
+  string id = 1;
+}
+`
+
+// brokenGenerator writes a getter with a syntax error for every field.
+type brokenGenerator struct{}
+
+func (g *brokenGenerator) GetUsage() string     { return "writes invalid code" }
+func (g *brokenGenerator) GetShortName() string { return "broken" }
+
+func (g *brokenGenerator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+func (g *brokenGenerator) GenerateMappedCode(pf *parser.File) ([]byte, *SourceMap, error) {
+	var outp bytes.Buffer
+	sm := NewSourceMap()
+	outp.WriteString("package " + pf.PackageName + "\n")
+	for i := range pf.Messages {
+		msg := &pf.Messages[i]
+		sm.Mark(outp.Len(), MessageOrigin(msg))
+		outp.WriteString("\ntype " + msg.Name + " struct{}\n")
+		for j := range msg.Fields {
+			field := &msg.Fields[j]
+			sm.Mark(outp.Len(), FieldOrigin(msg, field))
+			outp.WriteString("\nfunc (m *" + msg.Name + ") Get" + field.CamelName + "() string {\n")
+			outp.WriteString("\treturn \"\" +\n}\n")
+		}
+	}
+	return outp.Bytes(), sm, nil
+}
+
+// TestGenerateDiagnosticPosition tests a problem in the generated code is
+// reported at the position of the proto field it was generated from.
+func TestGenerateDiagnosticPosition(t *testing.T) {
+	dir := t.TempDir()
+	protoPath := path.Join(dir, "example.proto")
+	if err := ioutil.WriteFile(protoPath, []byte(testProto), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err := GenerateWithOptions(&brokenGenerator{}, protoPath, dir, Options{})
+	diags, ok := err.(Diagnostics)
+	if !ok || len(diags) == 0 {
+		t.Fatalf("expected diagnostics: %v", err)
+	}
+
+	diag := diags[0]
+	if diag.Origin.Message != "Example" || diag.Origin.Field != "id" {
+		t.Fatalf("unexpected origin: %s", diag.Origin.String())
+	}
+	if diag.Origin.Position.Filename != protoPath ||
+		diag.Origin.Position.Line != 5 ||
+		diag.Origin.Position.Column != 3 {
+		t.Fatalf("unexpected proto position: %s", diag.Origin.Position.String())
+	}
+	if !strings.HasPrefix(diag.Error(), protoPath+":5:3: message Example field id: ") {
+		t.Fatalf("unexpected diagnostic: %s", diag.Error())
+	}
+	if _, err := ioutil.ReadFile(path.Join(dir, "example.broken.go")); err == nil {
+		t.Fatal("expected invalid code not to be written")
+	}
+}
+
+// nameGenerator writes a constant named by the name parameter.
+type nameGenerator struct {
+	name string
+}
+
+func (g *nameGenerator) GetUsage() string     { return "writes a named constant" }
+func (g *nameGenerator) GetShortName() string { return "name" }
+
+func (g *nameGenerator) SetParam(key, value string) error {
+	if key != "name" {
+		return errors.New("unknown parameter, expected name")
+	}
+	g.name = value
+	return nil
+}
+
+func (g *nameGenerator) CloneGenerator() ParamGenerator {
+	clone := *g
+	return &clone
+}
+
+func (g *nameGenerator) GenerateCode(pf *parser.File) ([]byte, error) {
+	return []byte("package " + pf.PackageName + "\n\nconst Name = \"" + g.name + "\"\n"), nil
+}
+
+// TestGenerateSourceParams tests params apply to a single call only.
+func TestGenerateSourceParams(t *testing.T) {
+	gen := &nameGenerator{name: "default"}
+
+	var wg sync.WaitGroup
+	names := []string{"a", "b", "c", "d"}
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			files, err := GenerateSource(gen, "example.proto", []byte(testProto), Options{
+				Params: map[string]string{"name": name},
+			})
+			if err == nil && !strings.Contains(string(files[0].Content), `Name = "`+name+`"`) {
+				err = errors.Errorf("expected name %s: %s", name, files[0].Content)
+			}
+			errs[i] = err
+		}(i, name)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	files, err := GenerateSource(gen, "example.proto", []byte(testProto), Options{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if gen.name != "default" || !strings.Contains(string(files[0].Content), `Name = "default"`) {
+		t.Fatalf("params leaked into the generator: %s", files[0].Content)
+	}
+}
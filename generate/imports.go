@@ -0,0 +1,212 @@
+package generate
+
+import (
+	"bytes"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Imports tracks the packages referenced by generated code.
+// Identifiers are qualified with non-colliding package names, and the import
+// block is rendered with only the packages the code actually references.
+type Imports struct {
+	// names maps import paths to the package name used in the code.
+	names map[string]string
+	// taken contains the names of imports and reserved identifiers.
+	taken map[string]bool
+	// defaults maps import paths to the declared package name.
+	defaults map[string]string
+}
+
+// NewImports builds a new empty import tracker.
+func NewImports() *Imports {
+	return &Imports{
+		names:    make(map[string]string),
+		taken:    make(map[string]bool),
+		defaults: make(map[string]string),
+	}
+}
+
+// Reserve marks identifiers as declared by the generated code.
+// Imports will not use these names.
+func (i *Imports) Reserve(idents ...string) {
+	for _, ident := range idents {
+		i.taken[ident] = true
+	}
+}
+
+// AddNamed declares the package name of an import path, for packages where
+// the name differs from the last element of the path.
+// Must be called before the path is first used.
+func (i *Imports) AddNamed(importPath, pkgName string) {
+	i.defaults[importPath] = pkgName
+}
+
+// Name returns the name to refer to the package at importPath, recording
+// the import. Allocates an alias if the package name is already taken.
+func (i *Imports) Name(importPath string) string {
+	if name, ok := i.names[importPath]; ok {
+		return name
+	}
+
+	base := i.defaultName(importPath)
+	name := base
+	for n := 2; i.taken[name] || token.Lookup(name).IsKeyword(); n++ {
+		name = base + strconv.Itoa(n)
+	}
+	i.taken[name] = true
+	i.names[importPath] = name
+	return name
+}
+
+// Qualify returns the qualified identifier for ident in the package at
+// importPath, for example "sync.RWMutex", recording the import.
+func (i *Imports) Qualify(importPath, ident string) string {
+	return i.Name(importPath) + "." + ident
+}
+
+// defaultName returns the package name for an import path.
+// Uses the last path element without a major version suffix.
+func (i *Imports) defaultName(importPath string) string {
+	if name, ok := i.defaults[importPath]; ok {
+		return name
+	}
+
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	// gopkg.in/yaml.v2 is package yaml.
+	if idx := strings.Index(name, "."); idx > 0 {
+		name = name[:idx]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "pkg"
+	}
+	return name
+}
+
+// isMajorVersion checks if a path element is a major version like v2.
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// Render renders an import block for the imports in use.
+// usedNames are the package names referenced by the code.
+// Standard library imports are grouped before other imports.
+func (i *Imports) Render(usedNames map[string]bool) []byte {
+	var std, other []string
+	for importPath, name := range i.names {
+		if !usedNames[name] {
+			continue
+		}
+
+		firstElem := strings.SplitN(importPath, "/", 2)[0]
+		if strings.Contains(firstElem, ".") {
+			other = append(other, importPath)
+		} else {
+			std = append(std, importPath)
+		}
+	}
+	if len(std) == 0 && len(other) == 0 {
+		return nil
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var outp bytes.Buffer
+	outp.WriteString("\nimport (\n")
+	writeGroup := func(paths []string) {
+		for _, importPath := range paths {
+			outp.WriteString("\t")
+			if name := i.names[importPath]; name != i.defaultName(importPath) {
+				outp.WriteString(name)
+				outp.WriteString(" ")
+			}
+			outp.WriteString(strconv.Quote(importPath))
+			outp.WriteString("\n")
+		}
+	}
+	writeGroup(std)
+	if len(std) != 0 && len(other) != 0 {
+		outp.WriteString("\n")
+	}
+	writeGroup(other)
+	outp.WriteString(")\n")
+	return outp.Bytes()
+}
+
+// InsertImports inserts the import block after the package clause of code,
+// pruning imports the code does not reference, and shifts the source map.
+// If the code cannot be parsed all recorded imports are inserted.
+func InsertImports(code []byte, imports *Imports, sm *SourceMap) []byte {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "", code, goparser.PackageClauseOnly)
+	if err != nil {
+		return code
+	}
+	insertAt := fset.Position(file.Name.End()).Offset
+	if nl := bytes.IndexByte(code[insertAt:], '\n'); nl != -1 {
+		insertAt += nl + 1
+	} else {
+		insertAt = len(code)
+	}
+
+	usedNames := referencedNames(code)
+	if usedNames == nil {
+		usedNames = make(map[string]bool)
+		for _, name := range imports.names {
+			usedNames[name] = true
+		}
+	}
+
+	block := imports.Render(usedNames)
+	if len(block) == 0 {
+		return code
+	}
+
+	sm.Shift(insertAt, len(block))
+	outp := make([]byte, 0, len(code)+len(block))
+	outp = append(outp, code[:insertAt]...)
+	outp = append(outp, block...)
+	outp = append(outp, code[insertAt:]...)
+	return outp
+}
+
+// referencedNames returns the identifiers used as the operand of a selector
+// expression, which includes all references to imported packages.
+// Returns nil if the code cannot be parsed.
+func referencedNames(code []byte) map[string]bool {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "", code, 0)
+	if err != nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				names[ident.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,79 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestImportsSameBaseName tests packages with the same name get an alias.
+func TestImportsSameBaseName(t *testing.T) {
+	imports := NewImports()
+	if q := imports.Qualify("text/template", "Template"); q != "template.Template" {
+		t.Fatalf("unexpected text/template qualifier: %s", q)
+	}
+	if q := imports.Qualify("html/template", "HTML"); q != "template2.HTML" {
+		t.Fatalf("unexpected html/template qualifier: %s", q)
+	}
+	if q := imports.Qualify("text/template", "FuncMap"); q != "template.FuncMap" {
+		t.Fatalf("expected the same name for a repeated path: %s", q)
+	}
+
+	code := "package example\n\nvar _ template.Template\nvar _ template2.HTML\n"
+	out := string(InsertImports([]byte(code), imports, nil))
+	expected := "package example\n\nimport (\n\ttemplate2 \"html/template\"\n\t\"text/template\"\n)\n\nvar _ template.Template\nvar _ template2.HTML\n"
+	if out != expected {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+// TestImportsReservedIdent tests an import does not use the name of a local
+// identifier declared by the generated code.
+func TestImportsReservedIdent(t *testing.T) {
+	imports := NewImports()
+	imports.Reserve("errors")
+	if q := imports.Qualify("github.com/pkg/errors", "New"); q != "errors2.New" {
+		t.Fatalf("unexpected qualifier: %s", q)
+	}
+	if q := imports.Qualify("example.com/go-type", "T"); q != "type2.T" {
+		t.Fatalf("expected keyword package name to be aliased: %s", q)
+	}
+
+	code := "package example\n\nvar errors = errors2.New(\"local\")\nvar _ = errors.Error()\nvar _ type2.T\n"
+	out := string(InsertImports([]byte(code), imports, nil))
+	for _, expected := range []string{
+		"\terrors2 \"github.com/pkg/errors\"\n",
+		"\ttype2 \"example.com/go-type\"\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q in:\n%s", expected, out)
+		}
+	}
+}
+
+// TestImportsPruneUnused tests imports only referenced in comments or strings
+// are not rendered, and the source map is shifted past the import block.
+func TestImportsPruneUnused(t *testing.T) {
+	imports := NewImports()
+	imports.Qualify("strings", "Join")
+	imports.Qualify("fmt", "Println")
+	imports.Qualify("sync", "Mutex")
+
+	code := "package example\n\n// Join calls strings.Join.\nvar Join = \"fmt.Println\"\n\nvar mtx sync.Mutex\n"
+	sm := NewSourceMap()
+	declOffset := strings.Index(code, "var mtx")
+	sm.Mark(declOffset, Origin{Message: "Example"})
+
+	out := string(InsertImports([]byte(code), imports, sm))
+	expected := "package example\n\nimport (\n\t\"sync\"\n)\n\n// Join calls strings.Join.\nvar Join = \"fmt.Println\"\n\nvar mtx sync.Mutex\n"
+	if out != expected {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	origin, ok := sm.Lookup(strings.Index(out, "var mtx"))
+	if !ok || origin.Message != "Example" {
+		t.Fatalf("expected shifted source map, got %v", origin)
+	}
+	if _, ok := sm.Lookup(declOffset - 1); ok {
+		t.Fatal("expected code before the shifted span to be unattributed")
+	}
+}
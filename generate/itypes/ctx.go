@@ -0,0 +1,545 @@
+package itypes
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+// ctxSuffix is the suffix of the context aware interface types.
+const ctxSuffix = "Ctx"
+
+// CtxName returns the name of the context aware interface type for an
+// interface type, for example IHelloCtx.
+func CtxName(interName string) string {
+	return interName + ctxSuffix
+}
+
+// ctxType returns the context aware type of a Go type.
+// Messages and maps use their context aware interface types.
+func ctxType(goType string, kind parser.FieldKind) string {
+	if kind == parser.FieldKindScalar {
+		return goType
+	}
+	return CtxName(goType)
+}
+
+// adapterName returns the name of the adapter type lifting a type name.
+func adapterName(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[n:] + ctxSuffix
+}
+
+// writeCtx writes the context aware interfaces and the adapters lifting the
+// plain interfaces into them.
+func writeCtx(outp *generate.CodeWriter, pf *parser.File) {
+	ctxt := outp.Qualify("context", "Context")
+
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapCtx(outp, mapt, ctxt)
+	}
+
+	for mi := range pf.Messages {
+		message := &pf.Messages[mi]
+		outp.Mark(generate.MessageOrigin(message))
+		writeMessageCtx(outp, message, ctxt)
+	}
+}
+
+// writeMapCtx writes the context aware interface and adapter for a map type.
+func writeMapCtx(outp *generate.CodeWriter, mapt *parser.Map, ctxt string) {
+	typeName := CtxName(mapt.TypeName)
+	adapter := adapterName(mapt.ImplName)
+	value := ctxType(mapt.Value, mapt.ValueKind)
+	isPrim := mapt.ValueKind != parser.FieldKindMessage
+
+	// IKeyValueMapCtx is the context aware map type for map<key, value>.
+	outp.WriteString("\n// ")
+	outp.WriteString(typeName)
+	outp.WriteString(" is the context aware map type for map<")
+	outp.WriteString(mapt.Key)
+	outp.WriteString(", ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(">\n")
+	outp.WriteString("type ")
+	outp.WriteString(typeName)
+	outp.WriteString(" interface {\n")
+	outp.WriteString("\tGet(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", key string) (")
+	outp.WriteString(value)
+	outp.WriteString(", error)\n")
+	outp.WriteString("\tSet(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", key string, val ")
+	outp.WriteString(value)
+	outp.WriteString(") error\n")
+	outp.WriteString("\tForEach(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", cb func(key string, val ")
+	outp.WriteString(value)
+	outp.WriteString(") bool) (bool, error)\n")
+	outp.WriteString("}\n")
+
+	// type keyValueMapCtx struct
+	outp.WriteString("\n// ")
+	outp.WriteString(adapter)
+	outp.WriteString(" lifts an ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" into an ")
+	outp.WriteString(typeName)
+	outp.WriteString(".\n")
+	outp.WriteString("type ")
+	outp.WriteString(adapter)
+	outp.WriteString(" struct {\n\tv ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString("\n}\n")
+
+	// func ToIKeyValueMapCtx(v IKeyValueMap) IKeyValueMapCtx
+	outp.WriteString("\n// To")
+	outp.WriteString(typeName)
+	outp.WriteString(" lifts an ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" into an ")
+	outp.WriteString(typeName)
+	outp.WriteString(".\n// The calls return the context error if the context is done, and the\n// error recorded by v if it has an Err method.\n")
+	outp.WriteString("func To")
+	outp.WriteString(typeName)
+	outp.WriteString("(v ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") ")
+	outp.WriteString(typeName)
+	outp.WriteString(" {\n\tif v == nil {\n\t\treturn nil\n\t}\n\treturn &")
+	outp.WriteString(adapter)
+	outp.WriteString("{v: v}\n}\n")
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") Get(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", key string) (")
+	outp.WriteString(value)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tif err := ctx.Err(); err != nil {\n")
+	if isPrim {
+		outp.WriteString("\t\tvar val ")
+		outp.WriteString(value)
+		outp.WriteString("\n\t\treturn val, err\n\t}\n")
+		outp.WriteString("\tval := a.v.Get(key)\n")
+		outp.WriteString("\treturn val, a.backendErr()\n")
+	} else {
+		outp.WriteString("\t\treturn nil, err\n\t}\n")
+		outp.WriteString("\tval := a.v.Get(key)\n")
+		outp.WriteString("\tif err := a.backendErr(); err != nil {\n\t\treturn nil, err\n\t}\n")
+		outp.WriteString("\treturn To")
+		outp.WriteString(value)
+		outp.WriteString("(val), nil\n")
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") Set(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", key string, val ")
+	outp.WriteString(value)
+	outp.WriteString(") error {\n")
+	outp.WriteString("\tif err := ctx.Err(); err != nil {\n\t\treturn err\n\t}\n")
+	if isPrim {
+		outp.WriteString("\ta.v.Set(key, val)\n")
+	} else {
+		outp.WriteString("\tv, err := from")
+		outp.WriteString(value)
+		outp.WriteString("(ctx, val)\n")
+		outp.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		outp.WriteString("\ta.v.Set(key, v)\n")
+	}
+	outp.WriteString("\treturn a.backendErr()\n}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") ForEach(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", cb func(key string, val ")
+	outp.WriteString(value)
+	outp.WriteString(") bool) (bool, error) {\n")
+	outp.WriteString("\tif err := ctx.Err(); err != nil {\n\t\treturn false, err\n\t}\n")
+	outp.WriteString("\tvar err error\n")
+	outp.WriteString("\tdone := a.v.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\t\tif err = ctx.Err(); err != nil {\n\t\t\treturn false\n\t\t}\n")
+	if isPrim {
+		outp.WriteString("\t\treturn cb(key, val)\n")
+	} else {
+		outp.WriteString("\t\treturn cb(key, To")
+		outp.WriteString(value)
+		outp.WriteString("(val))\n")
+	}
+	outp.WriteString("\t})\n")
+	outp.WriteString("\tif err == nil {\n\t\terr = a.backendErr()\n\t}\n")
+	outp.WriteString("\treturn done, err\n}\n")
+
+	// backendErr()
+	writeBackendErr(outp, adapter)
+
+	// fromIKeyValueMapCtx()
+	writeFromCtx(outp, mapt.TypeName, adapter, ctxt)
+
+	// copyIKeyValueMapCtx()
+	outp.WriteString("\n// copy")
+	outp.WriteString(typeName)
+	outp.WriteString(" copies the entries of val into a new ")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(".\n")
+	outp.WriteString("func copy")
+	outp.WriteString(typeName)
+	outp.WriteString("(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", val ")
+	outp.WriteString(typeName)
+	outp.WriteString(") (")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tm := make(")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(")\n")
+	if isPrim {
+		outp.WriteString("\t_, err := val.ForEach(ctx, func(key string, v ")
+		outp.WriteString(value)
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tm[key] = v\n")
+		outp.WriteString("\t\treturn true\n")
+		outp.WriteString("\t})\n")
+		outp.WriteString("\treturn m, err\n}\n")
+	} else {
+		outp.WriteString("\tvar cerr error\n")
+		outp.WriteString("\t_, err := val.ForEach(ctx, func(key string, v ")
+		outp.WriteString(value)
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tif v == nil {\n\t\t\tm[key] = nil\n\t\t\treturn true\n\t\t}\n")
+		outp.WriteString("\t\tm[key], cerr = copy")
+		outp.WriteString(value)
+		outp.WriteString("(ctx, v)\n")
+		outp.WriteString("\t\treturn cerr == nil\n")
+		outp.WriteString("\t})\n")
+		outp.WriteString("\tif err == nil {\n\t\terr = cerr\n\t}\n")
+		outp.WriteString("\treturn m, err\n}\n")
+	}
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(typeName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(adapter)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeFromCtx writes the function converting a context aware value back
+// to the plain interface: adapted values are unwrapped, other values are
+// copied into the proto types.
+func writeFromCtx(outp *generate.CodeWriter, plainType, adapter, ctxt string) {
+	typeName := CtxName(plainType)
+	outp.WriteString("\n// from")
+	outp.WriteString(typeName)
+	outp.WriteString(" returns the ")
+	outp.WriteString(plainType)
+	outp.WriteString(" lifted into val, or a copy of val.\n")
+	outp.WriteString("func from")
+	outp.WriteString(typeName)
+	outp.WriteString("(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", val ")
+	outp.WriteString(typeName)
+	outp.WriteString(") (")
+	outp.WriteString(plainType)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tif val == nil {\n\t\treturn nil, nil\n\t}\n")
+	outp.WriteString("\tif a, ok := val.(*")
+	outp.WriteString(adapter)
+	outp.WriteString("); ok {\n\t\treturn a.v, nil\n\t}\n")
+	outp.WriteString("\tv, err := copy")
+	outp.WriteString(typeName)
+	outp.WriteString("(ctx, val)\n")
+	outp.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	outp.WriteString("\treturn v, nil\n}\n")
+}
+
+// writeMessageCtx writes the context aware interface and adapter for a message.
+func writeMessageCtx(outp *generate.CodeWriter, message *parser.Message, ctxt string) {
+	interName := message.InterName
+	typeName := CtxName(interName)
+	adapter := adapterName(message.Name)
+
+	var fields []*parser.Field
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if field.IsSupported() {
+			fields = append(fields, field)
+		}
+	}
+
+	// IHelloCtx is the context aware interface type for Hello.
+	outp.WriteString("\n// ")
+	outp.WriteString(typeName)
+	outp.WriteString(" is the context aware interface type for ")
+	outp.WriteString(message.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("type ")
+	outp.WriteString(typeName)
+	outp.WriteString(" interface {\n")
+	for _, field := range fields {
+		outp.Mark(generate.FieldOrigin(message, field))
+		goType := ctxType(field.GoType, field.Kind)
+
+		// GetSubject(ctx context.Context) (string, error)
+		outp.WriteString("\t")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(ctx ")
+		outp.WriteString(ctxt)
+		outp.WriteString(") (")
+		outp.WriteString(goType)
+		outp.WriteString(", error)\n")
+
+		// SetSubject(ctx context.Context, val string) error
+		outp.WriteString("\t")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(ctx ")
+		outp.WriteString(ctxt)
+		outp.WriteString(", val ")
+		outp.WriteString(goType)
+		outp.WriteString(") error\n")
+
+		// NewExample(ctx context.Context) (IExampleCtx, error)
+		if field.NewName != "" {
+			outp.WriteString("\t")
+			outp.WriteString(field.NewName)
+			outp.WriteString("(ctx ")
+			outp.WriteString(ctxt)
+			outp.WriteString(") (")
+			outp.WriteString(goType)
+			outp.WriteString(", error)\n")
+		}
+	}
+	outp.WriteString("}\n")
+
+	// type helloCtx struct
+	outp.Mark(generate.MessageOrigin(message))
+	outp.WriteString("\n// ")
+	outp.WriteString(adapter)
+	outp.WriteString(" lifts an ")
+	outp.WriteString(interName)
+	outp.WriteString(" into an ")
+	outp.WriteString(typeName)
+	outp.WriteString(".\n")
+	outp.WriteString("type ")
+	outp.WriteString(adapter)
+	outp.WriteString(" struct {\n\tv ")
+	outp.WriteString(interName)
+	outp.WriteString("\n}\n")
+
+	// func ToIHelloCtx(v IHello) IHelloCtx
+	outp.WriteString("\n// To")
+	outp.WriteString(typeName)
+	outp.WriteString(" lifts an ")
+	outp.WriteString(interName)
+	outp.WriteString(" into an ")
+	outp.WriteString(typeName)
+	outp.WriteString(".\n// The calls return the context error if the context is done, and the\n// error recorded by v if it has an Err method.\n")
+	outp.WriteString("func To")
+	outp.WriteString(typeName)
+	outp.WriteString("(v ")
+	outp.WriteString(interName)
+	outp.WriteString(") ")
+	outp.WriteString(typeName)
+	outp.WriteString(" {\n\tif v == nil {\n\t\treturn nil\n\t}\n\treturn &")
+	outp.WriteString(adapter)
+	outp.WriteString("{v: v}\n}\n")
+
+	for _, field := range fields {
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeFieldCtx(outp, adapter, field, ctxt)
+	}
+
+	// backendErr()
+	outp.Mark(generate.MessageOrigin(message))
+	writeBackendErr(outp, adapter)
+
+	// fromIHelloCtx()
+	writeFromCtx(outp, interName, adapter, ctxt)
+
+	// copyIHelloCtx()
+	outp.WriteString("\n// copy")
+	outp.WriteString(typeName)
+	outp.WriteString(" copies the fields of val into a new ")
+	outp.WriteString(message.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func copy")
+	outp.WriteString(typeName)
+	outp.WriteString("(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", val ")
+	outp.WriteString(typeName)
+	outp.WriteString(") (*")
+	outp.WriteString(message.Name)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tm := &")
+	outp.WriteString(message.Name)
+	outp.WriteString("{}\n")
+	for _, field := range fields {
+		outp.WriteString("\t{\n")
+		outp.WriteString("\t\tv, err := val.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(ctx)\n")
+		outp.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\t\tm.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(v)\n")
+		} else {
+			outp.WriteString("\t\tif v != nil {\n")
+			outp.WriteString("\t\t\tc, err := copy")
+			outp.WriteString(ctxType(field.GoType, field.Kind))
+			outp.WriteString("(ctx, v)\n")
+			outp.WriteString("\t\t\tif err != nil {\n\t\t\t\treturn nil, err\n\t\t\t}\n")
+			outp.WriteString("\t\t\tm.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(c)\n")
+			outp.WriteString("\t\t}\n")
+		}
+		outp.WriteString("\t}\n")
+	}
+	outp.WriteString("\treturn m, nil\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(typeName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(adapter)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeFieldCtx writes the adapter methods for a field.
+func writeFieldCtx(outp *generate.CodeWriter, adapter string, field *parser.Field, ctxt string) {
+	goType := ctxType(field.GoType, field.Kind)
+	isPrim := field.Kind == parser.FieldKindScalar
+
+	// func (a *helloCtx) GetSubject(ctx context.Context) (string, error)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(") (")
+	outp.WriteString(goType)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tif err := ctx.Err(); err != nil {\n")
+	if isPrim {
+		outp.WriteString("\t\tvar val ")
+		outp.WriteString(goType)
+		outp.WriteString("\n\t\treturn val, err\n\t}\n")
+		outp.WriteString("\tval := a.v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+		outp.WriteString("\treturn val, a.backendErr()\n")
+	} else {
+		outp.WriteString("\t\treturn nil, err\n\t}\n")
+		writeLiftCall(outp, goType, field.GetterName)
+	}
+	outp.WriteString("}\n")
+
+	// func (a *helloCtx) SetSubject(ctx context.Context, val string) error
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" sets ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(ctx ")
+	outp.WriteString(ctxt)
+	outp.WriteString(", val ")
+	outp.WriteString(goType)
+	outp.WriteString(") error {\n")
+	outp.WriteString("\tif err := ctx.Err(); err != nil {\n\t\treturn err\n\t}\n")
+	if isPrim {
+		outp.WriteString("\ta.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val)\n")
+	} else {
+		outp.WriteString("\tv, err := from")
+		outp.WriteString(goType)
+		outp.WriteString("(ctx, val)\n")
+		outp.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		outp.WriteString("\ta.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(v)\n")
+	}
+	outp.WriteString("\treturn a.backendErr()\n}\n")
+
+	// func (a *helloCtx) NewExample(ctx context.Context) (IExampleCtx, error)
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" builds a new ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (a *")
+		outp.WriteString(adapter)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("(ctx ")
+		outp.WriteString(ctxt)
+		outp.WriteString(") (")
+		outp.WriteString(goType)
+		outp.WriteString(", error) {\n")
+		outp.WriteString("\tif err := ctx.Err(); err != nil {\n\t\treturn nil, err\n\t}\n")
+		writeLiftCall(outp, goType, field.NewName)
+		outp.WriteString("}\n")
+	}
+}
+
+// writeLiftCall writes the body of an adapter method calling a method of the
+// wrapped value and lifting the result into a context aware type.
+func writeLiftCall(outp *generate.CodeWriter, goType, method string) {
+	outp.WriteString("\tval := a.v.")
+	outp.WriteString(method)
+	outp.WriteString("()\n")
+	outp.WriteString("\tif err := a.backendErr(); err != nil {\n\t\treturn nil, err\n\t}\n")
+	outp.WriteString("\treturn To")
+	outp.WriteString(goType)
+	outp.WriteString("(val), nil\n")
+}
+
+// writeBackendErr writes the adapter method returning the error recorded by
+// the wrapped value, for backends with an Err method.
+func writeBackendErr(outp *generate.CodeWriter, adapter string) {
+	outp.WriteString("\n// backendErr returns the error recorded by the wrapped value, if any.\n")
+	outp.WriteString("func (a *")
+	outp.WriteString(adapter)
+	outp.WriteString(") backendErr() error {\n")
+	outp.WriteString("\tif e, ok := a.v.(interface{ Err() error }); ok {\n")
+	outp.WriteString("\t\treturn e.Err()\n\t}\n")
+	outp.WriteString("\treturn nil\n}\n")
+}
@@ -1,16 +1,21 @@
 package itypes
 
 import (
-	"bytes"
+	"strconv"
 
 	"github.com/paralin/protods/generate"
 	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
 )
 
 const generatorName = "itypes"
 
 // Generator generates the interface types for protos.
-type Generator struct{}
+type Generator struct {
+	// Ctx enables the context aware interface types, for example IHelloCtx,
+	// and the adapters lifting the plain interface types into them.
+	Ctx bool
+}
 
 // GetUsage returns a usage description of the generator.
 func (g *Generator) GetUsage() string {
@@ -22,16 +27,45 @@ func (g *Generator) GetShortName() string {
 	return generatorName
 }
 
+// SetParam sets a generator parameter.
+func (g *Generator) SetParam(key, value string) error {
+	switch key {
+	case "ctx":
+		ctx, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Wrap(err, "ctx")
+		}
+		g.Ctx = ctx
+	default:
+		return errors.Errorf("unknown parameter, expected ctx")
+	}
+	return nil
+}
+
+// CloneGenerator returns a copy of the generator.
+func (g *Generator) CloneGenerator() generate.ParamGenerator {
+	clone := *g
+	return &clone
+}
+
 // GenerateCode generates code given the input proto file.
 func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
-	var outp bytes.Buffer
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
 
-	outp.WriteString("package ")
-	outp.WriteString(pf.PackageName)
-	outp.WriteString("\n")
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
 
-	for _, mapt := range pf.Maps {
 		typeName := mapt.TypeName
+		outp.Mark(generate.MapOrigin(mapt))
 
 		// IKeyValueMap is the map type for map<key, value>.
 		outp.WriteString("\n// ")
@@ -45,33 +79,45 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		// type IKeyValueMap interface {
 		outp.WriteString("type ")
 		outp.WriteString(typeName)
+		outp.WriteString(" interface {\n\t")
+		outp.WriteString(ReaderName(typeName))
+		outp.WriteString("\n\t")
+		outp.WriteString(WriterName(typeName))
+		outp.WriteString("\n")
+		if mapt.ValueKind == parser.FieldKindMessage {
+			// message values are returned as the read-only interface type by
+			// the reader, and as the mutable one here.
+			writeMapAccessors(outp, mapt.Value, "")
+		}
+		outp.WriteString("}\n")
+
+		// type IKeyValueMapReader interface {
+		writeSplitDoc(outp, ReaderName(typeName), "read-only", typeName)
+		outp.WriteString("type ")
+		outp.WriteString(ReaderName(typeName))
 		outp.WriteString(" interface {\n")
+		if mapt.ValueKind == parser.FieldKindMessage {
+			writeMapAccessors(outp, ReaderName(mapt.Value), "Reader")
+		} else {
+			writeMapAccessors(outp, mapt.Value, "")
+		}
+		outp.WriteString("}\n")
 
-		// Get()
-		outp.WriteString("\tGet(key string) ")
-		outp.WriteString(mapt.Value)
-		outp.WriteString("\n")
+		// type IKeyValueMapWriter interface {
+		writeSplitDoc(outp, WriterName(typeName), "mutating", typeName)
+		outp.WriteString("type ")
+		outp.WriteString(WriterName(typeName))
+		outp.WriteString(" interface {\n")
 
 		// Set()
 		outp.WriteString("\tSet(key string, val ")
 		outp.WriteString(mapt.Value)
 		outp.WriteString(")\n")
 
-		// ForEach()
-		outp.WriteString("\tForEach(cb func(key string, val ")
-		outp.WriteString(mapt.Value)
-		outp.WriteString(") bool) bool\n")
-
 		outp.WriteString("}\n")
 
 		// KeyValueMap satisfies IKeyValueMap.
-		typeNameSansi := typeName[1:]
-		valueSansi := mapt.Value
-		if valueSansi[0] == 'I' {
-			valueSansi = valueSansi[1:]
-			valueSansi = "*" + valueSansi
-		}
-
+		typeNameSansi := mapt.ImplName
 		outp.WriteString("\n// ")
 		outp.WriteString(typeNameSansi)
 		outp.WriteString(" satisfies ")
@@ -82,10 +128,10 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		outp.WriteString("type ")
 		outp.WriteString(typeNameSansi)
 		outp.WriteString(" map[string]")
-		outp.WriteString(valueSansi)
+		outp.WriteString(mapt.ValuePtr)
 		outp.WriteString("\n")
 
-		isPrim := mapt.Value[0] != 'I'
+		isPrim := mapt.ValueKind != parser.FieldKindMessage
 
 		// Get returns a value from the map.
 		outp.WriteString("\n// Get returns a value from the map.\n")
@@ -95,13 +141,13 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		outp.WriteString(mapt.Value)
 		outp.WriteString(" {\n")
 		if !isPrim {
-			outp.WriteString("\tif m == nil { return nil }\n")
+			// avoid returning a typed nil in the interface.
+			outp.WriteString("\tv := m[key]\n")
+			outp.WriteString("\tif v == nil { return nil }\n")
+			outp.WriteString("\treturn v\n")
 		} else {
-			outp.WriteString("\tif m == nil { return new(")
-			outp.WriteString(mapt.Value)
-			outp.WriteString(") }\n")
+			outp.WriteString("\treturn m[key]\n")
 		}
-		outp.WriteString("\treturn m[key]\n")
 		outp.WriteString("}\n")
 
 		// Set sets a value in the map.
@@ -112,10 +158,11 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		outp.WriteString(mapt.Value)
 		outp.WriteString(") {\n")
 		if isPrim {
-			outp.WriteString("\tm[key] = value")
+			outp.WriteString("\tm[key] = value\n")
 		} else {
-			outp.WriteString("\tm[key] = value.(*")
-			outp.WriteString(mapt.Value[1:])
+			outp.WriteString("\tif value == nil {\n\t\tm[key] = nil\n\t\treturn\n\t}\n")
+			outp.WriteString("\tm[key] = value.(")
+			outp.WriteString(mapt.ValuePtr)
 			outp.WriteString(")\n")
 		}
 		outp.WriteString("}\n")
@@ -136,10 +183,14 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 
 	return true`)
 		outp.WriteString("}\n")
+
+		WriteMapReaders(outp, "m", typeNameSansi, mapt)
 	}
 
-	for _, message := range pf.Messages {
+	for mi := range pf.Messages {
+		message := &pf.Messages[mi]
 		interName := message.InterName
+		outp.Mark(generate.MessageOrigin(message))
 
 		// IHello is the interface type for Hello.
 		outp.WriteString("\n// ")
@@ -158,46 +209,89 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		// type IHello interface {
 		outp.WriteString("type ")
 		outp.WriteString(interName)
-		outp.WriteString(" interface {\n")
-
-		for _, field := range message.Fields {
-			// GeT()
-			outp.WriteString("\tGet")
-			outp.WriteString(field.CamelName)
-			if field.Map != nil {
-				outp.WriteString("Inter")
+		outp.WriteString(" interface {\n\t")
+		outp.WriteString(ReaderName(interName))
+		outp.WriteString("\n\t")
+		outp.WriteString(WriterName(interName))
+		outp.WriteString("\n")
+		for fi := range message.Fields {
+			field := &message.Fields[fi]
+			if !field.IsSupported() || field.Kind == parser.FieldKindScalar {
+				continue
 			}
+			outp.Mark(generate.FieldOrigin(message, field))
+
+			// GetExampleInter() returns the mutable message.
+			outp.WriteString("\t")
+			outp.WriteString(field.GetterName)
 			outp.WriteString("() ")
+			outp.WriteString(field.GoType)
+			outp.WriteString("\n")
+		}
+		outp.WriteString("}\n")
 
-			var typeName string
-			if field.Map != nil {
-				typeName = field.Map.TypeName
-			} else {
-				typeName = field.Type
+		// type IHelloReader interface {
+		outp.Mark(generate.MessageOrigin(message))
+		writeSplitDoc(outp, ReaderName(interName), "read-only", interName)
+		outp.WriteString("type ")
+		outp.WriteString(ReaderName(interName))
+		outp.WriteString(" interface {\n")
+		for fi := range message.Fields {
+			field := &message.Fields[fi]
+			if !field.IsSupported() {
+				continue
 			}
+			outp.Mark(generate.FieldOrigin(message, field))
 
-			outp.WriteString(typeName)
+			// GetSubject() or GetExampleReader()
+			outp.WriteString("\t")
+			if field.Kind == parser.FieldKindScalar {
+				outp.WriteString(field.GetterName)
+				outp.WriteString("() ")
+				outp.WriteString(field.GoType)
+			} else {
+				outp.WriteString(ReaderGetterName(field))
+				outp.WriteString("() ")
+				outp.WriteString(ReaderName(field.GoType))
+			}
 			outp.WriteString("\n")
+		}
+		outp.WriteString("}\n")
 
-			// Set()
-			outp.WriteString("\tSet")
-			outp.WriteString(field.CamelName)
+		// type IHelloWriter interface {
+		outp.Mark(generate.MessageOrigin(message))
+		writeSplitDoc(outp, WriterName(interName), "mutating", interName)
+		outp.WriteString("type ")
+		outp.WriteString(WriterName(interName))
+		outp.WriteString(" interface {\n")
+		for fi := range message.Fields {
+			field := &message.Fields[fi]
+			if !field.IsSupported() {
+				continue
+			}
+			outp.Mark(generate.FieldOrigin(message, field))
+
+			// SetSubject()
+			outp.WriteString("\t")
+			outp.WriteString(field.SetterName)
 			outp.WriteString("(val ")
-			outp.WriteString(typeName)
+			outp.WriteString(field.GoType)
 			outp.WriteString(")\n")
 
-			// New()
-			if typeName[0] == 'I' {
-				outp.WriteString("\tNew")
-				outp.WriteString(field.CamelName)
+			// NewSubject()
+			if field.NewName != "" {
+				outp.WriteString("\t")
+				outp.WriteString(field.NewName)
 				outp.WriteString("() ")
-				outp.WriteString(typeName)
+				outp.WriteString(field.GoType)
 				outp.WriteString("\n")
 			}
 		}
 		outp.WriteString("}\n")
 
 		// Furthermore, augment the auto-generated proto types.
+		// func (m *Hello) ToIHello() IHello
+		outp.Mark(generate.MessageOrigin(message))
 		outp.WriteString("\nfunc (m *")
 		outp.WriteString(message.Name)
 		outp.WriteString(") To")
@@ -208,27 +302,31 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 		outp.WriteString(interName)
 		outp.WriteString(")(m)\n}\n")
 
-		for _, field := range message.Fields {
-			var typeName string
-			if field.Map != nil {
-				typeName = field.Map.TypeName
-			} else {
-				typeName = field.Type
+		for fi := range message.Fields {
+			field := &message.Fields[fi]
+			if !field.IsSupported() {
+				continue
 			}
+			outp.Mark(generate.FieldOrigin(message, field))
 
-			// func (m *Hello) ToIHello() IHello
-			if typeName[0] == 'I' {
-
+			typeName := field.GoType
+			if field.NewName != "" {
 				// func (m *Hello) NewSubject() ISubject
 				outp.WriteString("\nfunc (m *")
 				outp.WriteString(message.Name)
-				outp.WriteString(") New")
-				outp.WriteString(field.CamelName)
+				outp.WriteString(") ")
+				outp.WriteString(field.NewName)
 				outp.WriteString("() ")
 				outp.WriteString(typeName)
-				outp.WriteString(" {\n\treturn &")
-				outp.WriteString(typeName[1:])
-				outp.WriteString("{}\n}\n")
+				if field.Map != nil {
+					outp.WriteString(" {\n\treturn make(")
+					outp.WriteString(field.Map.ImplName)
+					outp.WriteString(")\n}\n")
+				} else {
+					outp.WriteString(" {\n\treturn &")
+					outp.WriteString(field.Message)
+					outp.WriteString("{}\n}\n")
+				}
 			}
 
 			// map type is generated at the beginning.
@@ -238,65 +336,135 @@ func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
 				// func (m *Hello) GetMapFieldInter() IMapFieldInter {
 				outp.WriteString("\nfunc (m *")
 				outp.WriteString(message.Name)
-				outp.WriteString(") Get")
-				outp.WriteString(field.CamelName)
-				outp.WriteString("Inter() ")
+				outp.WriteString(") ")
+				outp.WriteString(field.GetterName)
+				outp.WriteString("() ")
 				outp.WriteString(typeName)
 				outp.WriteString(" {\n")
 
-				typeNameWithouti := typeName
-				if typeNameWithouti[0] == 'I' {
-					typeNameWithouti = typeNameWithouti[1:]
-				}
-
 				// return IMapFieldInter(m.GetMapField())
 				outp.WriteString("\treturn ")
-				outp.WriteString(typeNameWithouti)
+				outp.WriteString(field.Map.ImplName)
 				outp.WriteString("(m.Get")
 				outp.WriteString(field.CamelName)
 				outp.WriteString("())\n}\n")
+			} else if field.Kind == parser.FieldKindMessage {
+				// func (m *Hello) GetExampleInter() IExample {
+				outp.WriteString("\nfunc (m *")
+				outp.WriteString(message.Name)
+				outp.WriteString(") ")
+				outp.WriteString(field.GetterName)
+				outp.WriteString("() ")
+				outp.WriteString(typeName)
+				outp.WriteString(" {\n")
+
+				// avoid returning a typed nil in the interface.
+				outp.WriteString("\tv := m.Get")
+				outp.WriteString(field.CamelName)
+				outp.WriteString("()\n\tif v == nil { return nil }\n\treturn v\n}\n")
 			}
 
 			// func (m *Hello) SetSubject(val string)
 			outp.WriteString("\nfunc (m *")
 			outp.WriteString(message.Name)
-			outp.WriteString(") Set")
-			outp.WriteString(field.CamelName)
+			outp.WriteString(") ")
+			outp.WriteString(field.SetterName)
 			outp.WriteString("(val ")
-
 			outp.WriteString(typeName)
 			outp.WriteString(") {\n")
 
+			if field.Kind != parser.FieldKindScalar {
+				outp.WriteString("\tif val == nil {\n\t\tm.")
+				outp.WriteString(field.CamelName)
+				outp.WriteString(" = nil\n\t\treturn\n\t}\n")
+			}
+
 			outp.WriteString("\tm.")
 			outp.WriteString(field.CamelName)
 			outp.WriteString(" = ")
-			if field.Map == nil {
-				outp.WriteString("val")
-			} else {
+			switch field.Kind {
+			case parser.FieldKindMap:
 				outp.WriteString("(map[")
 				outp.WriteString(field.Map.Key)
 				outp.WriteString("]")
 				outp.WriteString(field.Map.ValuePtr)
 				outp.WriteString(")")
 				outp.WriteString("(val.(")
-				outp.WriteString(field.Map.TypeName[1:])
+				outp.WriteString(field.Map.ImplName)
 				outp.WriteString("))")
+			case parser.FieldKindMessage:
+				outp.WriteString("val.(*")
+				outp.WriteString(field.Message)
+				outp.WriteString(")")
+			default:
+				outp.WriteString("val")
 			}
 			outp.WriteString("\n}\n")
-
-			// _ is a type assertion
-			outp.WriteString("\n// _ is a type assertion\n")
-			outp.WriteString("var _ ")
-			outp.WriteString(message.InterName)
-			outp.WriteString(" = &")
-			outp.WriteString(message.Name)
-			outp.WriteString("{}\n")
 		}
+
+		WriteReaderGetters(outp, "m", "*"+message.Name, message)
+
+		// _ is a type assertion
+		outp.Mark(generate.MessageOrigin(message))
+		outp.WriteString("\n// _ is a type assertion\n")
+		outp.WriteString("var _ ")
+		outp.WriteString(message.InterName)
+		outp.WriteString(" = &")
+		outp.WriteString(message.Name)
+		outp.WriteString("{}\n")
+	}
+
+	if g.Ctx {
+		writeCtx(outp, pf)
 	}
 
-	return outp.Bytes(), nil
+	return outp.Finish()
+}
+
+// ReaderName returns the name of the read-only part of an interface type,
+// for example IHelloReader.
+func ReaderName(interName string) string {
+	return interName + "Reader"
+}
+
+// WriterName returns the name of the mutating part of an interface type,
+// for example IHelloWriter.
+func WriterName(interName string) string {
+	return interName + "Writer"
+}
+
+// writeMapAccessors writes the Get and ForEach methods of a map interface
+// type, with the method name suffix.
+func writeMapAccessors(outp *generate.CodeWriter, valueType, suffix string) {
+	// Get()
+	outp.WriteString("\tGet")
+	outp.WriteString(suffix)
+	outp.WriteString("(key string) ")
+	outp.WriteString(valueType)
+	outp.WriteString("\n")
+
+	// ForEach()
+	outp.WriteString("\tForEach")
+	outp.WriteString(suffix)
+	outp.WriteString("(cb func(key string, val ")
+	outp.WriteString(valueType)
+	outp.WriteString(") bool) bool\n")
 }
 
+// writeSplitDoc writes the doc comment of a part of an interface type.
+func writeSplitDoc(outp *generate.CodeWriter, name, part, interName string) {
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is the ")
+	outp.WriteString(part)
+	outp.WriteString(" part of ")
+	outp.WriteString(interName)
+	outp.WriteString(".\n")
+}
+
+// _ is a type assertion
+var _ generate.ParamGenerator = ((*Generator)(nil))
+
 func init() {
 	generate.RegisterGenerator(generatorName, &Generator{})
 }
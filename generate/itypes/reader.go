@@ -0,0 +1,87 @@
+package itypes
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+// ReaderGetterName returns the name of the getter returning a message or map
+// field as its read-only interface type, for example GetExampleReader.
+func ReaderGetterName(field *parser.Field) string {
+	return "Get" + field.CamelName + "Reader"
+}
+
+// WriteReaderGetters writes the getters returning the message and map fields
+// as their read-only interface types, calling the mutable getters. Used by
+// the generators implementing the interface type of the message.
+func WriteReaderGetters(outp *generate.CodeWriter, recvName, recvType string, message *parser.Message) {
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() || field.Kind == parser.FieldKindScalar {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		// func (s *HelloKV) GetExampleReader() IExampleReader
+		outp.WriteString("\n// ")
+		outp.WriteString(ReaderGetterName(field))
+		outp.WriteString(" returns ")
+		outp.WriteString(field.Name)
+		outp.WriteString(" as an ")
+		outp.WriteString(ReaderName(field.GoType))
+		outp.WriteString(".\n")
+		outp.WriteString("func (")
+		outp.WriteString(recvName)
+		outp.WriteString(" ")
+		outp.WriteString(recvType)
+		outp.WriteString(") ")
+		outp.WriteString(ReaderGetterName(field))
+		outp.WriteString("() ")
+		outp.WriteString(ReaderName(field.GoType))
+		outp.WriteString(" {\n\treturn ")
+		outp.WriteString(recvName)
+		outp.WriteString(".")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n}\n")
+	}
+}
+
+// WriteMapReaders writes GetReader and ForEachReader for a map type with
+// message values, returning the values as their read-only interface type.
+// Used by the generators implementing the map type.
+func WriteMapReaders(outp *generate.CodeWriter, recvName, recvType string, mapt *parser.Map) {
+	if mapt.ValueKind != parser.FieldKindMessage {
+		return
+	}
+	valueReader := ReaderName(mapt.Value)
+
+	// GetReader()
+	outp.WriteString("\n// GetReader returns a value from the map as an ")
+	outp.WriteString(valueReader)
+	outp.WriteString(".\n")
+	outp.WriteString("func (")
+	outp.WriteString(recvName)
+	outp.WriteString(" ")
+	outp.WriteString(recvType)
+	outp.WriteString(") GetReader(key string) ")
+	outp.WriteString(valueReader)
+	outp.WriteString(" {\n\treturn ")
+	outp.WriteString(recvName)
+	outp.WriteString(".Get(key)\n}\n")
+
+	// ForEachReader()
+	outp.WriteString("\n// ForEachReader iterates over the map with the values as ")
+	outp.WriteString(valueReader)
+	outp.WriteString(".\n")
+	outp.WriteString("func (")
+	outp.WriteString(recvName)
+	outp.WriteString(" ")
+	outp.WriteString(recvType)
+	outp.WriteString(") ForEachReader(cb func(key string, val ")
+	outp.WriteString(valueReader)
+	outp.WriteString(") bool) bool {\n\treturn ")
+	outp.WriteString(recvName)
+	outp.WriteString(".ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n\t\treturn cb(key, val)\n\t})\n}\n")
+}
@@ -0,0 +1,599 @@
+package journal
+
+import (
+	"strings"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "journal"
+
+// runtimePath is the import path of the journal runtime package.
+const runtimePath = "github.com/paralin/protods/journal"
+
+// Generator generates wrappers recording the mutations of any
+// implementation of the interfaces to a journal, and functions replaying a
+// journal onto an object.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates wrappers recording mutations to a journal, and journal replay functions"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapJournal(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageJournal(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// journalName returns the name of the wrapper type for a message or map
+// implementation type name.
+func journalName(name string) string {
+	return name + "Journal"
+}
+
+// writeMapJournal writes the wrapper, record and apply functions for a map type.
+func writeMapJournal(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := journalName(mapt.ImplName)
+	journalType := outp.Qualify(runtimePath, "Journal")
+	entryType := outp.Qualify(runtimePath, "Entry")
+	isMsg := mapt.ValueKind == parser.FieldKindMessage
+	escape := outp.Qualify("net/url", "PathEscape")
+
+	// type StringExampleMapJournal struct
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" wraps an ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(", recording the mutations to a journal.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tv      ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString("\n\tj      *")
+	outp.WriteString(journalType)
+	outp.WriteString("\n\tprefix string\n}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" wraps the map at the key path prefix.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(v ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(", j *")
+	outp.WriteString(journalType)
+	outp.WriteString(", prefix string) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{v: v, j: j, prefix: prefix}\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	if isMsg {
+		outp.WriteString("\tv := s.v.Get(key)\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn New")
+		outp.WriteString(journalName(mapt.ValueMessage))
+		outp.WriteString("(v, s.j, s.prefix+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key))\n")
+	} else {
+		outp.WriteString("\treturn s.v.Get(key)\n")
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	outp.WriteString("\tkeyPath := s.prefix + \"/\" + ")
+	outp.WriteString(escape)
+	outp.WriteString("(key)\n")
+	if isMsg {
+		outp.WriteString("\tif val == nil {\n")
+		outp.WriteString("\t\ts.j.Delete(keyPath)\n")
+		outp.WriteString("\t\ts.v.Set(key, nil)\n")
+		outp.WriteString("\t\treturn\n\t}\n")
+		outp.WriteString("\trecord")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("(s.j, keyPath, val)\n")
+		outp.WriteString("\ts.v.Set(key, unwrap")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("(val))\n")
+	} else {
+		outp.WriteString("\ts.j.Set(keyPath, val)\n")
+		outp.WriteString("\ts.v.Set(key, val)\n")
+	}
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	if isMsg {
+		outp.WriteString("\treturn s.v.ForEach(func(key string, val ")
+		outp.WriteString(mapt.Value)
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tif val != nil {\n\t\t\tval = New")
+		outp.WriteString(journalName(mapt.ValueMessage))
+		outp.WriteString("(val, s.j, s.prefix+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key))\n\t\t}\n")
+		outp.WriteString("\t\treturn cb(key, val)\n\t})\n")
+	} else {
+		outp.WriteString("\treturn s.v.ForEach(cb)\n")
+	}
+	outp.WriteString("}\n")
+
+	// unwrap()
+	outp.WriteString("\n// unwrap")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(" returns the map wrapped by val, or val.\n")
+	outp.WriteString("func unwrap")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString("(val ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" {\n\tif w, ok := val.(*")
+	outp.WriteString(name)
+	outp.WriteString("); ok {\n\t\treturn w.v\n\t}\n\treturn val\n}\n")
+
+	// record()
+	outp.WriteString("\n// record")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(" records setting the map at the key path to the entries of val.\n")
+	outp.WriteString("func record")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString("(j *")
+	outp.WriteString(journalType)
+	outp.WriteString(", keyPath string, val ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") {\n")
+	outp.WriteString("\tj.Object(keyPath)\n")
+	outp.WriteString("\tval.ForEach(func(key string, v ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	if isMsg {
+		outp.WriteString("\t\tif v == nil {\n")
+		outp.WriteString("\t\t\tj.Delete(keyPath + \"/\" + ")
+		outp.WriteString(escape)
+		outp.WriteString("(key))\n")
+		outp.WriteString("\t\t} else {\n\t\t\trecord")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("(j, keyPath+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key), v)\n\t\t}\n")
+	} else {
+		outp.WriteString("\t\tj.Set(keyPath+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key), v)\n")
+	}
+	outp.WriteString("\t\treturn true\n\t})\n}\n")
+
+	// apply()
+	outp.WriteString("\n// apply")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(" applies a journal entry below the map.\n")
+	outp.WriteString("func apply")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString("(m ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(", segs []string, e *")
+	outp.WriteString(entryType)
+	outp.WriteString(") error {\n")
+	outp.WriteString("\tkey, err := ")
+	outp.WriteString(outp.Qualify("net/url", "PathUnescape"))
+	outp.WriteString("(segs[0])\n")
+	outp.WriteString("\tif err != nil {\n\t\treturn ")
+	outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+	outp.WriteString("(e)\n\t}\n")
+	if isMsg {
+		outp.WriteString("\tif len(segs) == 1 {\n")
+		outp.WriteString("\t\tswitch e.Op {\n")
+		outp.WriteString("\t\tcase ")
+		outp.WriteString(outp.Qualify(runtimePath, "OpObject"))
+		outp.WriteString(":\n\t\t\tm.Set(key, &")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("{})\n\t\t\treturn nil\n")
+		outp.WriteString("\t\tcase ")
+		outp.WriteString(outp.Qualify(runtimePath, "OpDelete"))
+		outp.WriteString(":\n\t\t\tm.Set(key, nil)\n\t\t\treturn nil\n")
+		outp.WriteString("\t\t}\n")
+		outp.WriteString("\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+		outp.WriteString("(e)\n\t}\n")
+		outp.WriteString("\tv := m.Get(key)\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+		outp.WriteString("(e)\n\t}\n")
+		outp.WriteString("\treturn apply")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("(v, segs[1:], e)\n")
+	} else {
+		outp.WriteString("\tif len(segs) != 1 || e.Op != ")
+		outp.WriteString(outp.Qualify(runtimePath, "OpSet"))
+		outp.WriteString(" {\n\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+		outp.WriteString("(e)\n\t}\n")
+		writeDecode(outp, mapt.Value, "\t")
+		outp.WriteString("\tm.Set(key, val)\n")
+		outp.WriteString("\treturn nil\n")
+	}
+	outp.WriteString("}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeDecode writes decoding the value of the entry e into val.
+func writeDecode(outp *generate.CodeWriter, goType, indent string) {
+	outp.WriteString(indent)
+	outp.WriteString("dv, err := e.DecodeValue()\n")
+	outp.WriteString(indent)
+	outp.WriteString("if err != nil {\n")
+	outp.WriteString(indent)
+	outp.WriteString("\treturn err\n")
+	outp.WriteString(indent)
+	outp.WriteString("}\n")
+	outp.WriteString(indent)
+	outp.WriteString("val, ok := dv.(")
+	outp.WriteString(goType)
+	outp.WriteString(")\n")
+	outp.WriteString(indent)
+	outp.WriteString("if !ok {\n")
+	outp.WriteString(indent)
+	outp.WriteString("\treturn ")
+	outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+	outp.WriteString("(e)\n")
+	outp.WriteString(indent)
+	outp.WriteString("}\n")
+}
+
+// writeMessageJournal writes the wrapper, record, apply and replay functions
+// for a message.
+func writeMessageJournal(outp *generate.CodeWriter, message *parser.Message) {
+	name := journalName(message.Name)
+	journalType := outp.Qualify(runtimePath, "Journal")
+	entryType := outp.Qualify(runtimePath, "Entry")
+	outp.Mark(generate.MessageOrigin(message))
+
+	var fields []*parser.Field
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if field.IsSupported() {
+			fields = append(fields, field)
+		}
+	}
+
+	// type ExampleJournal struct
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" wraps an ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(", recording the mutations to a journal.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tv      ")
+	outp.WriteString(message.InterName)
+	outp.WriteString("\n\tj      *")
+	outp.WriteString(journalType)
+	outp.WriteString("\n\tprefix string\n}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" wraps the object at the key path prefix, empty for the root object.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(v ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(", j *")
+	outp.WriteString(journalType)
+	outp.WriteString(", prefix string) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{v: v, j: j, prefix: prefix}\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	for _, field := range fields {
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeFieldJournal(outp, name, field)
+	}
+
+	// unwrap()
+	outp.Mark(generate.MessageOrigin(message))
+	outp.WriteString("\n// unwrap")
+	outp.WriteString(message.Name)
+	outp.WriteString(" returns the object wrapped by val, or val.\n")
+	outp.WriteString("func unwrap")
+	outp.WriteString(message.Name)
+	outp.WriteString("(val ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(" {\n\tif w, ok := val.(*")
+	outp.WriteString(name)
+	outp.WriteString("); ok {\n\t\treturn w.v\n\t}\n\treturn val\n}\n")
+
+	// record()
+	outp.WriteString("\n// record")
+	outp.WriteString(message.Name)
+	outp.WriteString(" records setting the object at the key path to the fields of val.\n")
+	outp.WriteString("func record")
+	outp.WriteString(message.Name)
+	outp.WriteString("(j *")
+	outp.WriteString(journalType)
+	outp.WriteString(", keyPath string, val ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") {\n")
+	outp.WriteString("\tj.Object(keyPath)\n")
+	for _, field := range fields {
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\tj.Set(keyPath+\"")
+			outp.WriteString(field.KeyPath)
+			outp.WriteString("\", val.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("())\n")
+			continue
+		}
+		outp.WriteString("\tif v := val.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(); v != nil {\n\t\trecord")
+		outp.WriteString(recordName(field))
+		outp.WriteString("(j, keyPath+\"")
+		outp.WriteString(field.KeyPath)
+		outp.WriteString("\", v)\n\t}\n")
+	}
+	outp.WriteString("}\n")
+
+	// apply()
+	outp.WriteString("\n// apply")
+	outp.WriteString(message.Name)
+	outp.WriteString(" applies a journal entry below the object.\n")
+	outp.WriteString("func apply")
+	outp.WriteString(message.Name)
+	outp.WriteString("(v ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(", segs []string, e *")
+	outp.WriteString(entryType)
+	outp.WriteString(") error {\n")
+	outp.WriteString("\tif len(segs) == 0 {\n")
+	outp.WriteString("\t\tif e.Op != ")
+	outp.WriteString(outp.Qualify(runtimePath, "OpObject"))
+	outp.WriteString(" {\n\t\t\treturn ")
+	outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+	outp.WriteString("(e)\n\t\t}\n\t\treturn nil\n\t}\n\n")
+	outp.WriteString("\tswitch segs[0] {\n")
+	for _, field := range fields {
+		outp.WriteString("\tcase \"")
+		outp.WriteString(strings.TrimPrefix(field.KeyPath, "/"))
+		outp.WriteString("\":\n")
+		if field.Kind == parser.FieldKindScalar {
+			outp.WriteString("\t\tif len(segs) != 1 || e.Op != ")
+			outp.WriteString(outp.Qualify(runtimePath, "OpSet"))
+			outp.WriteString(" {\n\t\t\treturn ")
+			outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+			outp.WriteString("(e)\n\t\t}\n")
+			writeDecode(outp, field.GoType, "\t\t")
+			outp.WriteString("\t\tv.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(val)\n")
+			outp.WriteString("\t\treturn nil\n")
+			continue
+		}
+
+		outp.WriteString("\t\tif len(segs) == 1 {\n")
+		outp.WriteString("\t\t\tswitch e.Op {\n")
+		outp.WriteString("\t\t\tcase ")
+		outp.WriteString(outp.Qualify(runtimePath, "OpObject"))
+		outp.WriteString(":\n\t\t\t\tv.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(v.")
+		outp.WriteString(field.NewName)
+		outp.WriteString("())\n\t\t\t\treturn nil\n")
+		outp.WriteString("\t\t\tcase ")
+		outp.WriteString(outp.Qualify(runtimePath, "OpDelete"))
+		outp.WriteString(":\n\t\t\t\tv.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(nil)\n\t\t\t\treturn nil\n")
+		outp.WriteString("\t\t\t}\n")
+		outp.WriteString("\t\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+		outp.WriteString("(e)\n\t\t}\n")
+		outp.WriteString("\t\tc := v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+		outp.WriteString("\t\tif c == nil {\n\t\t\treturn ")
+		outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+		outp.WriteString("(e)\n\t\t}\n")
+		outp.WriteString("\t\treturn apply")
+		outp.WriteString(recordName(field))
+		outp.WriteString("(c, segs[1:], e)\n")
+	}
+	outp.WriteString("\t}\n")
+	outp.WriteString("\treturn ")
+	outp.WriteString(outp.Qualify(runtimePath, "InvalidEntry"))
+	outp.WriteString("(e)\n}\n")
+
+	// Replay()
+	outp.WriteString("\n// Replay")
+	outp.WriteString(message.Name)
+	outp.WriteString(" applies the operations of the journal to target, in order.\n")
+	outp.WriteString("func Replay")
+	outp.WriteString(message.Name)
+	outp.WriteString("(j *")
+	outp.WriteString(journalType)
+	outp.WriteString(", target ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") error {\n")
+	outp.WriteString("\tentries := j.Entries()\n")
+	outp.WriteString("\tfor i := range entries {\n")
+	outp.WriteString("\t\te := &entries[i]\n")
+	outp.WriteString("\t\tif err := apply")
+	outp.WriteString(message.Name)
+	outp.WriteString("(target, ")
+	outp.WriteString(outp.Qualify(runtimePath, "SplitPath"))
+	outp.WriteString("(e.Path), e); err != nil {\n\t\t\treturn err\n\t\t}\n")
+	outp.WriteString("\t}\n\treturn nil\n}\n")
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+// recordName returns the message or map name used by the record and apply
+// functions of a message or map field.
+func recordName(field *parser.Field) string {
+	if field.Map != nil {
+		return field.Map.ImplName
+	}
+	return field.Message
+}
+
+// writeFieldJournal writes the wrapper methods for a field.
+func writeFieldJournal(outp *generate.CodeWriter, name string, field *parser.Field) {
+	keyPath := "s.prefix+\"" + field.KeyPath + "\""
+
+	// func (s *HelloJournal) GetSubject() string
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("() ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(" {\n")
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("\treturn s.v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+	} else {
+		outp.WriteString("\tv := s.v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn New")
+		outp.WriteString(journalName(recordName(field)))
+		outp.WriteString("(v, s.j, ")
+		outp.WriteString(keyPath)
+		outp.WriteString(")\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloJournal) SetSubject(val string)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" sets ")
+	outp.WriteString(field.Name)
+	outp.WriteString(" and records it.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(val ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(") {\n")
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("\ts.j.Set(")
+		outp.WriteString(keyPath)
+		outp.WriteString(", val)\n")
+		outp.WriteString("\ts.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val)\n")
+	} else {
+		outp.WriteString("\tif val == nil {\n")
+		outp.WriteString("\t\ts.j.Delete(")
+		outp.WriteString(keyPath)
+		outp.WriteString(")\n")
+		outp.WriteString("\t\ts.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(nil)\n")
+		outp.WriteString("\t\treturn\n\t}\n")
+		outp.WriteString("\trecord")
+		outp.WriteString(recordName(field))
+		outp.WriteString("(s.j, ")
+		outp.WriteString(keyPath)
+		outp.WriteString(", val)\n")
+		outp.WriteString("\ts.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(unwrap")
+		outp.WriteString(recordName(field))
+		outp.WriteString("(val))\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloJournal) NewExample() IExample
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" builds a new ")
+		outp.WriteString(field.Name)
+		outp.WriteString(", recorded when it is set.\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n\treturn s.v.")
+		outp.WriteString(field.NewName)
+		outp.WriteString("()\n}\n")
+	}
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
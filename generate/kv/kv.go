@@ -0,0 +1,413 @@
+package kv
+
+import (
+	"strconv"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "kv"
+
+// runtimePath is the import path of the kv runtime package.
+const runtimePath = "github.com/paralin/protods/kv"
+
+// Generator generates implementations of the interfaces stored in a
+// key/value store, loading each field when its getter is called.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates key/value store backed types loading fields on demand"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMap(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessage(outp, &pf.Messages[mi])
+	}
+
+	return outp.Finish()
+}
+
+// kvName returns the name of the kv type for a message or map
+// implementation type name.
+func kvName(name string) string {
+	return name + "KV"
+}
+
+// writeStruct writes a kv struct type and its constructor.
+func writeStruct(outp *generate.CodeWriter, name, inter string) {
+	treeType := outp.Qualify(runtimePath, "Tree")
+
+	// FooKV is an IFoo stored in a key/value store.
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is an ")
+	outp.WriteString(inter)
+	outp.WriteString(" stored in a key/value store.\n")
+	outp.WriteString("// Values are loaded when the getters are called.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\ttree   *")
+	outp.WriteString(treeType)
+	outp.WriteString("\n\tprefix string\n")
+	outp.WriteString("}\n")
+
+	// func NewFooKV(tree *kv.Tree, prefix string) *FooKV
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a new ")
+	outp.WriteString(name)
+	outp.WriteString(" at the key path prefix of the tree.\n")
+	outp.WriteString("// The root object has an empty prefix.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(tree *")
+	outp.WriteString(treeType)
+	outp.WriteString(", prefix string) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{tree: tree, prefix: prefix}\n}\n")
+
+	// _ is a type assertion
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(inter)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeLoad writes a function body returning the value at the key path.
+// Message and map values are a view of the key path if it is present.
+func writeLoad(outp *generate.CodeWriter, pathExpr string, kind parser.FieldKind, goType, valueKV string) {
+	if kind == parser.FieldKindScalar {
+		outp.WriteString("\t_, v := s.tree.Get(")
+		outp.WriteString(pathExpr)
+		outp.WriteString(")\n")
+		outp.WriteString("\tval, _ := v.(")
+		outp.WriteString(goType)
+		outp.WriteString(")\n")
+		outp.WriteString("\treturn val\n")
+		return
+	}
+
+	outp.WriteString("\tkeyPath := ")
+	outp.WriteString(pathExpr)
+	outp.WriteString("\n")
+	outp.WriteString("\tif found, _ := s.tree.Get(keyPath); !found {\n\t\treturn nil\n\t}\n")
+	outp.WriteString("\treturn New")
+	outp.WriteString(valueKV)
+	outp.WriteString("(s.tree, keyPath)\n")
+}
+
+// writeStore writes a function body storing val at the key path.
+// Message and map values are copied into the store below the key path.
+func writeStore(outp *generate.CodeWriter, pathExpr string, kind parser.FieldKind, valueKV, valueType string) {
+	if kind == parser.FieldKindScalar {
+		outp.WriteString("\ts.tree.Set(")
+		outp.WriteString(pathExpr)
+		outp.WriteString(", val)\n")
+		return
+	}
+
+	outp.WriteString("\tkeyPath := ")
+	outp.WriteString(pathExpr)
+	outp.WriteString("\n")
+	outp.WriteString("\tif val == nil {\n\t\ts.tree.DeleteTree(keyPath)\n\t\treturn\n\t}\n")
+	outp.WriteString("\tif v, ok := val.(*")
+	outp.WriteString(valueKV)
+	outp.WriteString("); ok && v.tree == s.tree && v.prefix == keyPath {\n\t\treturn\n\t}\n\n")
+	if kind == parser.FieldKindMap {
+		outp.WriteString("\ts.tree.DeleteTree(keyPath)\n")
+	}
+	outp.WriteString("\ts.tree.Set(keyPath, ")
+	outp.WriteString(outp.Qualify(runtimePath, "Object"))
+	outp.WriteString("{})\n")
+	if kind == parser.FieldKindMap {
+		outp.WriteString("\tdst := New")
+		outp.WriteString(valueKV)
+		outp.WriteString("(s.tree, keyPath)\n")
+		outp.WriteString("\tval.ForEach(func(key string, v ")
+		outp.WriteString(valueType)
+		outp.WriteString(") bool {\n\t\tdst.Set(key, v)\n\t\treturn true\n\t})\n")
+	} else {
+		outp.WriteString("\tNew")
+		outp.WriteString(valueKV)
+		outp.WriteString("(s.tree, keyPath).copyFrom(val)\n")
+	}
+}
+
+// writeMap writes the kv type for a map type.
+func writeMap(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := kvName(mapt.ImplName)
+	writeStruct(outp, name, mapt.TypeName)
+
+	pathEscape := outp.Qualify("net/url", "PathEscape")
+	pathUnescape := outp.Qualify("net/url", "PathUnescape")
+	prefetcher := outp.Qualify(runtimePath, "Prefetcher")
+	var valueKV string
+	if mapt.ValueKind == parser.FieldKindMessage {
+		valueKV = kvName(mapt.ValueMessage)
+	}
+	pathExpr := "s.prefix + \"/\" + " + pathEscape + "(key)"
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	writeLoad(outp, pathExpr, mapt.ValueKind, mapt.Value, valueKV)
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	writeStore(outp, pathExpr, mapt.ValueKind, valueKV, mapt.Value)
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("// Requires a store implementing kv.Lister.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	outp.WriteString("\tfor _, seg := range s.tree.List(s.prefix) {\n")
+	outp.WriteString("\t\tkey, err := ")
+	outp.WriteString(pathUnescape)
+	outp.WriteString("(seg)\n")
+	outp.WriteString("\t\tif err != nil {\n\t\t\tcontinue\n\t\t}\n")
+	outp.WriteString("\t\tif !cb(key, s.Get(key)) {\n\t\t\treturn false\n\t\t}\n")
+	outp.WriteString("\t}\n\n\treturn true\n}\n")
+
+	// PrefetchKeys()
+	outp.WriteString("\n// PrefetchKeys returns the keys of the map entries.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") PrefetchKeys() []string {\n")
+	outp.WriteString("\tvar keys []string\n")
+	outp.WriteString("\tfor _, seg := range s.tree.List(s.prefix) {\n")
+	outp.WriteString("\t\tkeys = append(keys, s.prefix+\"/\"+seg)\n")
+	outp.WriteString("\t}\n\treturn keys\n}\n")
+
+	// PrefetchChildren()
+	outp.WriteString("\n// PrefetchChildren returns the message values of the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") PrefetchChildren() []")
+	outp.WriteString(prefetcher)
+	outp.WriteString(" {\n")
+	if valueKV == "" {
+		outp.WriteString("\treturn nil\n}\n")
+		return
+	}
+	outp.WriteString("\tvar children []")
+	outp.WriteString(prefetcher)
+	outp.WriteString("\n")
+	outp.WriteString("\tfor _, seg := range s.tree.List(s.prefix) {\n")
+	outp.WriteString("\t\tkeyPath := s.prefix + \"/\" + seg\n")
+	outp.WriteString("\t\tif found, _ := s.tree.Get(keyPath); found {\n")
+	outp.WriteString("\t\t\tchildren = append(children, New")
+	outp.WriteString(valueKV)
+	outp.WriteString("(s.tree, keyPath))\n")
+	outp.WriteString("\t\t}\n\t}\n\treturn children\n}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeMessage writes the kv type for a message.
+func writeMessage(outp *generate.CodeWriter, message *parser.Message) {
+	name := kvName(message.Name)
+	prefetcher := outp.Qualify(runtimePath, "Prefetcher")
+	outp.Mark(generate.MessageOrigin(message))
+	writeStruct(outp, name, message.InterName)
+
+	// func (s *FooKV) Prefetch()
+	outp.WriteString("\n// Prefetch loads the fields of the object and the objects below it,\n")
+	outp.WriteString("// with one batch per level of the tree.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Prefetch() {\n\ts.tree.Prefetch(s)\n}\n")
+
+	// func (s *FooKV) PrefetchKeys() []string
+	outp.WriteString("\n// PrefetchKeys returns the keys of the fields of the object.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") PrefetchKeys() []string {\n")
+	outp.WriteString("\treturn []string{\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.WriteString("\t\ts.prefix + ")
+		outp.WriteString(strconv.Quote(field.KeyPath))
+		outp.WriteString(",\n")
+	}
+	outp.WriteString("\t}\n}\n")
+
+	// func (s *FooKV) PrefetchChildren() []kv.Prefetcher
+	outp.WriteString("\n// PrefetchChildren returns the present message and map fields.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") PrefetchChildren() []")
+	outp.WriteString(prefetcher)
+	outp.WriteString(" {\n")
+	outp.WriteString("\tvar children []")
+	outp.WriteString(prefetcher)
+	outp.WriteString("\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() || field.Kind == parser.FieldKindScalar {
+			continue
+		}
+		fieldKV := kvName(field.Message)
+		if field.Kind == parser.FieldKindMap {
+			fieldKV = kvName(field.Map.ImplName)
+		}
+		outp.WriteString("\tif found, _ := s.tree.Get(s.prefix + ")
+		outp.WriteString(strconv.Quote(field.KeyPath))
+		outp.WriteString("); found {\n")
+		outp.WriteString("\t\tchildren = append(children, New")
+		outp.WriteString(fieldKV)
+		outp.WriteString("(s.tree, s.prefix+")
+		outp.WriteString(strconv.Quote(field.KeyPath))
+		outp.WriteString("))\n\t}\n")
+	}
+	outp.WriteString("\treturn children\n}\n")
+
+	// func (s *FooKV) copyFrom(val IFoo)
+	outp.WriteString("\n// copyFrom stores the fields of val.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") copyFrom(val ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") {\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.WriteString("\ts.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("())\n")
+	}
+	outp.WriteString("}\n")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		pathExpr := "s.prefix + " + strconv.Quote(field.KeyPath)
+		var fieldKV string
+		switch field.Kind {
+		case parser.FieldKindMessage:
+			fieldKV = kvName(field.Message)
+		case parser.FieldKindMap:
+			fieldKV = kvName(field.Map.ImplName)
+		}
+
+		// func (s *HelloKV) GetSubject() string
+		outp.WriteString("\n// ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString(" loads ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n")
+		writeLoad(outp, pathExpr, field.Kind, field.GoType, fieldKV)
+		outp.WriteString("}\n")
+
+		// func (s *HelloKV) SetSubject(val string)
+		outp.WriteString("\n// ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString(" stores ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(") {\n")
+		var valueType string
+		if field.Map != nil {
+			valueType = field.Map.Value
+		}
+		writeStore(outp, pathExpr, field.Kind, fieldKV, valueType)
+		outp.WriteString("}\n")
+
+		// func (s *HelloKV) NewExample() IExample
+		if field.NewName != "" {
+			outp.WriteString("\n// ")
+			outp.WriteString(field.NewName)
+			outp.WriteString(" builds a new detached ")
+			outp.WriteString(field.Name)
+			outp.WriteString(", stored by ")
+			outp.WriteString(field.SetterName)
+			outp.WriteString(".\n")
+			outp.WriteString("func (s *")
+			outp.WriteString(name)
+			outp.WriteString(") ")
+			outp.WriteString(field.NewName)
+			outp.WriteString("() ")
+			outp.WriteString(field.GoType)
+			if field.Map != nil {
+				outp.WriteString(" {\n\treturn make(")
+				outp.WriteString(field.Map.ImplName)
+				outp.WriteString(")\n}\n")
+			} else {
+				outp.WriteString(" {\n\treturn &")
+				outp.WriteString(field.Message)
+				outp.WriteString("{}\n}\n")
+			}
+		}
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,225 @@
+package merge
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "merge"
+
+// Generator generates functions merging objects through the interface
+// types with the semantics of proto.Merge.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates proto.Merge style merge functions between any two implementations of the interfaces"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapMerge(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageMerge(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// MergeName returns the name of the merge function for a message or map
+// implementation type name.
+func MergeName(name string) string {
+	return "Merge" + name
+}
+
+// isSetExpr returns an expression checking if a proto3 scalar value is set,
+// that is not the zero value. Negative zero floats are set, like proto.Merge.
+func isSetExpr(outp *generate.CodeWriter, goType, v string) string {
+	switch goType {
+	case "string":
+		return v + ` != ""`
+	case "[]byte":
+		return "len(" + v + ") != 0"
+	case "bool":
+		return v
+	case "float32":
+		return outp.Qualify("math", "Float32bits") + "(" + v + ") != 0"
+	case "float64":
+		return outp.Qualify("math", "Float64bits") + "(" + v + ") != 0"
+	default:
+		return v + " != 0"
+	}
+}
+
+// writeMapMerge writes the merge function for a map type.
+func writeMapMerge(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := MergeName(mapt.ImplName)
+
+	// func MergeStringExampleMap(dst, src IStringExampleMap)
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" sets the entries of src in dst, replacing existing entries.\n")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString("// Message values are set to an empty ")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString(" and filled in through dst.Get.\n")
+	}
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(dst, src ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") {\n")
+	outp.WriteString("\tsrc.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	if mapt.ValueKind == parser.FieldKindMessage {
+		outp.WriteString("\t\tif val == nil {\n\t\t\tdst.Set(key, nil)\n\t\t\treturn true\n\t\t}\n")
+		outp.WriteString("\t\tdst.Set(key, &")
+		outp.WriteString(mapt.ValueMessage)
+		outp.WriteString("{})\n")
+		outp.WriteString("\t\tif v := dst.Get(key); v != nil {\n")
+		outp.WriteString("\t\t\t")
+		outp.WriteString(MergeName(mapt.ValueMessage))
+		outp.WriteString("(v, val)\n")
+		outp.WriteString("\t\t}\n")
+	} else {
+		outp.WriteString("\t\tdst.Set(key, ")
+		outp.WriteString(cloneValue(mapt.Value, "val"))
+		outp.WriteString(")\n")
+	}
+	outp.WriteString("\t\treturn true\n\t})\n}\n")
+
+	// func isUnsetStringExampleMap(m IStringExampleMap) bool
+	outp.WriteString("\n// ")
+	outp.WriteString(unsetName(mapt))
+	outp.WriteString(" checks if a map field is unset.\n")
+	outp.WriteString("// Empty proto maps are unset, like in proto.Merge.\n")
+	outp.WriteString("func ")
+	outp.WriteString(unsetName(mapt))
+	outp.WriteString("(m ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\tv, ok := m.(")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(")\n")
+	outp.WriteString("\treturn m == nil || ok && len(v) == 0\n}\n")
+}
+
+// unsetName returns the name of the function checking if a map is unset.
+func unsetName(mapt *parser.Map) string {
+	return "isUnset" + mapt.ImplName
+}
+
+// cloneValue returns the expression copying a scalar value.
+// Byte slices are cloned like proto.Merge, the other scalar types are values.
+func cloneValue(goType, expr string) string {
+	if goType == "[]byte" {
+		return "append([]byte(nil), " + expr + "...)"
+	}
+	return expr
+}
+
+// writeMessageMerge writes the merge function for a message.
+func writeMessageMerge(outp *generate.CodeWriter, message *parser.Message) {
+	name := MergeName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+
+	// func MergeExample(dst, src IExample)
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" merges src into dst like proto.Merge.\n")
+	outp.WriteString("// Scalar fields set in src overwrite dst, message fields are merged\n")
+	outp.WriteString("// recursively through the getters of dst, and map entries are set by key.\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if field.Repeated {
+			outp.WriteString("// The repeated field ")
+			outp.WriteString(field.Name)
+			outp.WriteString(" is not part of the interfaces and is not merged.\n")
+		}
+	}
+	outp.WriteString("func ")
+	outp.WriteString(name)
+	outp.WriteString("(dst, src ")
+	outp.WriteString(message.InterName)
+	outp.WriteString(") {\n")
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		// if v := src.GetSubject(); v != "" {
+		outp.WriteString("\tif v := src.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("(); ")
+		switch field.Kind {
+		case parser.FieldKindScalar:
+			outp.WriteString(isSetExpr(outp, field.GoType, "v"))
+			outp.WriteString(" {\n\t\tdst.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(")
+			outp.WriteString(cloneValue(field.GoType, "v"))
+			outp.WriteString(")\n\t}\n")
+			continue
+		case parser.FieldKindMap:
+			outp.WriteString("!")
+			outp.WriteString(unsetName(field.Map))
+			outp.WriteString("(v) {\n")
+			outp.WriteString("\t\tif d := dst.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(); ")
+			outp.WriteString(unsetName(field.Map))
+			outp.WriteString("(d) {\n")
+			outp.WriteString("\t\t\tc := dst.")
+			outp.WriteString(field.NewName)
+			outp.WriteString("()\n\t\t\t")
+			outp.WriteString(MergeName(field.Map.ImplName))
+			outp.WriteString("(c, v)\n\t\t\tdst.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(c)\n")
+			outp.WriteString("\t\t} else {\n\t\t\t")
+			outp.WriteString(MergeName(field.Map.ImplName))
+			outp.WriteString("(d, v)\n\t\t}\n\t}\n")
+		default:
+			outp.WriteString("v != nil {\n")
+			outp.WriteString("\t\tif d := dst.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(); d != nil {\n\t\t\t")
+			outp.WriteString(MergeName(field.Message))
+			outp.WriteString("(d, v)\n")
+			outp.WriteString("\t\t} else {\n")
+			outp.WriteString("\t\t\tc := dst.")
+			outp.WriteString(field.NewName)
+			outp.WriteString("()\n\t\t\t")
+			outp.WriteString(MergeName(field.Message))
+			outp.WriteString("(c, v)\n\t\t\tdst.")
+			outp.WriteString(field.SetterName)
+			outp.WriteString("(c)\n\t\t}\n\t}\n")
+		}
+	}
+	outp.WriteString("}\n")
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+)
+
+// Generator runs an external plugin executable to generate code.
+type Generator struct {
+	// Path is the path to the plugin executable.
+	Path string
+	// Name is the short name of the generator.
+	Name string
+
+	params map[string]string
+}
+
+// NewGenerator builds a new plugin generator for the executable.
+// The name is the filename without the protods-gen- prefix.
+func NewGenerator(execPath string) *Generator {
+	name := strings.TrimSuffix(filepath.Base(execPath), ".exe")
+	name = strings.TrimPrefix(name, ExecutablePrefix)
+	return &Generator{Path: execPath, Name: name}
+}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "runs the external generator " + g.Path
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return g.Name
+}
+
+// SetParam sets a parameter passed to the plugin.
+func (g *Generator) SetParam(key, value string) error {
+	if g.params == nil {
+		g.params = make(map[string]string)
+	}
+	g.params[key] = value
+	return nil
+}
+
+// CloneGenerator returns a copy of the generator.
+func (g *Generator) CloneGenerator() generate.ParamGenerator {
+	clone := &Generator{Path: g.Path, Name: g.Name}
+	for key, value := range g.params {
+		_ = clone.SetParam(key, value)
+	}
+	return clone
+}
+
+// GenerateCode generates code given the input proto file.
+// The base name is derived from the proto filename.
+// Returns an error if the plugin does not generate exactly one file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	protoFilename := path.Base(pf.Filename)
+	if pf.Filename == "" || !strings.HasSuffix(protoFilename, ".proto") {
+		return nil, errors.Errorf("expected .proto filename: %q", pf.Filename)
+	}
+	files, err := g.GenerateFiles(pf, strings.TrimSuffix(protoFilename, ".proto"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		return nil, errors.Errorf("plugin %s generated %d files, expected 1", g.Name, len(files))
+	}
+	return files[0].Content, nil
+}
+
+// GenerateFiles runs the plugin and returns the generated files.
+func (g *Generator) GenerateFiles(pf *parser.File, baseName string) ([]*generate.OutputFile, error) {
+	reqData, err := json.Marshal(&Request{
+		Version:  ProtocolVersion,
+		File:     pf,
+		BaseName: baseName,
+		Params:   g.params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(g.Path)
+	cmd.Stdin = bytes.NewReader(reqData)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "run plugin %s", g.Path)
+	}
+
+	resp := &Response{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, errors.Wrapf(err, "decode plugin %s response", g.Path)
+	}
+	if resp.Error != "" {
+		return nil, errors.Errorf("plugin %s: %s", g.Name, resp.Error)
+	}
+
+	files := make([]*generate.OutputFile, len(resp.Files))
+	for i, f := range resp.Files {
+		files[i] = &generate.OutputFile{Name: f.Name, Content: []byte(f.Content)}
+	}
+	return files, nil
+}
+
+// Discover finds plugin executables named protods-gen-* in a PATH list.
+// Returns a map from generator name to executable path.
+// Earlier directories take precedence, like exec.LookPath.
+func Discover(pathList string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+
+		f, err := os.Open(dir)
+		if err != nil {
+			continue
+		}
+		infos, err := f.Readdir(-1)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+
+		for _, info := range infos {
+			name := info.Name()
+			if !strings.HasPrefix(name, ExecutablePrefix) ||
+				!info.Mode().IsRegular() ||
+				info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+
+			gen := NewGenerator(filepath.Join(dir, name))
+			if gen.Name == "" {
+				continue
+			}
+			if _, ok := plugins[gen.Name]; !ok {
+				plugins[gen.Name] = gen.Path
+			}
+		}
+	}
+
+	return plugins
+}
+
+// _ is a type assertion
+var _ generate.FilesGenerator = ((*Generator)(nil))
+
+// _ is a type assertion
+var _ generate.ParamGenerator = ((*Generator)(nil))
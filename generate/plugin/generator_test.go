@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+)
+
+// testPluginEnv makes the test binary serve echoGenerator as a plugin.
+const testPluginEnv = "PROTODS_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		Serve(&echoGenerator{})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// echoGenerator writes the base name it was called with.
+type echoGenerator struct{}
+
+func (g *echoGenerator) GetUsage() string     { return "echoes the base name" }
+func (g *echoGenerator) GetShortName() string { return "echo" }
+
+func (g *echoGenerator) GenerateCode(pf *parser.File) ([]byte, error) {
+	return nil, errors.New("expected GenerateFiles")
+}
+
+func (g *echoGenerator) GenerateFiles(pf *parser.File, baseName string) ([]*generate.OutputFile, error) {
+	return []*generate.OutputFile{{
+		Name:    baseName + ".echo.go",
+		Content: []byte("package " + pf.PackageName + "\n\nconst BaseName = \"" + baseName + "\"\n"),
+	}}, nil
+}
+
+// TestGenerateCodeBaseName tests GenerateCode passes the proto base name.
+func TestGenerateCodeBaseName(t *testing.T) {
+	os.Setenv(testPluginEnv, "1")
+	defer os.Unsetenv(testPluginEnv)
+
+	gen := NewGenerator(os.Args[0])
+	code, err := gen.GenerateCode(&parser.File{
+		Filename:    "protos/example.proto",
+		PackageName: "test",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(string(code), `BaseName = "example"`) {
+		t.Fatalf("expected base name example: %s", code)
+	}
+
+	if _, err := gen.GenerateCode(&parser.File{PackageName: "test"}); err == nil {
+		t.Fatal("expected error without a proto filename")
+	}
+}
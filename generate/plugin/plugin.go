@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"github.com/paralin/protods/parser"
+)
+
+// ProtocolVersion is the version of the plugin protocol.
+const ProtocolVersion = 1
+
+// ExecutablePrefix is the filename prefix of plugins discovered on PATH.
+// protods-gen-foo is available as protods generate foo.
+const ExecutablePrefix = "protods-gen-"
+
+// Request is written as JSON to the standard input of the plugin.
+type Request struct {
+	// Version is the protocol version.
+	Version int `json:"version"`
+	// File is the parsed proto file, see protods inspect --format json.
+	File *parser.File `json:"file"`
+	// BaseName is the proto filename without the .proto suffix.
+	BaseName string `json:"base_name"`
+	// Params are the generator parameters passed with --param key=value.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Response is read as JSON from the standard output of the plugin.
+type Response struct {
+	// Files are the generated files.
+	Files []*File `json:"files"`
+	// Error is set if generation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// File is a generated file.
+type File struct {
+	// Name is the path of the file relative to the output directory.
+	// Files with a .go suffix are formatted and type checked.
+	Name string `json:"name"`
+	// Content is the content of the file.
+	Content string `json:"content"`
+}
+
+// linkMaps points the map fields at the maps of the file after decoding.
+func linkMaps(pf *parser.File) {
+	mapIndex := make(map[string]*parser.Map, len(pf.Maps))
+	for i := range pf.Maps {
+		mapIndex[pf.Maps[i].TypeName] = &pf.Maps[i]
+	}
+
+	for i := range pf.Messages {
+		fields := pf.Messages[i].Fields
+		for j := range fields {
+			if fields[j].Map == nil {
+				continue
+			}
+			if mt, ok := mapIndex[fields[j].Map.TypeName]; ok {
+				fields[j].Map = mt
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paralin/protods/generate"
+	"github.com/pkg/errors"
+)
+
+// Serve runs a Generator as a plugin, reading the request from stdin and
+// writing the response to stdout. Call it from the main function of a
+// protods-gen-* executable. Exits the process on protocol errors.
+func Serve(gen generate.Generator) {
+	if err := ServeIO(gen, os.Stdin, os.Stdout); err != nil {
+		_, _ = os.Stderr.WriteString("Error: ")
+		_, _ = os.Stderr.WriteString(err.Error())
+		_, _ = os.Stderr.WriteString("\n")
+		os.Exit(1)
+	}
+}
+
+// ServeIO handles a single plugin request.
+// Generation errors are returned to protods in the response.
+func ServeIO(gen generate.Generator, r io.Reader, w io.Writer) error {
+	req := &Request{}
+	if err := json.NewDecoder(r).Decode(req); err != nil {
+		return errors.Wrap(err, "decode request")
+	}
+	if req.Version != ProtocolVersion {
+		return errors.Errorf("unsupported protocol version %d, expected %d", req.Version, ProtocolVersion)
+	}
+	if req.File == nil {
+		return errors.New("request is missing the proto file")
+	}
+	linkMaps(req.File)
+
+	resp := &Response{}
+	files, err := generateFiles(gen, req)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	for _, f := range files {
+		resp.Files = append(resp.Files, &File{Name: f.Name, Content: string(f.Content)})
+	}
+
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// generateFiles runs the generator for the request.
+func generateFiles(gen generate.Generator, req *Request) ([]*generate.OutputFile, error) {
+	gen, err := generate.ApplyParams(gen, req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	if fgen, ok := gen.(generate.FilesGenerator); ok {
+		return fgen.GenerateFiles(req.File, req.BaseName)
+	}
+
+	code, err := gen.GenerateCode(req.File)
+	if err != nil {
+		return nil, err
+	}
+	return []*generate.OutputFile{{
+		Name:    fmt.Sprintf("%s.%s.go", req.BaseName, gen.GetShortName()),
+		Content: code,
+	}}, nil
+}
@@ -0,0 +1,295 @@
+package readonly
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "readonly"
+
+// runtimePath is the import path of the readonly runtime package.
+const runtimePath = "github.com/paralin/protods/readonly"
+
+// Generator generates read-only views of any implementation of the
+// interfaces.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates read-only views with setters raising readonly.ErrReadOnly"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapReadOnly(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageReadOnly(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// ReadOnlyName returns the name of the view type for a message or map
+// implementation type name.
+func ReadOnlyName(name string) string {
+	return "ReadOnly" + name
+}
+
+// writeCommon writes the view struct, constructors and type assertion shared
+// by messages and maps.
+func writeCommon(outp *generate.CodeWriter, name, implName, interName, kind string) {
+	readerName := itypes.ReaderName(interName)
+
+	// type ReadOnlyExample struct
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is a read-only view of an ")
+	outp.WriteString(interName)
+	outp.WriteString(".\n")
+	outp.WriteString("// Nested messages and maps are returned as read-only views, byte slices are\n")
+	outp.WriteString("// copied, and the setters panic with a *readonly.Error.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tv      ")
+	outp.WriteString(readerName)
+	outp.WriteString("\n\tprefix string\n}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a read-only view of the ")
+	outp.WriteString(kind)
+	outp.WriteString(".\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(v ")
+	outp.WriteString(readerName)
+	outp.WriteString(") *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{v: v}\n}\n")
+
+	// func FreezeExample(v IExampleReader) IExampleReader
+	outp.WriteString("\n// Freeze")
+	outp.WriteString(implName)
+	outp.WriteString(" returns a read-only view of the ")
+	outp.WriteString(kind)
+	outp.WriteString(" typed as ")
+	outp.WriteString(readerName)
+	outp.WriteString(",\n// so the setters are not available without a type assertion.\n")
+	outp.WriteString("func Freeze")
+	outp.WriteString(implName)
+	outp.WriteString("(v ")
+	outp.WriteString(readerName)
+	outp.WriteString(") ")
+	outp.WriteString(readerName)
+	outp.WriteString(" {\n\treturn New")
+	outp.WriteString(name)
+	outp.WriteString("(v)\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(interName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+}
+
+// writePanic writes raising a read-only error for the key path.
+func writePanic(outp *generate.CodeWriter, path string) {
+	outp.WriteString("\t")
+	outp.WriteString(outp.Qualify(runtimePath, "Panic"))
+	outp.WriteString("(")
+	outp.WriteString(path)
+	outp.WriteString(")\n")
+}
+
+// writeMapReadOnly writes the view for a map type.
+func writeMapReadOnly(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := ReadOnlyName(mapt.ImplName)
+	isMsg := mapt.ValueKind == parser.FieldKindMessage
+	escape := outp.Qualify("net/url", "PathEscape")
+	writeCommon(outp, name, mapt.ImplName, mapt.TypeName, "map")
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	if isMsg {
+		outp.WriteString("\tv := s.v.GetReader(key)\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn &")
+		outp.WriteString(ReadOnlyName(mapt.ValueMessage))
+		outp.WriteString("{v: v, prefix: s.prefix + \"/\" + ")
+		outp.WriteString(escape)
+		outp.WriteString("(key)}\n")
+	} else {
+		outp.WriteString("\treturn ")
+		outp.WriteString(copyValue(mapt.Value, "s.v.Get(key)"))
+		outp.WriteString("\n")
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set panics with a *readonly.Error.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	writePanic(outp, "s.prefix + \"/\" + "+escape+"(key)")
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	switch {
+	case isMsg:
+		outp.WriteString("\treturn s.v.ForEachReader(func(key string, val ")
+		outp.WriteString(itypes.ReaderName(mapt.Value))
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tvar v ")
+		outp.WriteString(mapt.Value)
+		outp.WriteString("\n\t\tif val != nil {\n\t\t\tv = &")
+		outp.WriteString(ReadOnlyName(mapt.ValueMessage))
+		outp.WriteString("{v: val, prefix: s.prefix + \"/\" + ")
+		outp.WriteString(escape)
+		outp.WriteString("(key)}\n\t\t}\n")
+		outp.WriteString("\t\treturn cb(key, v)\n\t})\n")
+	case mapt.Value == "[]byte":
+		outp.WriteString("\treturn s.v.ForEach(func(key string, val []byte) bool {\n")
+		outp.WriteString("\t\treturn cb(key, ")
+		outp.WriteString(copyValue(mapt.Value, "val"))
+		outp.WriteString(")\n\t})\n")
+	default:
+		outp.WriteString("\treturn s.v.ForEach(cb)\n")
+	}
+	outp.WriteString("}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// copyValue returns the expression copying a scalar value read from the
+// wrapped object, so byte slices cannot be modified through the view.
+func copyValue(goType, expr string) string {
+	if goType == "[]byte" {
+		return "append([]byte(nil), " + expr + "...)"
+	}
+	return expr
+}
+
+// writeMessageReadOnly writes the view for a message.
+func writeMessageReadOnly(outp *generate.CodeWriter, message *parser.Message) {
+	name := ReadOnlyName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+	writeCommon(outp, name, message.Name, message.InterName, "object")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeFieldReadOnly(outp, name, field)
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+// writeFieldReadOnly writes the view methods for a field.
+func writeFieldReadOnly(outp *generate.CodeWriter, name string, field *parser.Field) {
+	keyPath := "s.prefix + \"" + field.KeyPath + "\""
+
+	// func (s *ReadOnlyHello) GetSubject() string
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("() ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(" {\n")
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("\treturn ")
+		outp.WriteString(copyValue(field.GoType, "s.v."+field.GetterName+"()"))
+		outp.WriteString("\n")
+	} else {
+		nestedName := field.Message
+		if field.Map != nil {
+			nestedName = field.Map.ImplName
+		}
+		outp.WriteString("\tv := s.v.")
+		outp.WriteString(itypes.ReaderGetterName(field))
+		outp.WriteString("()\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn &")
+		outp.WriteString(ReadOnlyName(nestedName))
+		outp.WriteString("{v: v, prefix: ")
+		outp.WriteString(keyPath)
+		outp.WriteString("}\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *ReadOnlyHello) SetSubject(val string)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" panics with a *readonly.Error.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(val ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(") {\n")
+	writePanic(outp, keyPath)
+	outp.WriteString("}\n")
+
+	// func (s *ReadOnlyHello) NewExample() IExample
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" panics with a *readonly.Error.\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n")
+		writePanic(outp, keyPath)
+		outp.WriteString("\treturn nil\n}\n")
+	}
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
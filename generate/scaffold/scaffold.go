@@ -0,0 +1,285 @@
+package scaffold
+
+import (
+	"strconv"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "scaffold"
+
+// hooksName is the name of the hooks interface in the generated code.
+const hooksName = "ScaffoldHooks"
+
+// Generator generates skeleton backend types implementing the interfaces.
+// Each message and map type gets a type routing all access through the
+// ScaffoldHooks interface, as a starting point for a new backend.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates skeleton backend types routed through load/store/list hooks"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	outp.Imports.Reserve(hooksName)
+
+	writeHooks(outp)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapScaffold(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageScaffold(outp, &pf.Messages[mi])
+	}
+
+	return outp.Finish()
+}
+
+// scaffoldName returns the name of the scaffold type for a message or map
+// implementation type name.
+func scaffoldName(name string) string {
+	return name + "Scaffold"
+}
+
+// writeHooks writes the hooks interface.
+func writeHooks(outp *generate.CodeWriter) {
+	outp.WriteString("\n// ")
+	outp.WriteString(hooksName)
+	outp.WriteString(" are the hooks the scaffold types load and store values with.\n")
+	outp.WriteString("// Values are addressed by key path, for example /lower_kv/test/value.\n")
+	outp.WriteString("type ")
+	outp.WriteString(hooksName)
+	outp.WriteString(" interface {\n")
+	outp.WriteString("\t// LoadField loads the value at the key path.\n")
+	outp.WriteString("\t// Returns false if the value is not set.\n")
+	outp.WriteString("\tLoadField(keyPath string) (interface{}, bool)\n")
+	outp.WriteString("\t// StoreField stores the value at the key path.\n")
+	outp.WriteString("\t// A nil value clears the key path. Message and map values are the\n")
+	outp.WriteString("\t// interface types; their contents are stored below the key path.\n")
+	outp.WriteString("\tStoreField(keyPath string, val interface{})\n")
+	outp.WriteString("\t// ListKeys lists the path escaped keys directly below the key path.\n")
+	outp.WriteString("\tListKeys(keyPath string) []string\n")
+	outp.WriteString("}\n")
+}
+
+// writeStruct writes a scaffold struct type and its constructor.
+func writeStruct(outp *generate.CodeWriter, name, inter string) {
+	// FooScaffold is a skeleton IFoo routed through ScaffoldHooks.
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is a skeleton ")
+	outp.WriteString(inter)
+	outp.WriteString(" routed through ")
+	outp.WriteString(hooksName)
+	outp.WriteString(".\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\t// Hooks load and store the values.\n")
+	outp.WriteString("\tHooks ")
+	outp.WriteString(hooksName)
+	outp.WriteString("\n\t// Prefix is the key path of the object.\n")
+	outp.WriteString("\tPrefix string\n")
+	outp.WriteString("}\n")
+
+	// func NewFooScaffold(hooks ScaffoldHooks, prefix string) *FooScaffold
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a new ")
+	outp.WriteString(name)
+	outp.WriteString(" at the key path prefix.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(hooks ")
+	outp.WriteString(hooksName)
+	outp.WriteString(", prefix string) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{Hooks: hooks, Prefix: prefix}\n}\n")
+
+	// _ is a type assertion
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(inter)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+}
+
+// writeLoad writes a function body loading the value at the key path
+// expression into the return value.
+// Message and map values are a scaffold at the key path if it is set.
+func writeLoad(outp *generate.CodeWriter, pathExpr string, kind parser.FieldKind, goType, scaffold string) {
+	if kind == parser.FieldKindScalar {
+		outp.WriteString("\tv, _ := s.Hooks.LoadField(")
+		outp.WriteString(pathExpr)
+		outp.WriteString(")\n")
+		outp.WriteString("\tval, _ := v.(")
+		outp.WriteString(goType)
+		outp.WriteString(")\n")
+		outp.WriteString("\treturn val\n")
+		return
+	}
+
+	outp.WriteString("\tkeyPath := ")
+	outp.WriteString(pathExpr)
+	outp.WriteString("\n")
+	outp.WriteString("\tif _, ok := s.Hooks.LoadField(keyPath); !ok {\n\t\treturn nil\n\t}\n")
+	outp.WriteString("\treturn New")
+	outp.WriteString(scaffold)
+	outp.WriteString("(s.Hooks, keyPath)\n")
+}
+
+// writeMapScaffold writes the scaffold for a map type.
+func writeMapScaffold(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := scaffoldName(mapt.ImplName)
+	writeStruct(outp, name, mapt.TypeName)
+
+	pathEscape := outp.Qualify("net/url", "PathEscape")
+	pathUnescape := outp.Qualify("net/url", "PathUnescape")
+	var valueScaffold string
+	if mapt.ValueKind == parser.FieldKindMessage {
+		valueScaffold = scaffoldName(mapt.ValueMessage)
+	}
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	writeLoad(outp, "s.Prefix + \"/\" + "+pathEscape+"(key)", mapt.ValueKind, mapt.Value, valueScaffold)
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	outp.WriteString("\ts.Hooks.StoreField(s.Prefix+\"/\"+")
+	outp.WriteString(pathEscape)
+	outp.WriteString("(key), val)\n")
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	outp.WriteString("\tfor _, k := range s.Hooks.ListKeys(s.Prefix) {\n")
+	outp.WriteString("\t\tkey, err := ")
+	outp.WriteString(pathUnescape)
+	outp.WriteString("(k)\n")
+	outp.WriteString("\t\tif err != nil {\n\t\t\tcontinue\n\t\t}\n")
+	outp.WriteString("\t\tif !cb(key, s.Get(key)) {\n\t\t\treturn false\n\t\t}\n")
+	outp.WriteString("\t}\n\n\treturn true\n}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeMessageScaffold writes the scaffold for a message.
+func writeMessageScaffold(outp *generate.CodeWriter, message *parser.Message) {
+	name := scaffoldName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+	writeStruct(outp, name, message.InterName)
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+
+		pathExpr := "s.Prefix + " + strconv.Quote(field.KeyPath)
+		var fieldScaffold string
+		switch field.Kind {
+		case parser.FieldKindMessage:
+			fieldScaffold = scaffoldName(field.Message)
+		case parser.FieldKindMap:
+			fieldScaffold = scaffoldName(field.Map.ImplName)
+		}
+
+		// func (s *HelloScaffold) GetSubject() string
+		outp.WriteString("\n// ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString(" loads ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n")
+		writeLoad(outp, pathExpr, field.Kind, field.GoType, fieldScaffold)
+		outp.WriteString("}\n")
+
+		// func (s *HelloScaffold) SetSubject(val string)
+		outp.WriteString("\n// ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString(" stores ")
+		outp.WriteString(field.Name)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(") {\n")
+		outp.WriteString("\ts.Hooks.StoreField(")
+		outp.WriteString(pathExpr)
+		outp.WriteString(", val)\n}\n")
+
+		// func (s *HelloScaffold) NewExample() IExample
+		if field.NewName != "" {
+			outp.WriteString("\n// ")
+			outp.WriteString(field.NewName)
+			outp.WriteString(" builds a new ")
+			outp.WriteString(field.Name)
+			outp.WriteString(" at the key path of the field.\n")
+			outp.WriteString("func (s *")
+			outp.WriteString(name)
+			outp.WriteString(") ")
+			outp.WriteString(field.NewName)
+			outp.WriteString("() ")
+			outp.WriteString(field.GoType)
+			outp.WriteString(" {\n\treturn New")
+			outp.WriteString(fieldScaffold)
+			outp.WriteString("(s.Hooks, ")
+			outp.WriteString(pathExpr)
+			outp.WriteString(")\n}\n")
+		}
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
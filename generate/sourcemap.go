@@ -0,0 +1,115 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"text/scanner"
+
+	"github.com/paralin/protods/parser"
+)
+
+// Origin identifies the proto construct a span of generated code came from.
+type Origin struct {
+	// Message is the name of the message, if any.
+	Message string
+	// Field is the name of the field, if any.
+	Field string
+	// Map is the name of the map interface type, if any.
+	Map string
+	// Position is the location of the construct in the proto source.
+	Position scanner.Position
+}
+
+// MessageOrigin returns the origin for code generated from a message.
+func MessageOrigin(msg *parser.Message) Origin {
+	return Origin{Message: msg.Name, Position: msg.Position}
+}
+
+// FieldOrigin returns the origin for code generated from a message field.
+func FieldOrigin(msg *parser.Message, field *parser.Field) Origin {
+	return Origin{Message: msg.Name, Field: field.Name, Position: field.Position}
+}
+
+// MapOrigin returns the origin for code generated from a map type.
+func MapOrigin(mapt *parser.Map) Origin {
+	return Origin{Map: mapt.TypeName, Position: mapt.Position}
+}
+
+// String describes the construct, for example "message Hello field subject".
+func (o Origin) String() string {
+	switch {
+	case o.Field != "":
+		return fmt.Sprintf("message %s field %s", o.Message, o.Field)
+	case o.Message != "":
+		return fmt.Sprintf("message %s", o.Message)
+	case o.Map != "":
+		return fmt.Sprintf("map type %s", o.Map)
+	default:
+		return "file"
+	}
+}
+
+// sourceSpan is a span of generated code starting at offset.
+type sourceSpan struct {
+	offset int
+	origin Origin
+}
+
+// SourceMap attributes byte offsets of generated code to proto constructs.
+type SourceMap struct {
+	spans []sourceSpan
+}
+
+// NewSourceMap builds a new empty source map.
+func NewSourceMap() *SourceMap {
+	return &SourceMap{}
+}
+
+// Mark records that code from offset onwards was generated from origin.
+// The span continues until the next marked offset.
+func (s *SourceMap) Mark(offset int, origin Origin) {
+	if s == nil {
+		return
+	}
+
+	s.spans = append(s.spans, sourceSpan{offset: offset, origin: origin})
+}
+
+// Lookup returns the origin of the code at the byte offset.
+func (s *SourceMap) Lookup(offset int) (Origin, bool) {
+	if s == nil || len(s.spans) == 0 {
+		return Origin{}, false
+	}
+
+	spans := s.spans
+	if !sort.SliceIsSorted(spans, func(i, j int) bool {
+		return spans[i].offset < spans[j].offset
+	}) {
+		sort.SliceStable(spans, func(i, j int) bool {
+			return spans[i].offset < spans[j].offset
+		})
+	}
+
+	idx := sort.Search(len(spans), func(i int) bool {
+		return spans[i].offset > offset
+	})
+	if idx == 0 {
+		return Origin{}, false
+	}
+
+	return spans[idx-1].origin, true
+}
+
+// Shift moves the spans at or after offset by delta bytes.
+// Used when code is inserted into the generated output.
+func (s *SourceMap) Shift(offset, delta int) {
+	if s == nil {
+		return
+	}
+
+	for i := range s.spans {
+		if s.spans[i].offset >= offset {
+			s.spans[i].offset += delta
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/scanner"
+
+	"github.com/paralin/protods/parser"
+	"github.com/serenize/snaker"
+)
+
+// rowIDColumn is the primary key column of the message tables.
+// Proto field names cannot start with an underscore, so it cannot collide.
+const rowIDColumn = "_id"
+
+// sqlTypes maps scalar Go types to SQL column types and defaults.
+// database/sql does not accept uint64 values with the high bit set, so uint64
+// values are stored as the int64 with the same bits, see storedGoType.
+var sqlTypes = map[string][2]string{
+	"string":  {"TEXT", "''"},
+	"[]byte":  {"BLOB", "X''"},
+	"bool":    {"BOOLEAN", "0"},
+	"int32":   {"INTEGER", "0"},
+	"int64":   {"INTEGER", "0"},
+	"uint32":  {"INTEGER", "0"},
+	"uint64":  {"INTEGER", "0"},
+	"float32": {"REAL", "0"},
+	"float64": {"REAL", "0"},
+}
+
+// storedGoType returns the Go type of the values passed to database/sql for
+// a scalar Go type.
+func storedGoType(goType string) string {
+	if goType == "uint64" {
+		return "int64"
+	}
+	return goType
+}
+
+// quoteIdent quotes a SQL identifier.
+func quoteIdent(ident string) string {
+	return `"` + ident + `"`
+}
+
+// TableName returns the name of the table for a message.
+func TableName(msg *parser.Message) string {
+	return snaker.CamelToSnake(msg.Name)
+}
+
+// MapTableName returns the name of the child table for a map field.
+func MapTableName(msg *parser.Message, field *parser.Field) string {
+	return TableName(msg) + "_" + field.Name
+}
+
+// refColumn returns the foreign key column for a message field.
+func refColumn(field *parser.Field) string {
+	return field.Name + "_id"
+}
+
+// NameCollision is a table or column name generated for two constructs.
+type NameCollision struct {
+	// Position is the location of the second construct in the proto source.
+	Position scanner.Position
+	// Message describes the collision.
+	Message string
+}
+
+// Error returns the collision as a string.
+func (c *NameCollision) Error() string {
+	return c.Position.String() + ": " + c.Message
+}
+
+// NameCollisions returns the table names shared by two messages or map
+// fields and the column names shared by two fields of a message. Names are
+// joined with underscores, so for example the map field bar of Foo and the
+// message FooBar both use the table foo_bar. SQLite compares names
+// case-insensitively.
+func NameCollisions(pf *parser.File) []*NameCollision {
+	var collisions []*NameCollision
+	declare := func(declared map[string]string, pos scanner.Position, name, desc string) {
+		key := strings.ToLower(name)
+		if prev, ok := declared[key]; ok {
+			collisions = append(collisions, &NameCollision{
+				Position: pos,
+				Message:  fmt.Sprintf("%s %q collides with %s", desc, name, prev),
+			})
+			return
+		}
+		declared[key] = desc
+	}
+
+	tables := make(map[string]string)
+	for mi := range pf.Messages {
+		msg := &pf.Messages[mi]
+		declare(tables, msg.Position, TableName(msg), "table for message "+msg.Name)
+
+		columns := map[string]string{rowIDColumn: "the row ID column"}
+		for fi := range msg.Fields {
+			field := &msg.Fields[fi]
+			if !isSupportedField(pf, field) {
+				continue
+			}
+			desc := msg.Name + "." + field.Name
+			switch field.Kind {
+			case parser.FieldKindScalar:
+				declare(columns, field.Position, field.Name, "column for field "+desc)
+			case parser.FieldKindMessage:
+				declare(columns, field.Position, refColumn(field), "reference column for field "+desc)
+			case parser.FieldKindMap:
+				declare(tables, field.Position, MapTableName(msg, field), "table for map field "+desc)
+			}
+		}
+	}
+	return collisions
+}
+
+// isSupportedField checks if the field can be stored in a table.
+// Referenced messages must be declared in the proto file.
+func isSupportedField(pf *parser.File, field *parser.Field) bool {
+	if !field.IsSupported() {
+		return false
+	}
+	switch field.Kind {
+	case parser.FieldKindScalar:
+		_, ok := sqlTypes[field.GoType]
+		return ok
+	case parser.FieldKindMessage:
+		return pf.GetMessage(field.Message) != nil
+	case parser.FieldKindMap:
+		return isSupportedMap(pf, field.Map)
+	}
+	return false
+}
+
+// isSupportedMap checks if a map type can be stored in a child table.
+func isSupportedMap(pf *parser.File, mapt *parser.Map) bool {
+	if mapt.ValueKind == parser.FieldKindMessage {
+		return pf.GetMessage(mapt.ValueMessage) != nil
+	}
+	_, ok := sqlTypes[mapt.Value]
+	return ok
+}
+
+// sortMessages orders the messages so referenced tables come first.
+func sortMessages(pf *parser.File) []*parser.Message {
+	var sorted []*parser.Message
+	visited := make(map[string]bool)
+	var visit func(msg *parser.Message)
+	visit = func(msg *parser.Message) {
+		if msg == nil || visited[msg.Name] {
+			return
+		}
+		visited[msg.Name] = true
+		for fi := range msg.Fields {
+			field := &msg.Fields[fi]
+			if !isSupportedField(pf, field) {
+				continue
+			}
+			switch {
+			case field.Kind == parser.FieldKindMessage:
+				visit(pf.GetMessage(field.Message))
+			case field.Kind == parser.FieldKindMap && field.Map.ValueKind == parser.FieldKindMessage:
+				visit(pf.GetMessage(field.Map.ValueMessage))
+			}
+		}
+		sorted = append(sorted, msg)
+	}
+	for mi := range pf.Messages {
+		visit(&pf.Messages[mi])
+	}
+	return sorted
+}
+
+// Schema returns the CREATE TABLE statements for the proto file.
+//
+// Each message has a table with an integer primary key. Scalar fields are
+// columns, message fields are foreign keys to the table of the message, and
+// map fields are child tables keyed by the parent row and the map key.
+func Schema(pf *parser.File) []string {
+	var stmts []string
+	for _, msg := range sortMessages(pf) {
+		var outp bytes.Buffer
+		outp.WriteString("CREATE TABLE IF NOT EXISTS ")
+		outp.WriteString(quoteIdent(TableName(msg)))
+		outp.WriteString(" (\n\t")
+		outp.WriteString(quoteIdent(rowIDColumn))
+		outp.WriteString(" INTEGER PRIMARY KEY")
+		var mapFields []*parser.Field
+		for fi := range msg.Fields {
+			field := &msg.Fields[fi]
+			if !isSupportedField(pf, field) {
+				continue
+			}
+			switch field.Kind {
+			case parser.FieldKindScalar:
+				outp.WriteString(",\n\t")
+				writeValueColumn(&outp, field.Name, field.GoType)
+			case parser.FieldKindMessage:
+				outp.WriteString(",\n\t")
+				writeRefColumn(&outp, refColumn(field), pf.GetMessage(field.Message))
+			case parser.FieldKindMap:
+				mapFields = append(mapFields, field)
+			}
+		}
+		outp.WriteString("\n)")
+		stmts = append(stmts, outp.String())
+
+		for _, field := range mapFields {
+			stmts = append(stmts, mapSchema(pf, msg, field))
+		}
+	}
+	return stmts
+}
+
+// mapSchema returns the CREATE TABLE statement for a map field.
+func mapSchema(pf *parser.File, msg *parser.Message, field *parser.Field) string {
+	var outp bytes.Buffer
+	outp.WriteString("CREATE TABLE IF NOT EXISTS ")
+	outp.WriteString(quoteIdent(MapTableName(msg, field)))
+	outp.WriteString(" (\n\t")
+	outp.WriteString(quoteIdent("parent_id"))
+	outp.WriteString(" INTEGER NOT NULL REFERENCES ")
+	outp.WriteString(quoteIdent(TableName(msg)))
+	outp.WriteString(" (")
+	outp.WriteString(quoteIdent(rowIDColumn))
+	outp.WriteString(") ON DELETE CASCADE,\n\t")
+	outp.WriteString(quoteIdent("key"))
+	outp.WriteString(" TEXT NOT NULL,\n\t")
+	if field.Map.ValueKind == parser.FieldKindMessage {
+		writeRefColumn(&outp, "value_id", pf.GetMessage(field.Map.ValueMessage))
+	} else {
+		writeValueColumn(&outp, "value", field.Map.Value)
+	}
+	outp.WriteString(",\n\tPRIMARY KEY (")
+	outp.WriteString(quoteIdent("parent_id"))
+	outp.WriteString(", ")
+	outp.WriteString(quoteIdent("key"))
+	outp.WriteString(")\n)")
+	return outp.String()
+}
+
+// writeValueColumn writes a scalar column definition.
+func writeValueColumn(outp *bytes.Buffer, name, goType string) {
+	sqlType := sqlTypes[goType]
+	outp.WriteString(quoteIdent(name))
+	outp.WriteString(" ")
+	outp.WriteString(sqlType[0])
+	outp.WriteString(" NOT NULL DEFAULT ")
+	outp.WriteString(sqlType[1])
+}
+
+// writeRefColumn writes a foreign key column definition.
+func writeRefColumn(outp *bytes.Buffer, name string, msg *parser.Message) {
+	outp.WriteString(quoteIdent(name))
+	outp.WriteString(" INTEGER REFERENCES ")
+	outp.WriteString(quoteIdent(TableName(msg)))
+	outp.WriteString(" (")
+	outp.WriteString(quoteIdent(rowIDColumn))
+	outp.WriteString(") ON DELETE SET NULL")
+}
@@ -0,0 +1,57 @@
+package sql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/parser"
+)
+
+// parseString parses a proto source for tests.
+func parseString(t *testing.T, src string) *parser.File {
+	t.Helper()
+	pp, err := parser.ParseProto("test.proto", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pf, err := parser.Parse(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return pf
+}
+
+func TestNameCollisions(t *testing.T) {
+	pf := parseString(t, `syntax = "proto3";
+package test;
+
+message Foo {
+  map<string, int64> bar = 1;
+  Foo child = 2;
+  int64 child_id = 3;
+  string Name = 4;
+  string name = 5;
+}
+
+message FooBar {
+}
+`)
+
+	collisions := NameCollisions(pf)
+	var msgs []string
+	for _, c := range collisions {
+		msgs = append(msgs, c.Message)
+	}
+	expected := []string{
+		`column for field Foo.child_id "child_id" collides with reference column for field Foo.child`,
+		`column for field Foo.name "name" collides with column for field Foo.Name`,
+		`table for message FooBar "foo_bar" collides with table for map field Foo.bar`,
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected collisions:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	if _, err := (&Generator{}).GenerateFiles(pf, "test"); err == nil {
+		t.Fatal("expected the generator to fail")
+	}
+}
@@ -0,0 +1,583 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+)
+
+const generatorName = "sql"
+
+// runtimePath is the import path of the sqldb runtime package.
+const runtimePath = "github.com/paralin/protods/sqldb"
+
+// Generator maps each message to a table and generates implementations of
+// the interfaces reading and writing the rows with database/sql.
+//
+// Writes the CREATE TABLE statements to <base>.sql and the code, including
+// the statements, to <base>.sql.go. The SQL uses the SQLite dialect.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates a table per message, the DDL and database/sql backed types"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := generateCode(pf, pf.PackageName)
+	return code, err
+}
+
+// GenerateFiles generates the DDL and the code given the input proto file.
+func (g *Generator) GenerateFiles(pf *parser.File, baseName string) ([]*generate.OutputFile, error) {
+	code, sm, err := generateCode(pf, baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	var ddl strings.Builder
+	for _, stmt := range Schema(pf) {
+		ddl.WriteString(stmt)
+		ddl.WriteString(";\n\n")
+	}
+
+	return []*generate.OutputFile{
+		{Name: baseName + ".sql", Content: []byte(ddl.String())},
+		{Name: baseName + ".sql.go", Content: code, SourceMap: sm},
+	}, nil
+}
+
+// SchemaName returns the name of the schema variable for a proto file.
+func SchemaName(baseName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, baseName)
+	return parser.CamelCase(name) + "SQLSchema"
+}
+
+// sqlName returns the name of the SQL backed type for a message or map
+// implementation type name.
+func sqlName(name string) string {
+	return name + "SQL"
+}
+
+// generateCode generates the code for the proto file.
+func generateCode(pf *parser.File, baseName string) ([]byte, *generate.SourceMap, error) {
+	if collisions := NameCollisions(pf); len(collisions) != 0 {
+		return nil, nil, errors.Wrap(collisions[0], "sql name collision")
+	}
+
+	outp := generate.NewCodeWriter(pf)
+	writeSchema(outp, pf, SchemaName(baseName))
+
+	// map types are shared by all map fields with the same types.
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() || !isSupportedMap(pf, mapt) {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMap(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessage(outp, pf, &pf.Messages[mi])
+	}
+
+	return outp.Finish()
+}
+
+// writeSchema writes the schema statements variable.
+func writeSchema(outp *generate.CodeWriter, pf *parser.File, name string) {
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" contains the CREATE TABLE statements for the messages.\n")
+	outp.WriteString("var ")
+	outp.WriteString(name)
+	outp.WriteString(" = []string{\n")
+	for _, stmt := range Schema(pf) {
+		outp.WriteString("\t`")
+		outp.WriteString(stmt)
+		outp.WriteString("`,\n")
+	}
+	outp.WriteString("}\n")
+}
+
+// writeScalarGet writes a function body selecting a scalar column.
+func writeScalarGet(outp *generate.CodeWriter, query, args, goType string) {
+	storedType := storedGoType(goType)
+	outp.WriteString("\tvar val ")
+	outp.WriteString(storedType)
+	outp.WriteString("\n\ts.db.Get(")
+	outp.WriteString(query)
+	outp.WriteString(", []interface{}{")
+	outp.WriteString(args)
+	outp.WriteString("}, &val)\n")
+	if storedType != goType {
+		outp.WriteString("\treturn ")
+		outp.WriteString(goType)
+		outp.WriteString("(val)\n")
+		return
+	}
+	outp.WriteString("\treturn val\n")
+}
+
+// storedValue returns the expression converting val to the stored type.
+func storedValue(goType string) string {
+	if storedType := storedGoType(goType); storedType != goType {
+		return storedType + "(val)"
+	}
+	return "val"
+}
+
+// writeScalarSet writes a function body executing a statement storing val.
+// Nil byte slices are stored as empty, the columns are NOT NULL.
+func writeScalarSet(outp *generate.CodeWriter, query, args, goType string) {
+	if goType == "[]byte" {
+		outp.WriteString("\tif val == nil {\n\t\tval = []byte{}\n\t}\n")
+	}
+	outp.WriteString("\ts.db.Exec(")
+	outp.WriteString(query)
+	outp.WriteString(", ")
+	outp.WriteString(args)
+	outp.WriteString(")\n")
+}
+
+// writeRefGet writes a function body selecting a foreign key column and
+// returning the referenced object, or nil.
+func writeRefGet(outp *generate.CodeWriter, query, args, valueSQL string) {
+	outp.WriteString("\tvar id ")
+	outp.WriteString(outp.Qualify("database/sql", "NullInt64"))
+	outp.WriteString("\n\tif !s.db.Get(")
+	outp.WriteString(query)
+	outp.WriteString(", []interface{}{")
+	outp.WriteString(args)
+	outp.WriteString("}, &id) || !id.Valid {\n\t\treturn nil\n\t}\n")
+	outp.WriteString("\treturn New")
+	outp.WriteString(valueSQL)
+	outp.WriteString("(s.db, id.Int64)\n")
+}
+
+// writeRefSet writes a function body replacing a referenced object.
+// The new value is copied into a new row, then the reference is updated with
+// the update statement taking the new ID, or nil, and the old row is deleted.
+func writeRefSet(outp *generate.CodeWriter, getOld, valueSQL, update, updateArgs string) {
+	outp.WriteString("\told := ")
+	outp.WriteString(getOld)
+	outp.WriteString("\n")
+	outp.WriteString("\tif v, ok := val.(*")
+	outp.WriteString(valueSQL)
+	outp.WriteString("); ok && old != nil && v.db == s.db && v.id == old.(*")
+	outp.WriteString(valueSQL)
+	outp.WriteString(").id {\n\t\treturn\n\t}\n\n")
+	outp.WriteString("\tvar newID interface{}\n")
+	outp.WriteString("\tif val != nil {\n")
+	outp.WriteString("\t\tc, err := Create")
+	outp.WriteString(valueSQL)
+	outp.WriteString("(s.db)\n")
+	outp.WriteString("\t\tif err != nil {\n\t\t\treturn\n\t\t}\n")
+	outp.WriteString("\t\tc.copyFrom(val)\n")
+	outp.WriteString("\t\tnewID = c.id\n")
+	outp.WriteString("\t}\n")
+	outp.WriteString("\ts.db.Exec(")
+	outp.WriteString(update)
+	outp.WriteString(", ")
+	outp.WriteString(updateArgs)
+	outp.WriteString(")\n")
+	outp.WriteString("\tif old != nil {\n\t\told.(*")
+	outp.WriteString(valueSQL)
+	outp.WriteString(").Delete()\n\t}\n")
+}
+
+// writeErr writes the method returning the error recorded by the database.
+func writeErr(outp *generate.CodeWriter, name string) {
+	outp.WriteString("\n// Err returns the first error recorded by the database, if any.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Err() error {\n\treturn s.db.Err()\n}\n")
+}
+
+// writeMap writes the SQL backed type for a map type.
+func writeMap(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := sqlName(mapt.ImplName)
+	dbType := outp.Qualify(runtimePath, "DB")
+	var valueSQL string
+	if mapt.ValueKind == parser.FieldKindMessage {
+		valueSQL = sqlName(mapt.ValueMessage)
+	}
+
+	// FooMapSQL is an IFooMap stored in a child table.
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is an ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" stored in the child table of a map field.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tdb       *")
+	outp.WriteString(dbType)
+	outp.WriteString("\n\ttable    string\n")
+	outp.WriteString("\tparentID int64\n")
+	outp.WriteString("}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a new ")
+	outp.WriteString(name)
+	outp.WriteString(" for the entries of the parent row in the table.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(db *")
+	outp.WriteString(dbType)
+	outp.WriteString(", table string, parentID int64) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{db: db, table: table, parentID: parentID}\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	// Err()
+	writeErr(outp, name)
+
+	valueColumn := `"value"`
+	if valueSQL != "" {
+		valueColumn = `"value_id"`
+	}
+	selectQuery := "`SELECT " + valueColumn + " FROM \"` + s.table + `\" WHERE \"parent_id\" = ? AND \"key\" = ?`"
+	upsertQuery := "`INSERT INTO \"` + s.table + `\" (\"parent_id\", \"key\", " + valueColumn +
+		") VALUES (?, ?, ?) ON CONFLICT (\"parent_id\", \"key\") DO UPDATE SET " +
+		valueColumn + " = excluded." + valueColumn + "`"
+	deleteQuery := "`DELETE FROM \"` + s.table + `\" WHERE \"parent_id\" = ? AND \"key\" = ?`"
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	if valueSQL != "" {
+		writeRefGet(outp, selectQuery, "s.parentID, key", valueSQL)
+	} else {
+		writeScalarGet(outp, selectQuery, "s.parentID, key", mapt.Value)
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	if valueSQL != "" {
+		outp.WriteString("\tif val == nil {\n")
+		outp.WriteString("\t\told := s.Get(key)\n")
+		outp.WriteString("\t\ts.db.Exec(")
+		outp.WriteString(deleteQuery)
+		outp.WriteString(", s.parentID, key)\n")
+		outp.WriteString("\t\tif old != nil {\n\t\t\told.(*")
+		outp.WriteString(valueSQL)
+		outp.WriteString(").Delete()\n\t\t}\n\t\treturn\n\t}\n\n")
+		writeRefSet(outp, "s.Get(key)", valueSQL, upsertQuery, "s.parentID, key, newID")
+	} else {
+		writeScalarSet(outp, upsertQuery, "s.parentID, key, "+storedValue(mapt.Value), mapt.Value)
+	}
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map in key order.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	outp.WriteString("\tfor _, key := range s.keys() {\n")
+	outp.WriteString("\t\tif !cb(key, s.Get(key)) {\n\t\t\treturn false\n\t\t}\n")
+	outp.WriteString("\t}\n\n\treturn true\n}\n")
+
+	// keys()
+	outp.WriteString("\n// keys returns the keys of the map in order.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") keys() []string {\n")
+	outp.WriteString("\treturn s.db.Strings(`SELECT \"key\" FROM \"` + s.table + `\" WHERE \"parent_id\" = ? ORDER BY \"key\"`, s.parentID)\n")
+	outp.WriteString("}\n")
+
+	// deleteAll()
+	outp.WriteString("\n// deleteAll deletes the entries of the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") deleteAll() {\n")
+	if valueSQL != "" {
+		outp.WriteString("\tfor _, key := range s.keys() {\n")
+		outp.WriteString("\t\tif v := s.Get(key); v != nil {\n\t\t\tv.(*")
+		outp.WriteString(valueSQL)
+		outp.WriteString(").Delete()\n\t\t}\n\t}\n")
+	}
+	outp.WriteString("\ts.db.Exec(`DELETE FROM \"` + s.table + `\" WHERE \"parent_id\" = ?`, s.parentID)\n")
+	outp.WriteString("}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeMessage writes the SQL backed type for a message.
+func writeMessage(outp *generate.CodeWriter, pf *parser.File, msg *parser.Message) {
+	name := sqlName(msg.Name)
+	table := quoteIdent(TableName(msg))
+	dbType := outp.Qualify(runtimePath, "DB")
+	outp.Mark(generate.MessageOrigin(msg))
+
+	var fields []*parser.Field
+	for fi := range msg.Fields {
+		field := &msg.Fields[fi]
+		if isSupportedField(pf, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	// FooSQL is an IFoo stored as a row of the foo table.
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" is an ")
+	outp.WriteString(msg.InterName)
+	outp.WriteString(" stored as a row of the ")
+	outp.WriteString(TableName(msg))
+	outp.WriteString(" table.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tdb *")
+	outp.WriteString(dbType)
+	outp.WriteString("\n\tid int64\n")
+	outp.WriteString("}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" builds a new ")
+	outp.WriteString(name)
+	outp.WriteString(" for the row with the ID.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(db *")
+	outp.WriteString(dbType)
+	outp.WriteString(", id int64) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{db: db, id: id}\n}\n")
+
+	outp.WriteString("\n// Create")
+	outp.WriteString(name)
+	outp.WriteString(" inserts a new empty row.\n")
+	outp.WriteString("func Create")
+	outp.WriteString(name)
+	outp.WriteString("(db *")
+	outp.WriteString(dbType)
+	outp.WriteString(") (*")
+	outp.WriteString(name)
+	outp.WriteString(", error) {\n")
+	outp.WriteString("\tid, err := db.Insert(`INSERT INTO ")
+	outp.WriteString(table)
+	outp.WriteString(" DEFAULT VALUES`)\n")
+	outp.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	outp.WriteString("\treturn New")
+	outp.WriteString(name)
+	outp.WriteString("(db, id), nil\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(msg.InterName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	// GetRowID()
+	outp.WriteString("\n// GetRowID returns the ID of the row.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") GetRowID() int64 {\n\treturn s.id\n}\n")
+
+	// Err()
+	writeErr(outp, name)
+
+	// Delete()
+	outp.WriteString("\n// Delete deletes the row, the rows of its message fields and its map entries.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Delete() {\n")
+	for _, field := range fields {
+		switch field.Kind {
+		case parser.FieldKindMessage:
+			outp.WriteString("\tif v := s.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("(); v != nil {\n\t\tv.(*")
+			outp.WriteString(sqlName(field.Message))
+			outp.WriteString(").Delete()\n\t}\n")
+		case parser.FieldKindMap:
+			outp.WriteString("\ts.")
+			outp.WriteString(field.GetterName)
+			outp.WriteString("().(*")
+			outp.WriteString(sqlName(field.Map.ImplName))
+			outp.WriteString(").deleteAll()\n")
+		}
+	}
+	outp.WriteString("\ts.db.Exec(`DELETE FROM ")
+	outp.WriteString(table)
+	outp.WriteString(" WHERE \"_id\" = ?`, s.id)\n")
+	outp.WriteString("}\n")
+
+	// copyFrom()
+	outp.WriteString("\n// copyFrom stores the fields of val.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") copyFrom(val ")
+	outp.WriteString(msg.InterName)
+	outp.WriteString(") {\n")
+	for _, field := range fields {
+		outp.WriteString("\ts.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("())\n")
+	}
+	outp.WriteString("}\n")
+
+	for _, field := range fields {
+		outp.Mark(generate.FieldOrigin(msg, field))
+		writeField(outp, msg, field)
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, msg)
+}
+
+// writeField writes the accessors for a field.
+func writeField(outp *generate.CodeWriter, msg *parser.Message, field *parser.Field) {
+	name := sqlName(msg.Name)
+	table := quoteIdent(TableName(msg))
+
+	// func (s *HelloSQL) GetSubject() string
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("() ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(" {\n")
+	switch field.Kind {
+	case parser.FieldKindScalar:
+		writeScalarGet(outp, "`SELECT "+quoteIdent(field.Name)+" FROM "+table+" WHERE \"_id\" = ?`", "s.id", field.GoType)
+	case parser.FieldKindMessage:
+		writeRefGet(outp, "`SELECT "+quoteIdent(refColumn(field))+" FROM "+table+" WHERE \"_id\" = ?`", "s.id", sqlName(field.Message))
+	case parser.FieldKindMap:
+		outp.WriteString("\treturn New")
+		outp.WriteString(sqlName(field.Map.ImplName))
+		outp.WriteString("(s.db, ")
+		outp.WriteString("\"")
+		outp.WriteString(MapTableName(msg, field))
+		outp.WriteString("\", s.id)\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloSQL) SetSubject(val string)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" sets ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(val ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(") {\n")
+	switch field.Kind {
+	case parser.FieldKindScalar:
+		writeScalarSet(
+			outp,
+			"`UPDATE "+table+" SET "+quoteIdent(field.Name)+" = ? WHERE \"_id\" = ?`",
+			storedValue(field.GoType)+", s.id",
+			field.GoType,
+		)
+	case parser.FieldKindMessage:
+		writeRefSet(
+			outp,
+			"s."+field.GetterName+"()",
+			sqlName(field.Message),
+			"`UPDATE "+table+" SET "+quoteIdent(refColumn(field))+" = ? WHERE \"_id\" = ?`",
+			"newID, s.id",
+		)
+	case parser.FieldKindMap:
+		mapSQL := sqlName(field.Map.ImplName)
+		outp.WriteString("\tdst := s.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("().(*")
+		outp.WriteString(mapSQL)
+		outp.WriteString(")\n")
+		outp.WriteString("\tif v, ok := val.(*")
+		outp.WriteString(mapSQL)
+		outp.WriteString("); ok && *v == *dst {\n\t\treturn\n\t}\n\n")
+		outp.WriteString("\tdst.deleteAll()\n")
+		outp.WriteString("\tif val != nil {\n")
+		outp.WriteString("\t\tval.ForEach(func(key string, v ")
+		outp.WriteString(field.Map.Value)
+		outp.WriteString(") bool {\n\t\t\tdst.Set(key, v)\n\t\t\treturn true\n\t\t})\n")
+		outp.WriteString("\t}\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloSQL) NewExample() IExample
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" builds a new detached ")
+		outp.WriteString(field.Name)
+		outp.WriteString(", stored by ")
+		outp.WriteString(field.SetterName)
+		outp.WriteString(".\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		if field.Map != nil {
+			outp.WriteString(" {\n\treturn make(")
+			outp.WriteString(field.Map.ImplName)
+			outp.WriteString(")\n}\n")
+		} else {
+			outp.WriteString(" {\n\treturn &")
+			outp.WriteString(field.Message)
+			outp.WriteString("{}\n}\n")
+		}
+	}
+}
+
+// _ is a type assertion
+var _ generate.FilesGenerator = ((*Generator)(nil))
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
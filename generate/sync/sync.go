@@ -0,0 +1,346 @@
+package sync
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/deepcopy"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "sync"
+
+// Generator generates wrappers making any implementation of the interfaces
+// safe for concurrent use.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates thread-safe wrappers guarding an object tree with a shared RWMutex"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapSync(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageSync(outp, &pf.Messages[mi])
+	}
+	deepcopy.WriteCopyFuncs(outp, pf, snapshotName)
+	return outp.Finish()
+}
+
+// SyncName returns the name of the wrapper type for a message or map
+// implementation type name.
+func SyncName(name string) string {
+	return "Sync" + name
+}
+
+// snapshotName returns the name of the function copying a message or map
+// guarded by another mutex, given its implementation type name.
+func snapshotName(name string) string {
+	return "syncCopy" + name
+}
+
+// writeSnapshot writes replacing val, if it is wrapped with the mutex of
+// another tree, with a copy taken under the read lock of that tree. The
+// locks are not nested, so setting values across trees cannot deadlock.
+func writeSnapshot(outp *generate.CodeWriter, implName, newExpr string) {
+	outp.WriteString("\tif w, ok := val.(*")
+	outp.WriteString(SyncName(implName))
+	outp.WriteString("); ok && w.mtx != s.mtx {\n")
+	outp.WriteString("\t\tc := ")
+	outp.WriteString(newExpr)
+	outp.WriteString("\n\t\tw.mtx.RLock()\n\t\t")
+	outp.WriteString(snapshotName(implName))
+	outp.WriteString("(c, w.v)\n\t\tw.mtx.RUnlock()\n")
+	outp.WriteString("\t\tval = c\n\t}\n")
+}
+
+// writeCommon writes the wrapper struct, constructors, type assertion, unwrap
+// function and the Update and View methods shared by messages and maps.
+func writeCommon(outp *generate.CodeWriter, name, interName, kind string) {
+	mtxType := outp.Qualify("sync", "RWMutex")
+
+	// type SyncExample struct
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" wraps an ")
+	outp.WriteString(interName)
+	outp.WriteString(", guarding it with a RWMutex shared by the nested\n")
+	outp.WriteString("// messages and maps returned by the getters.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tv   ")
+	outp.WriteString(interName)
+	outp.WriteString("\n\tmtx *")
+	outp.WriteString(mtxType)
+	outp.WriteString("\n}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" wraps the ")
+	outp.WriteString(kind)
+	outp.WriteString(" with a new mutex.\n")
+	outp.WriteString("// v must not be used directly while it is wrapped.\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(v ")
+	outp.WriteString(interName)
+	outp.WriteString(") *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{v: v, mtx: new(")
+	outp.WriteString(mtxType)
+	outp.WriteString(")}\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(interName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	// Update()
+	outp.WriteString("\n// Update calls cb with the write lock held, for atomic multi-field updates.\n")
+	outp.WriteString("// cb is called with the unguarded ")
+	outp.WriteString(kind)
+	outp.WriteString(", which must not be retained.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Update(cb func(v ")
+	outp.WriteString(interName)
+	outp.WriteString(")) {\n")
+	outp.WriteString("\ts.mtx.Lock()\n\tdefer s.mtx.Unlock()\n\tcb(s.v)\n}\n")
+
+	// View()
+	outp.WriteString("\n// View calls cb with the read lock held, for consistent multi-field reads.\n")
+	outp.WriteString("// cb is called with the unguarded ")
+	outp.WriteString(kind)
+	outp.WriteString(", which must not be modified or retained.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") View(cb func(v ")
+	outp.WriteString(interName)
+	outp.WriteString(")) {\n")
+	outp.WriteString("\ts.mtx.RLock()\n\tdefer s.mtx.RUnlock()\n\tcb(s.v)\n}\n")
+
+	// unwrap()
+	outp.WriteString("\n// unwrap")
+	outp.WriteString(name)
+	outp.WriteString(" returns the value wrapped by val, or val.\n")
+	outp.WriteString("func unwrap")
+	outp.WriteString(name)
+	outp.WriteString("(val ")
+	outp.WriteString(interName)
+	outp.WriteString(") ")
+	outp.WriteString(interName)
+	outp.WriteString(" {\n\tif w, ok := val.(*")
+	outp.WriteString(name)
+	outp.WriteString("); ok {\n\t\treturn w.v\n\t}\n\treturn val\n}\n")
+}
+
+// writeMapSync writes the wrapper for a map type.
+func writeMapSync(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := SyncName(mapt.ImplName)
+	isMsg := mapt.ValueKind == parser.FieldKindMessage
+	writeCommon(outp, name, mapt.TypeName, "map")
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	outp.WriteString("\ts.mtx.RLock()\n\tv := s.v.Get(key)\n\ts.mtx.RUnlock()\n")
+	if isMsg {
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn &")
+		outp.WriteString(SyncName(mapt.ValueMessage))
+		outp.WriteString("{v: v, mtx: s.mtx}\n")
+	} else {
+		outp.WriteString("\treturn v\n")
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map.\n")
+	if isMsg {
+		outp.WriteString("// A value guarded by another mutex is copied under its read lock.\n")
+	}
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	if isMsg {
+		writeSnapshot(outp, mapt.ValueMessage, "&"+mapt.ValueMessage+"{}")
+	}
+	outp.WriteString("\ts.mtx.Lock()\n")
+	if isMsg {
+		outp.WriteString("\ts.v.Set(key, unwrap")
+		outp.WriteString(SyncName(mapt.ValueMessage))
+		outp.WriteString("(val))\n")
+	} else {
+		outp.WriteString("\ts.v.Set(key, val)\n")
+	}
+	outp.WriteString("\ts.mtx.Unlock()\n}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over a snapshot of the entries taken with the read lock\n")
+	outp.WriteString("// held, so cb can modify the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	outp.WriteString("\tvar keys []string\n")
+	outp.WriteString("\tvar vals []")
+	outp.WriteString(mapt.Value)
+	outp.WriteString("\n\ts.mtx.RLock()\n")
+	outp.WriteString("\ts.v.ForEach(func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n")
+	outp.WriteString("\t\tkeys = append(keys, key)\n")
+	outp.WriteString("\t\tvals = append(vals, val)\n")
+	outp.WriteString("\t\treturn true\n\t})\n")
+	outp.WriteString("\ts.mtx.RUnlock()\n\n")
+	outp.WriteString("\tfor i, key := range keys {\n")
+	if isMsg {
+		outp.WriteString("\t\tval := vals[i]\n")
+		outp.WriteString("\t\tif val != nil {\n\t\t\tval = &")
+		outp.WriteString(SyncName(mapt.ValueMessage))
+		outp.WriteString("{v: val, mtx: s.mtx}\n\t\t}\n")
+		outp.WriteString("\t\tif !cb(key, val) {\n")
+	} else {
+		outp.WriteString("\t\tif !cb(key, vals[i]) {\n")
+	}
+	outp.WriteString("\t\t\treturn false\n\t\t}\n\t}\n")
+	outp.WriteString("\treturn true\n}\n")
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeMessageSync writes the wrapper for a message.
+func writeMessageSync(outp *generate.CodeWriter, message *parser.Message) {
+	name := SyncName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+	writeCommon(outp, name, message.InterName, "object")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeFieldSync(outp, name, field)
+	}
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+// writeFieldSync writes the wrapper methods for a field.
+func writeFieldSync(outp *generate.CodeWriter, name string, field *parser.Field) {
+	nestedName := field.Message
+	if field.Map != nil {
+		nestedName = field.Map.ImplName
+	}
+
+	// func (s *SyncHello) GetSubject() string
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("() ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(" {\n")
+	outp.WriteString("\ts.mtx.RLock()\n\tv := s.v.")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("()\n\ts.mtx.RUnlock()\n")
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("\treturn v\n")
+	} else {
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn &")
+		outp.WriteString(SyncName(nestedName))
+		outp.WriteString("{v: v, mtx: s.mtx}\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *SyncHello) SetSubject(val string)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" sets ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	snapshot := field.Kind != parser.FieldKindScalar && field.NewName != ""
+	if snapshot {
+		outp.WriteString("// A value guarded by another mutex is copied under its read lock.\n")
+	}
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(val ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(") {\n")
+	if snapshot {
+		writeSnapshot(outp, nestedName, "s."+field.NewName+"()")
+	}
+	outp.WriteString("\ts.mtx.Lock()\n\ts.v.")
+	outp.WriteString(field.SetterName)
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("(val)\n")
+	} else {
+		outp.WriteString("(unwrap")
+		outp.WriteString(SyncName(nestedName))
+		outp.WriteString("(val))\n")
+	}
+	outp.WriteString("\ts.mtx.Unlock()\n}\n")
+
+	// func (s *SyncHello) NewExample() IExample
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" builds a new unguarded ")
+		outp.WriteString(field.Name)
+		outp.WriteString(", guarded once it is set.\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n")
+		outp.WriteString("\ts.mtx.RLock()\n\tdefer s.mtx.RUnlock()\n\treturn s.v.")
+		outp.WriteString(field.NewName)
+		outp.WriteString("()\n}\n")
+	}
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
@@ -0,0 +1,111 @@
+package template
+
+import (
+	"strconv"
+	"strings"
+	ttemplate "text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/paralin/protods/parser"
+	"github.com/serenize/snaker"
+)
+
+// Funcs returns the helper functions available to templates.
+//
+// Naming:
+//   - camelCase "lower_kv": LowerKv, as protoc-gen-go names fields.
+//   - snakeCase "LowerKv": lower_kv
+//   - lowerFirst, upperFirst: change the case of the first letter.
+//   - implName "IHello": Hello, drops the interface I prefix.
+//
+// Types:
+//   - message "Hello": the *parser.Message with the name, or nil.
+//   - supportedFields msg: the fields protods can generate code for.
+//   - isScalar, isMessage, isMap field: check the kind of a field.
+//   - zeroValue goType: the Go zero value literal for the type.
+//
+// Comments:
+//   - comment text: text as // line comments.
+//
+// Imports (added by Execute):
+//   - qualify "sync" "Mutex": sync.Mutex, importing the package.
+//
+// Strings: quote, join, split, lower, upper, hasPrefix, hasSuffix,
+// trimPrefix, trimSuffix, replace.
+func Funcs(pf *parser.File) ttemplate.FuncMap {
+	return ttemplate.FuncMap{
+		"camelCase":  parser.CamelCase,
+		"snakeCase":  snaker.CamelToSnake,
+		"lowerFirst": lowerFirst,
+		"upperFirst": upperFirst,
+		"implName":   implName,
+
+		"message":         pf.GetMessage,
+		"supportedFields": supportedFields,
+		"isScalar": func(f *parser.Field) bool {
+			return f.Kind == parser.FieldKindScalar
+		},
+		"isMessage": func(f *parser.Field) bool {
+			return f.Kind == parser.FieldKindMessage
+		},
+		"isMap": func(f *parser.Field) bool {
+			return f.Kind == parser.FieldKindMap
+		},
+		"zeroValue": parser.ZeroValue,
+
+		"comment": comment,
+
+		"quote":      strconv.Quote,
+		"join":       strings.Join,
+		"split":      strings.Split,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"hasPrefix":  strings.HasPrefix,
+		"hasSuffix":  strings.HasSuffix,
+		"trimPrefix": strings.TrimPrefix,
+		"trimSuffix": strings.TrimSuffix,
+		"replace":    strings.Replace,
+	}
+}
+
+// lowerFirst lower-cases the first letter of s.
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// upperFirst upper-cases the first letter of s.
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// implName drops the I prefix from an interface type name.
+func implName(s string) string {
+	return strings.TrimPrefix(s, "I")
+}
+
+// supportedFields returns the fields protods can generate code for.
+func supportedFields(msg *parser.Message) []*parser.Field {
+	var fields []*parser.Field
+	for i := range msg.Fields {
+		if msg.Fields[i].IsSupported() {
+			fields = append(fields, &msg.Fields[i])
+		}
+	}
+	return fields
+}
+
+// comment formats text as Go line comments.
+func comment(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimSpace(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
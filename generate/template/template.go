@@ -0,0 +1,110 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path"
+	"strings"
+	ttemplate "text/template"
+
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/parser"
+	"github.com/pkg/errors"
+)
+
+const generatorName = "template"
+
+// Generator generates code by executing a user-supplied text/template
+// against the parsed proto file.
+//
+// Parameters:
+//   - template: path to the template file, required.
+//   - name: short name used in the output filename.
+//     Defaults to the template filename without extensions.
+type Generator struct {
+	// TemplatePath is the path to the template file.
+	TemplatePath string
+	// ShortName is the short name used in the output filename.
+	ShortName string
+}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates code from a text/template (--param template=file.tmpl)"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	if g.ShortName != "" {
+		return g.ShortName
+	}
+
+	if g.TemplatePath != "" {
+		name := path.Base(g.TemplatePath)
+		if idx := strings.Index(name, "."); idx > 0 {
+			name = name[:idx]
+		}
+		return name
+	}
+
+	return generatorName
+}
+
+// SetParam sets a generator parameter.
+func (g *Generator) SetParam(key, value string) error {
+	switch key {
+	case "template":
+		g.TemplatePath = value
+	case "name":
+		g.ShortName = value
+	default:
+		return errors.Errorf("unknown parameter, expected template or name")
+	}
+	return nil
+}
+
+// CloneGenerator returns a copy of the generator.
+func (g *Generator) CloneGenerator() generate.ParamGenerator {
+	clone := *g
+	return &clone
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	if g.TemplatePath == "" {
+		return nil, errors.New("template parameter is required: --param template=file.tmpl")
+	}
+
+	tmplData, err := ioutil.ReadFile(g.TemplatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Execute(path.Base(g.TemplatePath), string(tmplData), pf)
+}
+
+// Execute parses and executes a template against the proto file.
+// The template has access to the helpers in Funcs. Packages referenced with
+// the qualify helper are imported after the package clause of the output.
+func Execute(name, text string, pf *parser.File) ([]byte, error) {
+	imports := generate.NewImports()
+	imports.Reserve(pf.PackageName)
+	tmpl, err := ttemplate.New(name).
+		Funcs(Funcs(pf)).
+		Funcs(ttemplate.FuncMap{"qualify": imports.Qualify}).
+		Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	var outp bytes.Buffer
+	if err := tmpl.Execute(&outp, pf); err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+
+	return generate.InsertImports(outp.Bytes(), imports, nil), nil
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
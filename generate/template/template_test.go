@@ -0,0 +1,86 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/parser"
+)
+
+// richTemplate lists the supported fields of each message with their kind.
+const richTemplate = `package {{ .PackageName }}
+{{ range .Messages }}
+{{ comment .Comment }}
+// {{ implName .InterName }} fields:
+{{- range supportedFields . }}
+//   - {{ .Name }} {{ snakeCase .CamelName }} {{ .GoType }}
+{{- if isMap . }} map{{ end }}
+{{- if isMessage . }} message {{ (message .Type).InterName }}{{ end }}
+{{- end }}
+{{ end -}}
+`
+
+// TestExecuteRich tests executing a template against the rich fixture.
+func TestExecuteRich(t *testing.T) {
+	pp, err := parser.ReadProto("../../internal/rich/rich.proto")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pf, err := parser.Parse(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	out, err := Execute("rich.tmpl", richTemplate, pf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := `package rich
+
+// Lower is the lower message.
+// Lower fields:
+//   - value value string
+//   - flag flag bool
+
+// Upper is the upper message.
+// Upper fields:
+//   - id id string
+//   - lower lower ILower message ILower
+//   - lower_kv lower_kv IStringLowerMap map
+//   - score score float64
+//   - data data []byte
+//   - counts counts IStringInt64Map map
+//   - big big uint64
+`
+	if string(out) != expected {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+// TestExecuteQualify tests qualify imports the referenced packages.
+func TestExecuteQualify(t *testing.T) {
+	pp, err := parser.ReadProto("../../internal/rich/rich.proto")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pf, err := parser.Parse(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	out, err := Execute("qualify.tmpl", `package {{ .PackageName }}
+{{ range .Messages }}
+// {{ .Name }}Mtx guards {{ .Name }}.
+var {{ .Name }}Mtx {{ qualify "sync" "Mutex" }}
+{{ end -}}
+`, pf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := "package rich\n\nimport (\n\t\"sync\"\n)\n\n// LowerMtx guards Lower.\nvar LowerMtx sync.Mutex\n"
+	if !strings.HasPrefix(string(out), expected) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,277 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Diagnostic is a problem in generated code, attributed to a proto construct.
+type Diagnostic struct {
+	// Origin is the proto construct the faulty code was generated from.
+	// Origin.Position is empty if the code could not be attributed.
+	Origin Origin
+	// GoPosition is the location of the problem in the generated code.
+	GoPosition token.Position
+	// Message describes the problem.
+	Message string
+}
+
+// Error returns the diagnostic as a string.
+func (d *Diagnostic) Error() string {
+	var outp bytes.Buffer
+	if d.Origin.Position.IsValid() {
+		outp.WriteString(d.Origin.Position.String())
+		outp.WriteString(": ")
+		outp.WriteString(d.Origin.String())
+		outp.WriteString(": ")
+	}
+	outp.WriteString(d.Message)
+	outp.WriteString(" (")
+	outp.WriteString(d.GoPosition.String())
+	outp.WriteString(")")
+	return outp.String()
+}
+
+// Diagnostics is a list of problems found in generated code.
+type Diagnostics []*Diagnostic
+
+// Error returns the diagnostics as a string, one per line.
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.Error()
+	}
+	return fmt.Sprintf(
+		"generated code is invalid, %d problem(s):\n%s",
+		len(d),
+		strings.Join(lines, "\n"),
+	)
+}
+
+// VerifyOptions controls Verify.
+type VerifyOptions struct {
+	// PackageFS contains the other files of the Go package, such as the
+	// protoc-gen-go output. Nil skips type checking.
+	PackageFS fs.FS
+}
+
+// OutputFile is a generated output file.
+type OutputFile struct {
+	// Name is the path of the file.
+	Name string
+	// Content is the content of the file.
+	Content []byte
+	// SourceMap attributes the content to proto constructs, may be nil.
+	SourceMap *SourceMap
+}
+
+// Verify parses, type checks and formats generated code.
+// Problems are returned as Diagnostics, attributed using the source map.
+// Returns the formatted code.
+func Verify(filename string, code []byte, sm *SourceMap, opts VerifyOptions) ([]byte, error) {
+	file := &OutputFile{Name: filename, Content: code, SourceMap: sm}
+	if err := VerifyFiles([]*OutputFile{file}, opts); err != nil {
+		return nil, err
+	}
+	return file.Content, nil
+}
+
+// VerifyFiles parses, type checks and formats generated Go files together.
+// Files without a .go suffix are skipped.
+// Files are grouped by directory, each directory is checked as one package.
+// Directory names are relative to VerifyOptions.PackageFS.
+// Problems are returned as Diagnostics, attributed using the source maps.
+// On success the content of the files is replaced with the formatted code.
+func VerifyFiles(files []*OutputFile, opts VerifyOptions) error {
+	var dirs []string
+	dirFiles := make(map[string][]*OutputFile)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name, ".go") {
+			continue
+		}
+
+		dir := path.Dir(file.Name)
+		if _, ok := dirFiles[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		dirFiles[dir] = append(dirFiles[dir], file)
+	}
+
+	var diags Diagnostics
+	for _, dir := range dirs {
+		pkgFS := opts.PackageFS
+		if pkgFS != nil && dir != "." && fs.ValidPath(dir) {
+			var err error
+			pkgFS, err = fs.Sub(pkgFS, dir)
+			if err != nil {
+				return err
+			}
+		}
+		if err := verifyPackage(dirFiles[dir], pkgFS); err != nil {
+			pkgDiags, ok := err.(Diagnostics)
+			if !ok {
+				return err
+			}
+			diags = append(diags, pkgDiags...)
+		}
+	}
+	if len(diags) != 0 {
+		return diags
+	}
+
+	for _, dir := range dirs {
+		for _, file := range dirFiles[dir] {
+			fmtSrc, err := format.Source(file.Content)
+			if err != nil {
+				return errors.Wrapf(err, "format %s", file.Name)
+			}
+			file.Content = fmtSrc
+		}
+	}
+
+	return nil
+}
+
+// verifyPackage parses and type checks the generated files of one package.
+// pkgFS contains the other files of the package, nil skips type checking.
+func verifyPackage(files []*OutputFile, pkgFS fs.FS) error {
+	fset := token.NewFileSet()
+	var diags Diagnostics
+	var genFiles []*ast.File
+	genMaps := make(map[string]*SourceMap)
+	genNames := make(map[string]bool)
+	for _, file := range files {
+		genNames[path.Base(file.Name)] = true
+		genMaps[file.Name] = file.SourceMap
+		genFile, err := goparser.ParseFile(fset, file.Name, file.Content, goparser.ParseComments)
+		if err != nil {
+			errList, ok := err.(scanner.ErrorList)
+			if !ok {
+				return err
+			}
+
+			for _, e := range errList {
+				diags = append(diags, newDiagnostic(file.SourceMap, e.Pos, e.Msg))
+			}
+			continue
+		}
+		genFiles = append(genFiles, genFile)
+	}
+	if len(diags) != 0 {
+		return diags
+	}
+	if len(genFiles) == 0 || pkgFS == nil {
+		return nil
+	}
+
+	return typeCheck(fset, genFiles, genMaps, genNames, pkgFS)
+}
+
+// typeCheck type checks the generated files with the other files in pkgFS.
+func typeCheck(
+	fset *token.FileSet,
+	genFiles []*ast.File,
+	genMaps map[string]*SourceMap,
+	genNames map[string]bool,
+	pkgFS fs.FS,
+) error {
+	pkgName := genFiles[0].Name.Name
+	companions, err := parsePackageFiles(fset, pkgFS, pkgName, genNames)
+	if err != nil {
+		return err
+	}
+	if len(companions) == 0 {
+		// nothing to check against, e.g. protoc-gen-go output not written yet.
+		return nil
+	}
+	files := append(append([]*ast.File{}, genFiles...), companions...)
+
+	var diags Diagnostics
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok {
+				return
+			}
+			pos := terr.Fset.Position(terr.Pos)
+			sm, ok := genMaps[pos.Filename]
+			if !ok {
+				return
+			}
+			diags = append(diags, newDiagnostic(sm, pos, terr.Msg))
+		},
+	}
+	_, _ = conf.Check(pkgName, fset, files, nil)
+	if len(diags) != 0 {
+		sort.SliceStable(diags, func(i, j int) bool {
+			if diags[i].GoPosition.Filename != diags[j].GoPosition.Filename {
+				return diags[i].GoPosition.Filename < diags[j].GoPosition.Filename
+			}
+			return diags[i].GoPosition.Offset < diags[j].GoPosition.Offset
+		})
+		return diags
+	}
+
+	return nil
+}
+
+// parsePackageFiles parses the non-test Go files in pkgFS belonging to pkgName.
+// Files that fail to parse are skipped, they may be stale generated code.
+// Files named in skipFilenames are skipped, they are being regenerated.
+func parsePackageFiles(fset *token.FileSet, pkgFS fs.FS, pkgName string, skipFilenames map[string]bool) ([]*ast.File, error) {
+	entries, err := fs.ReadDir(pkgFS, ".")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// the package directory is new.
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read package dir")
+	}
+
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() ||
+			skipFilenames[name] ||
+			!strings.HasSuffix(name, ".go") ||
+			strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		src, err := fs.ReadFile(pkgFS, name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := goparser.ParseFile(fset, name, src, 0)
+		if err != nil || f.Name.Name != pkgName {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// newDiagnostic builds a diagnostic, looking up the origin in the source map.
+func newDiagnostic(sm *SourceMap, pos token.Position, msg string) *Diagnostic {
+	origin, _ := sm.Lookup(pos.Offset)
+	return &Diagnostic{
+		Origin:     origin,
+		GoPosition: pos,
+		Message:    msg,
+	}
+}
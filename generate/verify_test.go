@@ -0,0 +1,40 @@
+package generate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// TestVerifyFilesDirectories tests files in different directories are checked
+// as separate packages against the matching package files.
+func TestVerifyFilesDirectories(t *testing.T) {
+	pkgFS := fstest.MapFS{
+		"example.pb.go":     {Data: []byte("package example\n\ntype Example struct{}\n")},
+		"sub/other.pb.go":   {Data: []byte("package other\n\ntype Other struct{}\n")},
+		"unrelated/main.go": {Data: []byte("package main\n")},
+	}
+	files := []*OutputFile{
+		{Name: "example.gen.go", Content: []byte("package example\nvar _ = Example{}\n")},
+		{Name: "sub/other.gen.go", Content: []byte("package other\nvar _ = Other{}\n")},
+		{Name: "fresh/fresh.gen.go", Content: []byte("package fresh\nvar X = 1\n")},
+	}
+	if err := VerifyFiles(files, VerifyOptions{PackageFS: pkgFS}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(files[0].Content) != "package example\n\nvar _ = Example{}\n" {
+		t.Fatalf("expected formatted code: %q", files[0].Content)
+	}
+
+	// a reference to a type from another directory does not resolve.
+	files = []*OutputFile{
+		{Name: "sub/other.gen.go", Content: []byte("package other\nvar _ = Example{}\n")},
+	}
+	err := VerifyFiles(files, VerifyOptions{PackageFS: pkgFS})
+	diags, ok := err.(Diagnostics)
+	if !ok || len(diags) != 1 {
+		t.Fatalf("expected one diagnostic: %v", err)
+	}
+	if diags[0].GoPosition.Filename != "sub/other.gen.go" {
+		t.Fatalf("unexpected diagnostic file: %s", diags[0].GoPosition.Filename)
+	}
+}
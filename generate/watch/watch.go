@@ -0,0 +1,391 @@
+package watch
+
+import (
+	"github.com/paralin/protods/generate"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/parser"
+)
+
+const generatorName = "watch"
+
+// runtimePath is the import path of the watch runtime package.
+const runtimePath = "github.com/paralin/protods/watch"
+
+// diffPath is the import path of the diff runtime package.
+const diffPath = "github.com/paralin/protods/diff"
+
+// Generator generates observable wrappers notifying watchers of the
+// mutations of any implementation of the interfaces.
+type Generator struct{}
+
+// GetUsage returns a usage description of the generator.
+func (g *Generator) GetUsage() string {
+	return "generates observable wrappers notifying watchers of key paths after each mutation"
+}
+
+// GetShortName returns the short name of the generator.
+func (g *Generator) GetShortName() string {
+	return generatorName
+}
+
+// GenerateCode generates code given the input proto file.
+func (g *Generator) GenerateCode(pf *parser.File) ([]byte, error) {
+	code, _, err := g.GenerateMappedCode(pf)
+	return code, err
+}
+
+// GenerateMappedCode generates code and a source map given the input proto file.
+func (g *Generator) GenerateMappedCode(pf *parser.File) ([]byte, *generate.SourceMap, error) {
+	outp := generate.NewCodeWriter(pf)
+	for mi := range pf.Maps {
+		mapt := &pf.Maps[mi]
+		if !mapt.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.MapOrigin(mapt))
+		writeMapObservable(outp, mapt)
+	}
+	for mi := range pf.Messages {
+		writeMessageObservable(outp, &pf.Messages[mi])
+	}
+	return outp.Finish()
+}
+
+// observableName returns the name of the wrapper type for a message or map
+// implementation type name.
+func observableName(name string) string {
+	return name + "Observable"
+}
+
+// writeCommon writes the wrapper struct, constructor, type assertion, unwrap
+// function and watch methods shared by messages and maps.
+func writeCommon(outp *generate.CodeWriter, name, interName, kind string) {
+	hubType := outp.Qualify(runtimePath, "Hub")
+	subType := outp.Qualify(runtimePath, "Subscription")
+	changeType := outp.Qualify(diffPath, "Change")
+
+	// type ExampleObservable struct
+	outp.WriteString("\n// ")
+	outp.WriteString(name)
+	outp.WriteString(" wraps an ")
+	outp.WriteString(interName)
+	outp.WriteString(", notifying watchers after each mutation.\n")
+	outp.WriteString("type ")
+	outp.WriteString(name)
+	outp.WriteString(" struct {\n")
+	outp.WriteString("\tv      ")
+	outp.WriteString(interName)
+	outp.WriteString("\n\thub    *")
+	outp.WriteString(hubType)
+	outp.WriteString("\n\tprefix string\n}\n")
+
+	outp.WriteString("\n// New")
+	outp.WriteString(name)
+	outp.WriteString(" wraps the ")
+	outp.WriteString(kind)
+	outp.WriteString(" at the key path prefix")
+	if kind == "object" {
+		outp.WriteString(", empty for the root object")
+	}
+	outp.WriteString(".\n")
+	outp.WriteString("func New")
+	outp.WriteString(name)
+	outp.WriteString("(v ")
+	outp.WriteString(interName)
+	outp.WriteString(", hub *")
+	outp.WriteString(hubType)
+	outp.WriteString(", prefix string) *")
+	outp.WriteString(name)
+	outp.WriteString(" {\n\treturn &")
+	outp.WriteString(name)
+	outp.WriteString("{v: v, hub: hub, prefix: prefix}\n}\n")
+
+	outp.WriteString("\n// _ is a type assertion\n")
+	outp.WriteString("var _ ")
+	outp.WriteString(interName)
+	outp.WriteString(" = ((*")
+	outp.WriteString(name)
+	outp.WriteString(")(nil))\n")
+
+	// Watch()
+	outp.WriteString("\n// Watch calls cb after each change at or below the path, relative to the ")
+	outp.WriteString(kind)
+	outp.WriteString(",\n// or replacing a path above it, for example /lower_kv or an empty path for\n// all changes.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Watch(path string, cb func(")
+	outp.WriteString(changeType)
+	outp.WriteString(")) *")
+	outp.WriteString(subType)
+	outp.WriteString(" {\n\treturn s.hub.Watch(s.prefix+path, cb)\n}\n")
+
+	// WatchChan()
+	outp.WriteString("\n// WatchChan delivers the changes matching the path to a buffered channel.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") WatchChan(path string, size int) (<-chan ")
+	outp.WriteString(changeType)
+	outp.WriteString(", *")
+	outp.WriteString(subType)
+	outp.WriteString(") {\n\treturn s.hub.WatchChan(s.prefix+path, size)\n}\n")
+}
+
+// writeUnwrap writes the function returning the value wrapped by an
+// observable wrapper.
+func writeUnwrap(outp *generate.CodeWriter, name, interName string) {
+	outp.WriteString("\n// unwrap")
+	outp.WriteString(name)
+	outp.WriteString(" returns the value wrapped by val, or val.\n")
+	outp.WriteString("func unwrap")
+	outp.WriteString(name)
+	outp.WriteString("(val ")
+	outp.WriteString(interName)
+	outp.WriteString(") ")
+	outp.WriteString(interName)
+	outp.WriteString(" {\n\tif w, ok := val.(*")
+	outp.WriteString(name)
+	outp.WriteString("); ok {\n\t\treturn w.v\n\t}\n\treturn val\n}\n")
+}
+
+// writeNotify writes notifying the hub of a change.
+func writeNotify(outp *generate.CodeWriter, indent, path, kind, oldVal, newVal string) {
+	outp.WriteString(indent)
+	outp.WriteString("s.hub.Notify(")
+	outp.WriteString(outp.Qualify(diffPath, "Change"))
+	outp.WriteString("{Path: ")
+	outp.WriteString(path)
+	outp.WriteString(", Kind: ")
+	outp.WriteString(kind)
+	outp.WriteString(", Old: ")
+	outp.WriteString(oldVal)
+	outp.WriteString(", New: ")
+	outp.WriteString(newVal)
+	outp.WriteString("})\n")
+}
+
+// changeKindExpr returns an expression computing the kind of a change
+// replacing a message or map value.
+func changeKindExpr(outp *generate.CodeWriter) string {
+	return outp.Qualify(runtimePath, "ChangeKind") + "(old != nil, val != nil)"
+}
+
+// writeMapObservable writes the wrapper for a map type.
+func writeMapObservable(outp *generate.CodeWriter, mapt *parser.Map) {
+	name := observableName(mapt.ImplName)
+	isMsg := mapt.ValueKind == parser.FieldKindMessage
+	escape := outp.Qualify("net/url", "PathEscape")
+	writeCommon(outp, name, mapt.TypeName, "map")
+
+	// Get()
+	outp.WriteString("\n// Get returns a value from the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Get(key string) ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(" {\n")
+	if isMsg {
+		outp.WriteString("\tv := s.v.Get(key)\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn New")
+		outp.WriteString(observableName(mapt.ValueMessage))
+		outp.WriteString("(v, s.hub, s.prefix+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key))\n")
+	} else {
+		outp.WriteString("\treturn s.v.Get(key)\n")
+	}
+	outp.WriteString("}\n")
+
+	// Set()
+	outp.WriteString("\n// Set sets a value in the map and notifies the watchers.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") Set(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") {\n")
+	if isMsg {
+		outp.WriteString("\told := s.v.Get(key)\n")
+		outp.WriteString("\ts.v.Set(key, unwrap")
+		outp.WriteString(observableName(mapt.ValueMessage))
+		outp.WriteString("(val))\n")
+		writeNotify(outp, "\t", "s.prefix + \"/\" + "+escape+"(key)", changeKindExpr(outp), "old", "val")
+	} else {
+		outp.WriteString("\tkind := ")
+		outp.WriteString(outp.Qualify(diffPath, "Added"))
+		outp.WriteString("\n\tif hasKey")
+		outp.WriteString(mapt.ImplName)
+		outp.WriteString("(s.v, key) {\n\t\tkind = ")
+		outp.WriteString(outp.Qualify(diffPath, "Modified"))
+		outp.WriteString("\n\t}\n")
+		outp.WriteString("\told := s.v.Get(key)\n")
+		outp.WriteString("\ts.v.Set(key, val)\n")
+		writeNotify(outp, "\t", "s.prefix + \"/\" + "+escape+"(key)", "kind", "old", "val")
+	}
+	outp.WriteString("}\n")
+
+	// ForEach()
+	outp.WriteString("\n// ForEach iterates over the map.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ForEach(cb func(key string, val ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool) bool {\n")
+	if isMsg {
+		outp.WriteString("\treturn s.v.ForEach(func(key string, val ")
+		outp.WriteString(mapt.Value)
+		outp.WriteString(") bool {\n")
+		outp.WriteString("\t\tif val != nil {\n\t\t\tval = New")
+		outp.WriteString(observableName(mapt.ValueMessage))
+		outp.WriteString("(val, s.hub, s.prefix+\"/\"+")
+		outp.WriteString(escape)
+		outp.WriteString("(key))\n\t\t}\n")
+		outp.WriteString("\t\treturn cb(key, val)\n\t})\n")
+	} else {
+		outp.WriteString("\treturn s.v.ForEach(cb)\n")
+	}
+	outp.WriteString("}\n")
+
+	writeUnwrap(outp, name, mapt.TypeName)
+	if !isMsg {
+		writeHasKey(outp, mapt)
+	}
+	itypes.WriteMapReaders(outp, "s", "*"+name, mapt)
+}
+
+// writeHasKey writes the function checking if a key is in a scalar map, as
+// Get returns the zero value for a missing key.
+func writeHasKey(outp *generate.CodeWriter, mapt *parser.Map) {
+	outp.WriteString("\n// hasKey")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString(" checks if the key is in the map.\n")
+	outp.WriteString("func hasKey")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString("(m ")
+	outp.WriteString(mapt.TypeName)
+	outp.WriteString(", key string) bool {\n")
+	outp.WriteString("\tif v, ok := m.(")
+	outp.WriteString(mapt.ImplName)
+	outp.WriteString("); ok {\n\t\t_, ok = v[key]\n\t\treturn ok\n\t}\n")
+	outp.WriteString("\treturn !m.ForEach(func(k string, _ ")
+	outp.WriteString(mapt.Value)
+	outp.WriteString(") bool {\n\t\treturn k != key\n\t})\n}\n")
+}
+
+// writeMessageObservable writes the wrapper for a message.
+func writeMessageObservable(outp *generate.CodeWriter, message *parser.Message) {
+	name := observableName(message.Name)
+	outp.Mark(generate.MessageOrigin(message))
+	writeCommon(outp, name, message.InterName, "object")
+
+	for fi := range message.Fields {
+		field := &message.Fields[fi]
+		if !field.IsSupported() {
+			continue
+		}
+		outp.Mark(generate.FieldOrigin(message, field))
+		writeFieldObservable(outp, name, field)
+	}
+
+	outp.Mark(generate.MessageOrigin(message))
+	writeUnwrap(outp, name, message.InterName)
+	itypes.WriteReaderGetters(outp, "s", "*"+name, message)
+}
+
+// writeFieldObservable writes the wrapper methods for a field.
+func writeFieldObservable(outp *generate.CodeWriter, name string, field *parser.Field) {
+	keyPath := "s.prefix + \"" + field.KeyPath + "\""
+	nestedName := field.Message
+	if field.Map != nil {
+		nestedName = field.Map.ImplName
+	}
+
+	// func (s *HelloObservable) GetSubject() string
+	outp.WriteString("\n// ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString(" returns ")
+	outp.WriteString(field.Name)
+	outp.WriteString(".\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("() ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(" {\n")
+	if field.Kind == parser.FieldKindScalar {
+		outp.WriteString("\treturn s.v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+	} else {
+		outp.WriteString("\tv := s.v.")
+		outp.WriteString(field.GetterName)
+		outp.WriteString("()\n")
+		outp.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		outp.WriteString("\treturn New")
+		outp.WriteString(observableName(nestedName))
+		outp.WriteString("(v, s.hub, ")
+		outp.WriteString(keyPath)
+		outp.WriteString(")\n")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloObservable) SetSubject(val string)
+	outp.WriteString("\n// ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString(" sets ")
+	outp.WriteString(field.Name)
+	outp.WriteString(" and notifies the watchers.\n")
+	outp.WriteString("func (s *")
+	outp.WriteString(name)
+	outp.WriteString(") ")
+	outp.WriteString(field.SetterName)
+	outp.WriteString("(val ")
+	outp.WriteString(field.GoType)
+	outp.WriteString(") {\n")
+	outp.WriteString("\told := s.v.")
+	outp.WriteString(field.GetterName)
+	outp.WriteString("()\n")
+	switch field.Kind {
+	case parser.FieldKindScalar:
+		outp.WriteString("\ts.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(val)\n")
+		writeNotify(outp, "\t", keyPath, outp.Qualify(diffPath, "Modified"), "old", "val")
+	case parser.FieldKindMap:
+		// the proto binding wraps a nil map if the field is unset.
+		outp.WriteString("\tif m, ok := old.(")
+		outp.WriteString(field.Map.ImplName)
+		outp.WriteString("); ok && m == nil {\n\t\told = nil\n\t}\n")
+		fallthrough
+	default:
+		outp.WriteString("\ts.v.")
+		outp.WriteString(field.SetterName)
+		outp.WriteString("(unwrap")
+		outp.WriteString(observableName(nestedName))
+		outp.WriteString("(val))\n")
+		writeNotify(outp, "\t", keyPath, changeKindExpr(outp), "old", "val")
+	}
+	outp.WriteString("}\n")
+
+	// func (s *HelloObservable) NewExample() IExample
+	if field.NewName != "" {
+		outp.WriteString("\n// ")
+		outp.WriteString(field.NewName)
+		outp.WriteString(" builds a new ")
+		outp.WriteString(field.Name)
+		outp.WriteString(", notified when it is set.\n")
+		outp.WriteString("func (s *")
+		outp.WriteString(name)
+		outp.WriteString(") ")
+		outp.WriteString(field.NewName)
+		outp.WriteString("() ")
+		outp.WriteString(field.GoType)
+		outp.WriteString(" {\n\treturn s.v.")
+		outp.WriteString(field.NewName)
+		outp.WriteString("()\n}\n")
+	}
+}
+
+func init() {
+	generate.RegisterGenerator(generatorName, &Generator{})
+}
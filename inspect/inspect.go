@@ -0,0 +1,76 @@
+package inspect
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/paralin/protods/parser"
+)
+
+// WriteJSON writes the parsed file as indented JSON.
+// The output is stable for a given proto file and protods version.
+func WriteJSON(w io.Writer, pf *parser.File) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(pf)
+}
+
+// WriteTree writes the parsed file as a human-readable tree.
+func WriteTree(w io.Writer, pf *parser.File) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "package %s\n", pf.PackageName)
+
+	for i := range pf.Maps {
+		mapt := &pf.Maps[i]
+		fmt.Fprintf(bw, "map %s\n", mapt.TypeName)
+		fmt.Fprintf(bw, "  position: %s\n", mapt.Position.String())
+		fmt.Fprintf(bw, "  key: %s\n", mapt.Key)
+		fmt.Fprintf(bw, "  value: %s (%s)\n", mapt.Value, mapt.ValueKind.String())
+		keyType, ok := parser.ScalarGoType(mapt.Key)
+		if !ok {
+			keyType = mapt.Key
+		}
+		fmt.Fprintf(bw, "  impl type: %s map[%s]%s\n", mapt.ImplName, keyType, mapt.ValuePtr)
+		if !mapt.IsSupported() {
+			fmt.Fprintf(bw, "  unsupported\n")
+		}
+	}
+
+	for i := range pf.Messages {
+		msg := &pf.Messages[i]
+		fmt.Fprintf(bw, "message %s\n", msg.Name)
+		fmt.Fprintf(bw, "  position: %s\n", msg.Position.String())
+		fmt.Fprintf(bw, "  interface: %s\n", msg.InterName)
+		for j := range msg.Fields {
+			writeFieldTree(bw, &msg.Fields[j])
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeFieldTree writes a field to the tree.
+func writeFieldTree(w io.Writer, field *parser.Field) {
+	var label string
+	if field.Repeated {
+		label = "repeated "
+	}
+	fmt.Fprintf(w, "  field %s = %d\n", field.Name, field.Number)
+	fmt.Fprintf(w, "    position: %s\n", field.Position.String())
+	fmt.Fprintf(w, "    proto type: %s%s\n", label, field.Type)
+	fmt.Fprintf(w, "    kind: %s\n", field.Kind.String())
+	if !field.IsSupported() {
+		fmt.Fprintf(w, "    unsupported\n")
+		return
+	}
+
+	fmt.Fprintf(w, "    go type: %s\n", field.GoType)
+	fmt.Fprintf(w, "    getter: %s\n", field.GetterName)
+	fmt.Fprintf(w, "    setter: %s\n", field.SetterName)
+	if field.NewName != "" {
+		fmt.Fprintf(w, "    new: %s\n", field.NewName)
+	}
+	fmt.Fprintf(w, "    key path: %s\n", field.KeyPath)
+}
@@ -0,0 +1,46 @@
+package inspect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/proto"
+	"github.com/paralin/protods/parser"
+)
+
+// TestWriteTreeMapKey tests the map impl type uses the Go type of the key.
+func TestWriteTreeMapKey(t *testing.T) {
+	pparser := proto.NewParser(strings.NewReader(`syntax = "proto3";
+package test;
+
+message Upper {
+  map<string, Upper> children = 1;
+  map<int32, string> names = 2;
+  map<sint64, bytes> blobs = 3;
+}
+`))
+	pparser.Filename("test.proto")
+	pp, err := pparser.Parse()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pf, err := parser.Parse(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var outp bytes.Buffer
+	if err := WriteTree(&outp, pf); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, expected := range []string{
+		"map[string]*Upper\n",
+		"map[int32]string\n",
+		"map[int64][]byte\n",
+	} {
+		if !strings.Contains(outp.String(), expected) {
+			t.Fatalf("expected %q in:\n%s", expected, outp.String())
+		}
+	}
+}
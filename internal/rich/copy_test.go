@@ -0,0 +1,61 @@
+package rich
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	kvfs "github.com/paralin/protods/kv/fs"
+)
+
+// TestCopy tests copying a proto into each implementation.
+func TestCopy(t *testing.T) {
+	src := &Upper{}
+	fillUpper(src)
+
+	sqlUpper, err := CreateUpperSQL(openSQL(t))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dsts := map[string]IUpper{
+		"proto": &Upper{},
+		"ctrie": NewUpperCtrie(),
+		"kv":    NewUpperKV(kv.NewTree(kvfs.NewStore(t.TempDir(), nil)), ""),
+		"sql":   sqlUpper,
+	}
+	for name, dst := range dsts {
+		t.Run(name, func(t *testing.T) {
+			CopyUpper(dst, src)
+			checkUpper(t, dst)
+			checkUpper(t, ToProtoUpper(dst))
+		})
+	}
+}
+
+// TestCopyBytes tests byte slices are not shared with the copy.
+func TestCopyBytes(t *testing.T) {
+	src := &Upper{Data: []byte{1, 2, 3}}
+	c := ToProtoUpper(src)
+	src.Data[0] = 9
+	if c.Data[0] != 1 {
+		t.Fatalf("expected the copy to keep its data, got %v", c.Data)
+	}
+}
+
+// TestCopyMapValues tests message map values are built by the destination.
+func TestCopyMapValues(t *testing.T) {
+	src := StringLowerMap{"a": {Value: "a"}}
+
+	dst := NewUpperCtrie()
+	dst.SetLowerKv(dst.NewLowerKv())
+	dstMap := dst.GetLowerKvInter()
+	CopyStringLowerMap(dstMap, src)
+
+	v, ok := dstMap.Get("a").(*LowerCtrie)
+	if !ok || v.GetValue() != "a" {
+		t.Fatalf("expected a ctrie value, got %#v", dstMap.Get("a"))
+	}
+	src["a"].Value = "changed"
+	if v.GetValue() != "a" {
+		t.Fatal("expected the copy to be independent of src")
+	}
+}
@@ -0,0 +1,63 @@
+package rich
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCtrie(t *testing.T) {
+	u := NewUpperCtrie()
+	fillUpper(u)
+	checkUpper(t, u)
+
+	from := &Upper{}
+	fillUpper(from)
+	checkUpper(t, NewUpperCtrieFrom(from))
+}
+
+// TestCtrieSnapshot tests snapshots are not affected by later changes.
+func TestCtrieSnapshot(t *testing.T) {
+	u := NewUpperCtrie()
+	fillUpper(u)
+	snap := u.Snapshot()
+
+	u.SetId("changed")
+	u.GetLowerInter().SetValue("changed")
+	u.GetLowerKvInter().Set("d", &Lower{Value: "d"})
+	u.GetCountsInter().Set("x", 10)
+	u.SetLower(nil)
+
+	checkUpper(t, snap)
+	if u.GetLowerInter() != nil {
+		t.Error("expected lower to be cleared")
+	}
+	if u.GetCountsInter().Get("x") != 10 {
+		t.Error("expected the change to counts")
+	}
+}
+
+// TestCtrieBytes tests byte slices are not shared with the caller or between
+// snapshots.
+func TestCtrieBytes(t *testing.T) {
+	data := []byte{1, 2, 3}
+	u := NewUpperCtrie()
+	u.SetData(data)
+	data[0] = 9
+	if got := u.GetData(); !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Fatalf("expected the stored data to be copied, got %v", got)
+	}
+
+	snap := u.Snapshot()
+	u.GetData()[1] = 9
+	snap.GetData()[2] = 9
+	if got := u.GetData(); !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Fatalf("expected the returned data to be copied, got %v", got)
+	}
+
+	from := &Upper{Data: []byte{4, 5, 6}}
+	c := NewUpperCtrieFrom(from)
+	from.Data[0] = 9
+	if got := c.GetData(); !bytes.Equal(got, []byte{4, 5, 6}) {
+		t.Fatalf("expected the source data to be copied, got %v", got)
+	}
+}
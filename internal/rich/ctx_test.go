@@ -0,0 +1,170 @@
+package rich
+
+import (
+	"context"
+	"testing"
+)
+
+// cancelLower is an ILowerCtx canceling the context when its value is read.
+type cancelLower struct {
+	cancel context.CancelFunc
+}
+
+// GetValue cancels the context and returns a value.
+func (l *cancelLower) GetValue(ctx context.Context) (string, error) {
+	l.cancel()
+	return "canceled", nil
+}
+
+// SetValue sets the value.
+func (l *cancelLower) SetValue(ctx context.Context, val string) error {
+	return nil
+}
+
+// GetFlag returns the flag.
+func (l *cancelLower) GetFlag(ctx context.Context) (bool, error) {
+	return false, ctx.Err()
+}
+
+// SetFlag sets the flag.
+func (l *cancelLower) SetFlag(ctx context.Context, val bool) error {
+	return nil
+}
+
+// _ is a type assertion
+var _ ILowerCtx = ((*cancelLower)(nil))
+
+// TestCtx tests the context aware types copy values in and out of the
+// wrapped types.
+func TestCtx(t *testing.T) {
+	ctx := context.Background()
+	src := &Upper{}
+	fillUpper(src)
+
+	dst := &Upper{}
+	u := ToIUpperCtx(dst)
+	from := ToIUpperCtx(src)
+	if id, err := from.GetId(ctx); err != nil || id != "upper" {
+		t.Fatalf("unexpected id %q: %v", id, err)
+	}
+	if err := u.SetId(ctx, "upper"); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, set := range []func() error{
+		func() error { return u.SetScore(ctx, src.GetScore()) },
+		func() error { return u.SetData(ctx, src.GetData()) },
+		func() error { return u.SetBig(ctx, src.GetBig()) },
+	} {
+		if err := set(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// adapted values are unwrapped, other values are copied.
+	lower, err := from.GetLowerInter(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := u.SetLower(ctx, lower); err != nil {
+		t.Fatal(err.Error())
+	}
+	if dst.GetLower() != src.GetLower() {
+		t.Fatal("expected the adapted lower to be unwrapped")
+	}
+	lowerKv, err := from.GetLowerKvInter(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := u.SetLowerKv(ctx, struct{ IStringLowerMapCtx }{lowerKv}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if dst.GetLowerKv()["c"] == src.GetLowerKv()["c"] {
+		t.Fatal("expected the map values to be copied")
+	}
+	counts, err := from.GetCountsInter(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := u.SetCounts(ctx, struct{ IStringInt64MapCtx }{counts}); err != nil {
+		t.Fatal(err.Error())
+	}
+	checkUpper(t, dst)
+}
+
+// TestCtxCancelForEach tests canceling the context stops an iteration.
+func TestCtxCancelForEach(t *testing.T) {
+	u := &Upper{}
+	fillUpper(u)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	counts := ToIStringInt64MapCtx(u.GetCountsInter())
+	var calls int
+	done, err := counts.ForEach(ctx, func(key string, val int64) bool {
+		calls++
+		cancel()
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if done || calls != 1 {
+		t.Fatalf("expected the iteration to stop, got done %v after %d calls", done, calls)
+	}
+
+	if _, err := counts.Get(ctx, "x"); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+// TestCtxCancelSet tests canceling the context while copying a value leaves
+// the field unchanged.
+func TestCtxCancelSet(t *testing.T) {
+	u := &Upper{}
+	fillUpper(u)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	uc := ToIUpperCtx(u)
+	if err := uc.SetLower(ctx, &cancelLower{cancel: cancel}); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if u.GetLower().GetValue() != "lower" {
+		t.Fatalf("expected lower to be unchanged, got %q", u.GetLower().GetValue())
+	}
+
+	if err := uc.SetId(ctx, "changed"); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if u.GetId() != "upper" {
+		t.Fatalf("expected id to be unchanged, got %q", u.GetId())
+	}
+}
+
+// TestCtxBackendErr tests the adapters return the error recorded by the
+// database of the SQL types.
+func TestCtxBackendErr(t *testing.T) {
+	db := openSQL(t)
+	u, err := CreateUpperSQL(db)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fillUpper(u)
+
+	ctx := context.Background()
+	uc := ToIUpperCtx(u)
+	if id, err := uc.GetId(ctx); err != nil || id != "upper" {
+		t.Fatalf("unexpected id %q: %v", id, err)
+	}
+
+	if err := db.GetQuerier().(interface{ Close() error }).Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := uc.GetId(ctx); err == nil {
+		t.Fatal("expected the database error")
+	}
+	counts, err := ToIUpperCtx(NewUpperSQL(db, u.GetRowID())).GetCountsInter(ctx)
+	if err == nil {
+		t.Fatalf("expected the database error, got %v", counts)
+	}
+}
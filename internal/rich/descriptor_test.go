@@ -0,0 +1,61 @@
+package rich
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/runtime"
+	"github.com/pkg/errors"
+)
+
+// TestDescriptor tests getting and setting fields through the descriptors.
+func TestDescriptor(t *testing.T) {
+	for name, v := range map[string]IUpper{
+		"proto": &Upper{},
+		"ctrie": NewUpperCtrie(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if desc, err := runtime.Lookup(v); err != nil || desc != UpperDescriptor {
+				t.Fatalf("expected UpperDescriptor, got %v %v", desc, err)
+			}
+
+			if err := runtime.SetField(v, "id", "test"); err != nil {
+				t.Fatal(err.Error())
+			}
+			if err := runtime.SetField(v, "id", 1); errors.Cause(err) != runtime.ErrInvalidValue {
+				t.Fatalf("expected invalid value, got %v", err)
+			}
+			if err := runtime.SetField(v, "missing", 1); errors.Cause(err) != runtime.ErrUnknownField {
+				t.Fatalf("expected unknown field, got %v", err)
+			}
+
+			fd := UpperDescriptor.FieldByName("lower")
+			lower := fd.New(v).(ILower)
+			if err := runtime.SetField(lower, "value", "lower"); err != nil {
+				t.Fatal(err.Error())
+			}
+			if err := fd.Set(v, lower); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			counts := UpperDescriptor.FieldByNumber(6)
+			m := counts.New(v)
+			if err := counts.Map.Set(m, "x", int64(1)); err != nil {
+				t.Fatal(err.Error())
+			}
+			if err := counts.Set(v, m); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			got := make(map[string]interface{})
+			if _, err := runtime.Range(v, func(fd *runtime.FieldDescriptor, val interface{}) bool {
+				got[fd.Name] = val
+				return true
+			}); err != nil {
+				t.Fatal(err.Error())
+			}
+			if got["id"] != "test" || v.GetLowerInter().GetValue() != "lower" || v.GetCountsInter().Get("x") != 1 {
+				t.Fatalf("unexpected fields %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package rich
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/diff"
+)
+
+// TestDiff tests the changes between objects of different implementations.
+func TestDiff(t *testing.T) {
+	a := &Upper{}
+	fillUpper(a)
+	b := NewUpperCtrie()
+	fillUpper(b)
+	if changes := DiffUpper(a, b); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	b.SetId("changed")
+	b.GetLowerInter().SetFlag(false)
+	b.GetLowerKvInter().Set("d", &Lower{Value: "d"})
+	b.GetCountsInter().Set("y", 3)
+	b.SetData(nil)
+	a.SetLowerKv(StringLowerMap{"a/b": &Lower{Value: "escaped"}, "c": a.GetLowerKvInter().Get("c").(*Lower), "e": nil})
+
+	expected := []diff.Change{
+		{Path: "/id", Kind: diff.Modified, Old: "upper", New: "changed"},
+		{Path: "/lower/flag", Kind: diff.Modified, Old: true, New: false},
+		{Path: "/lower_kv/d", Kind: diff.Added},
+		{Path: "/lower_kv/e", Kind: diff.Removed},
+		{Path: "/data", Kind: diff.Modified},
+		{Path: "/counts/y", Kind: diff.Modified, Old: int64(-2), New: int64(3)},
+	}
+	changes := DiffUpper(a, b)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+	for i, c := range changes {
+		e := expected[i]
+		if c.Path != e.Path || c.Kind != e.Kind {
+			t.Errorf("change %d: expected %s %s, got %v", i, e.Kind, e.Path, c)
+		}
+		if e.Old != nil && (c.Old != e.Old || c.New != e.New) {
+			t.Errorf("change %d: expected %v, got %v", i, e, c)
+		}
+	}
+
+	// a nil object is added or removed at the root.
+	if changes := DiffLower(nil, &Lower{}); len(changes) != 1 || changes[0].Kind != diff.Added {
+		t.Fatalf("expected an added change, got %v", changes)
+	}
+}
@@ -0,0 +1,7 @@
+// Package rich is a test fixture containing the output of the generators for
+// rich.proto, used to test the behavior of the generated code.
+//
+// Regenerate the files after changing a generator with:
+//
+//	go test ./internal/rich -update
+package rich
@@ -0,0 +1,45 @@
+package rich
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEqual tests comparing objects across implementations.
+func TestEqual(t *testing.T) {
+	a := &Upper{}
+	fillUpper(a)
+	b := NewUpperCtrie()
+	fillUpper(b)
+	if !EqualUpper(a, b) || !EqualUpper(b, a) {
+		t.Fatal("expected the proto and ctrie objects to be equal")
+	}
+
+	for name, change := range map[string]func(u IUpper){
+		"id":     func(u IUpper) { u.SetId("changed") },
+		"lower":  func(u IUpper) { u.SetLower(nil) },
+		"nested": func(u IUpper) { u.GetLowerKvInter().Get("c").SetFlag(false) },
+		"key":    func(u IUpper) { u.GetLowerKvInter().Set("d", &Lower{}) },
+		"data":   func(u IUpper) { u.SetData([]byte{1, 2}) },
+		"counts": func(u IUpper) { u.GetCountsInter().Set("x", 2) },
+		"big":    func(u IUpper) { u.SetBig(0) },
+	} {
+		c := NewUpperCtrieFrom(b)
+		change(c)
+		if EqualUpper(a, c) || EqualUpper(c, a) {
+			t.Errorf("%s: expected a change to be unequal", name)
+		}
+	}
+
+	// NaN scores are equal, nil and empty maps are equal.
+	a.SetScore(math.NaN())
+	b.SetScore(math.NaN())
+	b.SetCounts(nil)
+	a.SetCounts(StringInt64Map{})
+	if !EqualUpper(a, b) {
+		t.Fatal("expected NaN scores and empty maps to be equal")
+	}
+	if EqualLower(nil, &Lower{}) || !EqualLower(nil, nil) {
+		t.Fatal("expected nil to only equal nil")
+	}
+}
@@ -0,0 +1,93 @@
+package rich
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/paralin/protods/generate"
+	_ "github.com/paralin/protods/generate/ctrie"
+	_ "github.com/paralin/protods/generate/deepcopy"
+	_ "github.com/paralin/protods/generate/descriptor"
+	_ "github.com/paralin/protods/generate/diff"
+	_ "github.com/paralin/protods/generate/equal"
+	_ "github.com/paralin/protods/generate/itypes"
+	_ "github.com/paralin/protods/generate/journal"
+	_ "github.com/paralin/protods/generate/kv"
+	_ "github.com/paralin/protods/generate/merge"
+	_ "github.com/paralin/protods/generate/readonly"
+	_ "github.com/paralin/protods/generate/scaffold"
+	_ "github.com/paralin/protods/generate/sql"
+	_ "github.com/paralin/protods/generate/sync"
+	_ "github.com/paralin/protods/generate/watch"
+)
+
+var update = flag.Bool("update", false, "regenerate the fixture files")
+
+// fixtureGenerators are the generators with output in the fixture.
+var fixtureGenerators = []struct {
+	name   string
+	params map[string]string
+}{
+	{name: "copy"},
+	{name: "ctrie"},
+	{name: "descriptor"},
+	{name: "diff"},
+	{name: "equal"},
+	{name: "itypes", params: map[string]string{"ctx": "true"}},
+	{name: "journal"},
+	{name: "kv"},
+	{name: "merge"},
+	{name: "readonly"},
+	{name: "scaffold"},
+	{name: "sql"},
+	{name: "sync"},
+	{name: "watch"},
+}
+
+// TestGenerated tests the fixture files match the generator output.
+func TestGenerated(t *testing.T) {
+	for _, fg := range fixtureGenerators {
+		gen := generate.GetGenerator(fg.name)
+		if gen == nil {
+			t.Fatalf("generator %s not registered", fg.name)
+		}
+
+		outDir := "."
+		if !*update {
+			outDir = t.TempDir()
+		}
+
+		// the fixture package is type checked by the compiler.
+		err := generate.GenerateWithOptions(gen, "rich.proto", outDir, generate.Options{
+			Params:        fg.params,
+			SkipTypeCheck: true,
+		})
+		if err != nil {
+			t.Fatalf("generate %s: %v", fg.name, err)
+		}
+		if *update {
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(outDir)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, info := range infos {
+			generated, err := ioutil.ReadFile(path.Join(outDir, info.Name()))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			existing, err := ioutil.ReadFile(info.Name())
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if !bytes.Equal(existing, generated) {
+				t.Errorf("%s is out of date, run go test -update", info.Name())
+			}
+		}
+	}
+}
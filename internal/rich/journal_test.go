@@ -0,0 +1,66 @@
+package rich
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/paralin/protods/journal"
+	"github.com/pkg/errors"
+)
+
+// TestJournal tests recording mutations and replaying them on each
+// implementation, through a serialized journal.
+func TestJournal(t *testing.T) {
+	j := journal.New()
+	src := &Upper{}
+	fillUpper(NewUpperJournal(src, j, ""))
+	if err := j.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var buf bytes.Buffer
+	if _, err := j.WriteTo(&buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	read, err := journal.Read(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if read.Len() != j.Len() {
+		t.Fatalf("expected %d entries, got %d", j.Len(), read.Len())
+	}
+
+	for name, dst := range map[string]IUpper{
+		"proto": &Upper{},
+		"ctrie": NewUpperCtrie(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := ReplayUpper(read, dst); err != nil {
+				t.Fatal(err.Error())
+			}
+			checkUpper(t, dst)
+			if !EqualUpper(src, dst) {
+				t.Fatal("expected the replayed object to equal the source")
+			}
+		})
+	}
+
+	// clearing a message is replayed as a delete.
+	cleared := journal.New()
+	NewUpperJournal(&Upper{}, cleared, "").SetLower(nil)
+	dst := &Upper{}
+	fillUpper(dst)
+	if err := ReplayUpper(cleared, dst); err != nil {
+		t.Fatal(err.Error())
+	}
+	if dst.GetLowerInter() != nil {
+		t.Fatal("expected lower to be cleared")
+	}
+
+	// entries not matching the target are rejected.
+	bad := journal.New()
+	bad.Set("/missing", "value")
+	if err := ReplayUpper(bad, &Upper{}); errors.Cause(err) != journal.ErrInvalidEntry {
+		t.Fatalf("expected invalid entry, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package rich
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	"github.com/paralin/protods/kv/redis"
+	"github.com/paralin/protods/kv/redis/redistest"
+)
+
+// countingStore counts the round trips to a store.
+type countingStore struct {
+	kv.Lister
+	gets    int
+	batches int
+}
+
+func (s *countingStore) Get(key string) (bool, interface{}) {
+	s.gets++
+	return s.Lister.Get(key)
+}
+
+func (s *countingStore) GetBatch(keys []string) map[string]interface{} {
+	s.batches++
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if found, value := s.Lister.Get(key); found {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// newRedisStore builds a store backed by a test redis server.
+func newRedisStore(t *testing.T, namespace string) *redis.Store {
+	srv, err := redistest.NewServer()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+
+	client, err := srv.Dial()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return redis.NewStore(client, namespace)
+}
+
+func TestKV(t *testing.T) {
+	store := newRedisStore(t, "upper:")
+	fillUpper(NewUpperKV(kv.NewTree(store), ""))
+	if err := store.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// load the object again from the store.
+	checkUpper(t, NewUpperKV(kv.NewTree(store), ""))
+
+	from := &Upper{}
+	fillUpper(from)
+	u := NewUpperKV(kv.NewTree(newRedisStore(t, "from:")), "")
+	u.copyFrom(from)
+	checkUpper(t, u)
+}
+
+// TestKVPrefetch tests Prefetch loads the object with one batch per level.
+func TestKVPrefetch(t *testing.T) {
+	store := &countingStore{Lister: newRedisStore(t, "upper:")}
+	fillUpper(NewUpperKV(kv.NewTree(store), ""))
+
+	u := NewUpperKV(kv.NewTree(store), "")
+	store.gets, store.batches = 0, 0
+	u.Prefetch()
+	if store.batches != 3 {
+		t.Errorf("expected 3 batches, got %d", store.batches)
+	}
+	checkUpper(t, u)
+	if store.gets != 0 {
+		t.Errorf("expected the prefetched values to be cached, got %d gets", store.gets)
+	}
+}
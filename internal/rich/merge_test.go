@@ -0,0 +1,55 @@
+package rich
+
+import (
+	"testing"
+)
+
+// TestMerge tests merging a proto into each implementation.
+func TestMerge(t *testing.T) {
+	src := &Upper{}
+	fillUpper(src)
+
+	for name, dst := range map[string]IUpper{
+		"proto": &Upper{},
+		"ctrie": NewUpperCtrie(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// an empty source changes nothing.
+			MergeUpper(dst, &Upper{})
+			if dst.GetLowerInter() != nil {
+				t.Fatal("expected lower to stay unset")
+			}
+			if !isUnsetStringLowerMap(dst.GetLowerKvInter()) || !isUnsetStringInt64Map(dst.GetCountsInter()) {
+				t.Fatal("expected the maps to stay unset")
+			}
+
+			MergeUpper(dst, src)
+			checkUpper(t, dst)
+
+			// set fields are overwritten, map entries are set by key.
+			MergeUpper(dst, &Upper{
+				Lower:  &Lower{Value: "merged"},
+				Counts: map[string]int64{"x": 5, "z": 3},
+			})
+			lower := dst.GetLowerInter()
+			if lower.GetValue() != "merged" || !lower.GetFlag() {
+				t.Errorf("expected lower to be merged: %q %v", lower.GetValue(), lower.GetFlag())
+			}
+			counts := dst.GetCountsInter()
+			if counts.Get("x") != 5 || counts.Get("y") != -2 || counts.Get("z") != 3 {
+				t.Errorf("expected counts to be merged: %v %v %v", counts.Get("x"), counts.Get("y"), counts.Get("z"))
+			}
+		})
+	}
+}
+
+// TestMergeBytes tests merged byte slices are not shared with src.
+func TestMergeBytes(t *testing.T) {
+	src := &Upper{Data: []byte{1}}
+	dst := &Upper{}
+	MergeUpper(dst, src)
+	src.Data[0] = 9
+	if dst.Data[0] != 1 {
+		t.Fatalf("expected dst to keep its data, got %v", dst.Data)
+	}
+}
@@ -0,0 +1,47 @@
+package rich
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/paralin/protods/readonly"
+	"github.com/pkg/errors"
+)
+
+// TestReadOnly tests the read-only views.
+func TestReadOnly(t *testing.T) {
+	u := &Upper{}
+	fillUpper(u)
+	view := FreezeUpper(u)
+	checkUpper(t, view)
+
+	// the returned bytes are a copy.
+	view.GetData()[0] = 9
+	if !bytes.Equal(u.GetData(), []byte{1, 2, 3}) {
+		t.Fatalf("expected data to be unchanged, got %v", u.GetData())
+	}
+
+	// the nested values are views, so the setters panic with the key path.
+	for path, set := range map[string]func(){
+		"/id":             func() { NewReadOnlyUpper(u).SetId("changed") },
+		"/lower/value":    func() { view.(IUpper).GetLowerInter().SetValue("changed") },
+		"/lower_kv/a%2Fb": func() { view.GetLowerKvReader().(IStringLowerMap).Set("a/b", nil) },
+		"/lower_kv/c/flag": func() {
+			view.GetLowerKvReader().GetReader("c").(ILower).SetFlag(false)
+		},
+		"/counts/x": func() { view.GetCountsReader().(IStringInt64Map).Set("x", 2) },
+	} {
+		err := func() (err error) {
+			defer readonly.Recover(&err)
+			set()
+			return nil
+		}()
+		if errors.Cause(err) != readonly.ErrReadOnly {
+			t.Fatalf("%s: expected read-only error, got %v", path, err)
+		}
+		if p := err.(*readonly.Error).Path; p != path {
+			t.Errorf("expected path %s, got %s", path, p)
+		}
+	}
+	checkUpper(t, u)
+}
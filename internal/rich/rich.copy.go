@@ -0,0 +1,83 @@
+package rich
+
+// CopyStringInt64Map sets the entries of src in dst.
+func CopyStringInt64Map(dst, src IStringInt64Map) {
+	src.ForEach(func(key string, val int64) bool {
+		dst.Set(key, val)
+		return true
+	})
+}
+
+// CopyStringLowerMap sets the entries of src in dst.
+// Message values are set to an empty Lower and filled in through dst.Get,
+// so they are stored by the implementation of dst.
+func CopyStringLowerMap(dst, src IStringLowerMap) {
+	src.ForEach(func(key string, val ILower) bool {
+		if val == nil {
+			dst.Set(key, nil)
+			return true
+		}
+		dst.Set(key, &Lower{})
+		if v := dst.Get(key); v != nil {
+			CopyLower(v, val)
+		}
+		return true
+	})
+}
+
+// CopyLower sets the fields of dst to deep copies of the fields of src.
+// Message and map fields are built with the constructors of dst.
+func CopyLower(dst, src ILower) {
+	dst.SetValue(src.GetValue())
+	dst.SetFlag(src.GetFlag())
+}
+
+// ToProtoLower returns a deep copy of src as a Lower, or nil if src is nil.
+func ToProtoLower(src ILower) *Lower {
+	if src == nil {
+		return nil
+	}
+	m := &Lower{}
+	CopyLower(m, src)
+	return m
+}
+
+// CopyUpper sets the fields of dst to deep copies of the fields of src.
+// Message and map fields are built with the constructors of dst.
+func CopyUpper(dst, src IUpper) {
+	dst.SetId(src.GetId())
+	if v := src.GetLowerInter(); v != nil {
+		c := dst.NewLower()
+		CopyLower(c, v)
+		dst.SetLower(c)
+	} else {
+		dst.SetLower(nil)
+	}
+	if v := src.GetLowerKvInter(); v != nil {
+		c := dst.NewLowerKv()
+		CopyStringLowerMap(c, v)
+		dst.SetLowerKv(c)
+	} else {
+		dst.SetLowerKv(nil)
+	}
+	dst.SetScore(src.GetScore())
+	dst.SetData(append([]byte(nil), src.GetData()...))
+	if v := src.GetCountsInter(); v != nil {
+		c := dst.NewCounts()
+		CopyStringInt64Map(c, v)
+		dst.SetCounts(c)
+	} else {
+		dst.SetCounts(nil)
+	}
+	dst.SetBig(src.GetBig())
+}
+
+// ToProtoUpper returns a deep copy of src as a Upper, or nil if src is nil.
+func ToProtoUpper(src IUpper) *Upper {
+	if src == nil {
+		return nil
+	}
+	m := &Upper{}
+	CopyUpper(m, src)
+	return m
+}
@@ -0,0 +1,376 @@
+package rich
+
+import (
+	"net/url"
+
+	"github.com/paralin/protods/ctrie"
+)
+
+// StringInt64MapCtrie is an IStringInt64Map stored in a concurrent hash trie.
+type StringInt64MapCtrie struct {
+	trie   *ctrie.Trie
+	prefix string
+}
+
+// NewStringInt64MapCtrie builds a new empty StringInt64MapCtrie.
+func NewStringInt64MapCtrie() *StringInt64MapCtrie {
+	return &StringInt64MapCtrie{trie: ctrie.New()}
+}
+
+// NewStringInt64MapCtrieFrom copies val into a new StringInt64MapCtrie.
+// Shares structure with val in constant time if it is a StringInt64MapCtrie.
+func NewStringInt64MapCtrieFrom(val IStringInt64Map) *StringInt64MapCtrie {
+	if v, ok := val.(*StringInt64MapCtrie); ok {
+		if sub := v.trie.Subtrie(v.prefix); sub != nil {
+			return &StringInt64MapCtrie{trie: sub}
+		}
+	}
+
+	c := NewStringInt64MapCtrie()
+	val.ForEach(func(key string, v int64) bool {
+		c.Set(key, v)
+		return true
+	})
+	return c
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapCtrie)(nil))
+
+// Get returns a value from the map.
+func (s *StringInt64MapCtrie) Get(key string) int64 {
+	v, _ := s.trie.Load(s.prefix + "/" + url.PathEscape(key))
+	val, _ := v.(int64)
+	return val
+}
+
+// Set sets a value in the map.
+func (s *StringInt64MapCtrie) Set(key string, val int64) {
+	s.trie.Store(s.prefix+"/"+url.PathEscape(key), val)
+}
+
+// ForEach iterates over a snapshot of the map.
+func (s *StringInt64MapCtrie) ForEach(cb func(key string, val int64) bool) bool {
+	snap := NewStringInt64MapCtrieFrom(s)
+	for _, k := range snap.trie.Keys("") {
+		key, err := url.PathUnescape(k)
+		if err != nil {
+			continue
+		}
+		if !cb(key, snap.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StringLowerMapCtrie is an IStringLowerMap stored in a concurrent hash trie.
+type StringLowerMapCtrie struct {
+	trie   *ctrie.Trie
+	prefix string
+}
+
+// NewStringLowerMapCtrie builds a new empty StringLowerMapCtrie.
+func NewStringLowerMapCtrie() *StringLowerMapCtrie {
+	return &StringLowerMapCtrie{trie: ctrie.New()}
+}
+
+// NewStringLowerMapCtrieFrom copies val into a new StringLowerMapCtrie.
+// Shares structure with val in constant time if it is a StringLowerMapCtrie.
+func NewStringLowerMapCtrieFrom(val IStringLowerMap) *StringLowerMapCtrie {
+	if v, ok := val.(*StringLowerMapCtrie); ok {
+		if sub := v.trie.Subtrie(v.prefix); sub != nil {
+			return &StringLowerMapCtrie{trie: sub}
+		}
+	}
+
+	c := NewStringLowerMapCtrie()
+	val.ForEach(func(key string, v ILower) bool {
+		c.Set(key, v)
+		return true
+	})
+	return c
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapCtrie)(nil))
+
+// Get returns a value from the map.
+func (s *StringLowerMapCtrie) Get(key string) ILower {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	if !s.trie.Has(keyPath) {
+		return nil
+	}
+	return &LowerCtrie{trie: s.trie, prefix: keyPath}
+}
+
+// Set sets a value in the map.
+func (s *StringLowerMapCtrie) Set(key string, val ILower) {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	if val == nil {
+		s.trie.Delete(keyPath)
+		return
+	}
+	s.trie.Graft(keyPath, NewLowerCtrieFrom(val).trie)
+}
+
+// ForEach iterates over a snapshot of the map.
+func (s *StringLowerMapCtrie) ForEach(cb func(key string, val ILower) bool) bool {
+	snap := NewStringLowerMapCtrieFrom(s)
+	for _, k := range snap.trie.Keys("") {
+		key, err := url.PathUnescape(k)
+		if err != nil {
+			continue
+		}
+		if !cb(key, snap.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapCtrie) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapCtrie) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerCtrie is an ILower stored in a concurrent hash trie.
+type LowerCtrie struct {
+	trie   *ctrie.Trie
+	prefix string
+}
+
+// NewLowerCtrie builds a new empty LowerCtrie.
+func NewLowerCtrie() *LowerCtrie {
+	return &LowerCtrie{trie: ctrie.New()}
+}
+
+// NewLowerCtrieFrom copies val into a new LowerCtrie.
+// Shares structure with val in constant time if it is a LowerCtrie.
+func NewLowerCtrieFrom(val ILower) *LowerCtrie {
+	if v, ok := val.(*LowerCtrie); ok {
+		if sub := v.trie.Subtrie(v.prefix); sub != nil {
+			return &LowerCtrie{trie: sub}
+		}
+	}
+
+	c := NewLowerCtrie()
+	c.SetValue(val.GetValue())
+	c.SetFlag(val.GetFlag())
+	return c
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerCtrie)(nil))
+
+// Snapshot returns an independent copy of the object in constant time.
+func (s *LowerCtrie) Snapshot() ILower {
+	return NewLowerCtrieFrom(s)
+}
+
+// GetValue returns value.
+func (s *LowerCtrie) GetValue() string {
+	v, _ := s.trie.Load(s.prefix + "/value")
+	val, _ := v.(string)
+	return val
+}
+
+// SetValue sets value.
+func (s *LowerCtrie) SetValue(val string) {
+	s.trie.Store(s.prefix+"/value", val)
+}
+
+// GetFlag returns flag.
+func (s *LowerCtrie) GetFlag() bool {
+	v, _ := s.trie.Load(s.prefix + "/flag")
+	val, _ := v.(bool)
+	return val
+}
+
+// SetFlag sets flag.
+func (s *LowerCtrie) SetFlag(val bool) {
+	s.trie.Store(s.prefix+"/flag", val)
+}
+
+// UpperCtrie is an IUpper stored in a concurrent hash trie.
+type UpperCtrie struct {
+	trie   *ctrie.Trie
+	prefix string
+}
+
+// NewUpperCtrie builds a new empty UpperCtrie.
+func NewUpperCtrie() *UpperCtrie {
+	return &UpperCtrie{trie: ctrie.New()}
+}
+
+// NewUpperCtrieFrom copies val into a new UpperCtrie.
+// Shares structure with val in constant time if it is a UpperCtrie.
+func NewUpperCtrieFrom(val IUpper) *UpperCtrie {
+	if v, ok := val.(*UpperCtrie); ok {
+		if sub := v.trie.Subtrie(v.prefix); sub != nil {
+			return &UpperCtrie{trie: sub}
+		}
+	}
+
+	c := NewUpperCtrie()
+	c.SetId(val.GetId())
+	c.SetLower(val.GetLowerInter())
+	c.SetLowerKv(val.GetLowerKvInter())
+	c.SetScore(val.GetScore())
+	c.SetData(val.GetData())
+	c.SetCounts(val.GetCountsInter())
+	c.SetBig(val.GetBig())
+	return c
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperCtrie)(nil))
+
+// Snapshot returns an independent copy of the object in constant time.
+func (s *UpperCtrie) Snapshot() IUpper {
+	return NewUpperCtrieFrom(s)
+}
+
+// GetId returns id.
+func (s *UpperCtrie) GetId() string {
+	v, _ := s.trie.Load(s.prefix + "/id")
+	val, _ := v.(string)
+	return val
+}
+
+// SetId sets id.
+func (s *UpperCtrie) SetId(val string) {
+	s.trie.Store(s.prefix+"/id", val)
+}
+
+// GetLowerInter returns lower.
+func (s *UpperCtrie) GetLowerInter() ILower {
+	keyPath := s.prefix + "/lower"
+	if !s.trie.Has(keyPath) {
+		return nil
+	}
+	return &LowerCtrie{trie: s.trie, prefix: keyPath}
+}
+
+// SetLower sets lower.
+func (s *UpperCtrie) SetLower(val ILower) {
+	keyPath := s.prefix + "/lower"
+	if val == nil {
+		s.trie.Delete(keyPath)
+		return
+	}
+	s.trie.Graft(keyPath, NewLowerCtrieFrom(val).trie)
+}
+
+// NewLower builds a new empty lower.
+func (s *UpperCtrie) NewLower() ILower {
+	return NewLowerCtrie()
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *UpperCtrie) GetLowerKvInter() IStringLowerMap {
+	keyPath := s.prefix + "/lower_kv"
+	if !s.trie.Has(keyPath) {
+		return nil
+	}
+	return &StringLowerMapCtrie{trie: s.trie, prefix: keyPath}
+}
+
+// SetLowerKv sets lower_kv.
+func (s *UpperCtrie) SetLowerKv(val IStringLowerMap) {
+	keyPath := s.prefix + "/lower_kv"
+	if val == nil {
+		s.trie.Delete(keyPath)
+		return
+	}
+	s.trie.Graft(keyPath, NewStringLowerMapCtrieFrom(val).trie)
+}
+
+// NewLowerKv builds a new empty lower_kv.
+func (s *UpperCtrie) NewLowerKv() IStringLowerMap {
+	return NewStringLowerMapCtrie()
+}
+
+// GetScore returns score.
+func (s *UpperCtrie) GetScore() float64 {
+	v, _ := s.trie.Load(s.prefix + "/score")
+	val, _ := v.(float64)
+	return val
+}
+
+// SetScore sets score.
+func (s *UpperCtrie) SetScore(val float64) {
+	s.trie.Store(s.prefix+"/score", val)
+}
+
+// GetData returns data.
+func (s *UpperCtrie) GetData() []byte {
+	v, _ := s.trie.Load(s.prefix + "/data")
+	val, _ := v.([]byte)
+	return append([]byte(nil), val...)
+}
+
+// SetData sets data.
+func (s *UpperCtrie) SetData(val []byte) {
+	s.trie.Store(s.prefix+"/data", append([]byte(nil), val...))
+}
+
+// GetCountsInter returns counts.
+func (s *UpperCtrie) GetCountsInter() IStringInt64Map {
+	keyPath := s.prefix + "/counts"
+	if !s.trie.Has(keyPath) {
+		return nil
+	}
+	return &StringInt64MapCtrie{trie: s.trie, prefix: keyPath}
+}
+
+// SetCounts sets counts.
+func (s *UpperCtrie) SetCounts(val IStringInt64Map) {
+	keyPath := s.prefix + "/counts"
+	if val == nil {
+		s.trie.Delete(keyPath)
+		return
+	}
+	s.trie.Graft(keyPath, NewStringInt64MapCtrieFrom(val).trie)
+}
+
+// NewCounts builds a new empty counts.
+func (s *UpperCtrie) NewCounts() IStringInt64Map {
+	return NewStringInt64MapCtrie()
+}
+
+// GetBig returns big.
+func (s *UpperCtrie) GetBig() uint64 {
+	v, _ := s.trie.Load(s.prefix + "/big")
+	val, _ := v.(uint64)
+	return val
+}
+
+// SetBig sets big.
+func (s *UpperCtrie) SetBig(val uint64) {
+	s.trie.Store(s.prefix+"/big", val)
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperCtrie) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperCtrie) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperCtrie) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
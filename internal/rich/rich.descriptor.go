@@ -0,0 +1,261 @@
+package rich
+
+import (
+	"github.com/paralin/protods/runtime"
+)
+
+// StringInt64MapDescriptor describes IStringInt64Map.
+var StringInt64MapDescriptor = &runtime.MapDescriptor{
+	Name:        "StringInt64Map",
+	ValueKind:   runtime.KindScalar,
+	ValueGoType: "int64",
+}
+
+// StringLowerMapDescriptor describes IStringLowerMap.
+var StringLowerMapDescriptor = &runtime.MapDescriptor{
+	Name:        "StringLowerMap",
+	ValueKind:   runtime.KindMessage,
+	ValueGoType: "ILower",
+}
+
+// LowerDescriptor describes ILower.
+var LowerDescriptor = &runtime.MessageDescriptor{Name: "Lower"}
+
+// UpperDescriptor describes IUpper.
+var UpperDescriptor = &runtime.MessageDescriptor{Name: "Upper"}
+
+func init() {
+	StringInt64MapDescriptor.Get = func(m interface{}, key string) interface{} {
+		return m.(IStringInt64Map).Get(key)
+	}
+	StringInt64MapDescriptor.Set = func(m interface{}, key string, val interface{}) error {
+		v, ok := val.(int64)
+		if !ok {
+			return runtime.InvalidValue("StringInt64Map", val)
+		}
+		m.(IStringInt64Map).Set(key, v)
+		return nil
+	}
+	StringInt64MapDescriptor.Range = func(m interface{}, cb func(key string, val interface{}) bool) bool {
+		return m.(IStringInt64Map).ForEach(func(key string, val int64) bool {
+			return cb(key, val)
+		})
+	}
+	StringLowerMapDescriptor.ValueMessage = LowerDescriptor
+	StringLowerMapDescriptor.Get = func(m interface{}, key string) interface{} {
+		return m.(IStringLowerMap).Get(key)
+	}
+	StringLowerMapDescriptor.Set = func(m interface{}, key string, val interface{}) error {
+		var v ILower
+		if val != nil {
+			var ok bool
+			if v, ok = val.(ILower); !ok {
+				return runtime.InvalidValue("StringLowerMap", val)
+			}
+		}
+		m.(IStringLowerMap).Set(key, v)
+		return nil
+	}
+	StringLowerMapDescriptor.Range = func(m interface{}, cb func(key string, val interface{}) bool) bool {
+		return m.(IStringLowerMap).ForEach(func(key string, val ILower) bool {
+			return cb(key, val)
+		})
+	}
+	LowerDescriptor.Is = func(obj interface{}) bool {
+		_, ok := obj.(ILower)
+		return ok
+	}
+	LowerDescriptor.Fields = []*runtime.FieldDescriptor{
+		{
+			Name:    "value",
+			Number:  1,
+			Kind:    runtime.KindScalar,
+			GoType:  "string",
+			KeyPath: "/value",
+			Get: func(obj interface{}) interface{} {
+				return obj.(ILower).GetValue()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.(string)
+				if !ok {
+					return runtime.InvalidValue("Lower.value", val)
+				}
+				obj.(ILower).SetValue(v)
+				return nil
+			},
+		},
+		{
+			Name:    "flag",
+			Number:  2,
+			Kind:    runtime.KindScalar,
+			GoType:  "bool",
+			KeyPath: "/flag",
+			Get: func(obj interface{}) interface{} {
+				return obj.(ILower).GetFlag()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.(bool)
+				if !ok {
+					return runtime.InvalidValue("Lower.flag", val)
+				}
+				obj.(ILower).SetFlag(v)
+				return nil
+			},
+		},
+	}
+	runtime.Register(LowerDescriptor, (*Lower)(nil))
+	UpperDescriptor.Is = func(obj interface{}) bool {
+		_, ok := obj.(IUpper)
+		return ok
+	}
+	UpperDescriptor.Fields = []*runtime.FieldDescriptor{
+		{
+			Name:    "id",
+			Number:  1,
+			Kind:    runtime.KindScalar,
+			GoType:  "string",
+			KeyPath: "/id",
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetId()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.(string)
+				if !ok {
+					return runtime.InvalidValue("Upper.id", val)
+				}
+				obj.(IUpper).SetId(v)
+				return nil
+			},
+		},
+		{
+			Name:    "lower",
+			Number:  2,
+			Kind:    runtime.KindMessage,
+			GoType:  "ILower",
+			KeyPath: "/lower",
+			Message: LowerDescriptor,
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetLowerInter()
+			},
+			Set: func(obj, val interface{}) error {
+				var v ILower
+				if val != nil {
+					var ok bool
+					if v, ok = val.(ILower); !ok {
+						return runtime.InvalidValue("Upper.lower", val)
+					}
+				}
+				obj.(IUpper).SetLower(v)
+				return nil
+			},
+			New: func(obj interface{}) interface{} {
+				return obj.(IUpper).NewLower()
+			},
+		},
+		{
+			Name:    "lower_kv",
+			Number:  3,
+			Kind:    runtime.KindMap,
+			GoType:  "IStringLowerMap",
+			KeyPath: "/lower_kv",
+			Map:     StringLowerMapDescriptor,
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetLowerKvInter()
+			},
+			Set: func(obj, val interface{}) error {
+				var v IStringLowerMap
+				if val != nil {
+					var ok bool
+					if v, ok = val.(IStringLowerMap); !ok {
+						return runtime.InvalidValue("Upper.lower_kv", val)
+					}
+				}
+				obj.(IUpper).SetLowerKv(v)
+				return nil
+			},
+			New: func(obj interface{}) interface{} {
+				return obj.(IUpper).NewLowerKv()
+			},
+		},
+		{
+			Name:    "score",
+			Number:  4,
+			Kind:    runtime.KindScalar,
+			GoType:  "float64",
+			KeyPath: "/score",
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetScore()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.(float64)
+				if !ok {
+					return runtime.InvalidValue("Upper.score", val)
+				}
+				obj.(IUpper).SetScore(v)
+				return nil
+			},
+		},
+		{
+			Name:    "data",
+			Number:  5,
+			Kind:    runtime.KindScalar,
+			GoType:  "[]byte",
+			KeyPath: "/data",
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetData()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.([]byte)
+				if !ok {
+					return runtime.InvalidValue("Upper.data", val)
+				}
+				obj.(IUpper).SetData(v)
+				return nil
+			},
+		},
+		{
+			Name:    "counts",
+			Number:  6,
+			Kind:    runtime.KindMap,
+			GoType:  "IStringInt64Map",
+			KeyPath: "/counts",
+			Map:     StringInt64MapDescriptor,
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetCountsInter()
+			},
+			Set: func(obj, val interface{}) error {
+				var v IStringInt64Map
+				if val != nil {
+					var ok bool
+					if v, ok = val.(IStringInt64Map); !ok {
+						return runtime.InvalidValue("Upper.counts", val)
+					}
+				}
+				obj.(IUpper).SetCounts(v)
+				return nil
+			},
+			New: func(obj interface{}) interface{} {
+				return obj.(IUpper).NewCounts()
+			},
+		},
+		{
+			Name:    "big",
+			Number:  8,
+			Kind:    runtime.KindScalar,
+			GoType:  "uint64",
+			KeyPath: "/big",
+			Get: func(obj interface{}) interface{} {
+				return obj.(IUpper).GetBig()
+			},
+			Set: func(obj, val interface{}) error {
+				v, ok := val.(uint64)
+				if !ok {
+					return runtime.InvalidValue("Upper.big", val)
+				}
+				obj.(IUpper).SetBig(v)
+				return nil
+			},
+		},
+	}
+	runtime.Register(UpperDescriptor, (*Upper)(nil))
+}
@@ -0,0 +1,157 @@
+package rich
+
+import (
+	"bytes"
+	"math"
+	"net/url"
+	"sort"
+
+	"github.com/paralin/protods/diff"
+)
+
+// appendDiffStringInt64Map appends the changes from a to b below the path, in key order.
+// A nil map is equal to an empty map.
+func appendDiffStringInt64Map(changes []diff.Change, path string, a, b IStringInt64Map) []diff.Change {
+	aEntries := make(map[string]int64)
+	if a != nil {
+		a.ForEach(func(key string, val int64) bool {
+			aEntries[key] = val
+			return true
+		})
+	}
+	bEntries := make(map[string]int64)
+	if b != nil {
+		b.ForEach(func(key string, val int64) bool {
+			bEntries[key] = val
+			return true
+		})
+	}
+	keys := make([]string, 0, len(aEntries)+len(bEntries))
+	for key := range aEntries {
+		keys = append(keys, key)
+	}
+	for key := range bEntries {
+		if _, ok := aEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := path + "/" + url.PathEscape(key)
+		av, aok := aEntries[key]
+		bv, bok := bEntries[key]
+		switch {
+		case !bok:
+			changes = append(changes, diff.Change{Path: keyPath, Kind: diff.Removed, Old: av})
+		case !aok:
+			changes = append(changes, diff.Change{Path: keyPath, Kind: diff.Added, New: bv})
+		case av != bv:
+			changes = append(changes, diff.Change{Path: keyPath, Kind: diff.Modified, Old: av, New: bv})
+		}
+	}
+	return changes
+}
+
+// appendDiffStringLowerMap appends the changes from a to b below the path, in key order.
+// A nil map is equal to an empty map.
+func appendDiffStringLowerMap(changes []diff.Change, path string, a, b IStringLowerMap) []diff.Change {
+	aEntries := make(map[string]ILower)
+	if a != nil {
+		a.ForEach(func(key string, val ILower) bool {
+			aEntries[key] = val
+			return true
+		})
+	}
+	bEntries := make(map[string]ILower)
+	if b != nil {
+		b.ForEach(func(key string, val ILower) bool {
+			bEntries[key] = val
+			return true
+		})
+	}
+	keys := make([]string, 0, len(aEntries)+len(bEntries))
+	for key := range aEntries {
+		keys = append(keys, key)
+	}
+	for key := range bEntries {
+		if _, ok := aEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := path + "/" + url.PathEscape(key)
+		av, aok := aEntries[key]
+		bv, bok := bEntries[key]
+		switch {
+		case !bok:
+			changes = append(changes, diff.Change{Path: keyPath, Kind: diff.Removed, Old: av})
+		case !aok:
+			changes = append(changes, diff.Change{Path: keyPath, Kind: diff.Added, New: bv})
+		default:
+			changes = appendDiffLower(changes, keyPath, av, bv)
+		}
+	}
+	return changes
+}
+
+// DiffLower returns the changes from a to b.
+// Paths use the key/value layout, for example /lower_kv/test/value.
+func DiffLower(a, b ILower) []diff.Change {
+	return appendDiffLower(nil, "", a, b)
+}
+
+// appendDiffLower appends the changes from a to b below the path.
+func appendDiffLower(changes []diff.Change, path string, a, b ILower) []diff.Change {
+	if a == nil || b == nil {
+		if a != nil {
+			changes = append(changes, diff.Change{Path: path, Kind: diff.Removed, Old: a})
+		} else if b != nil {
+			changes = append(changes, diff.Change{Path: path, Kind: diff.Added, New: b})
+		}
+		return changes
+	}
+	if av, bv := a.GetValue(), b.GetValue(); av != bv {
+		changes = append(changes, diff.Change{Path: path + "/value", Kind: diff.Modified, Old: av, New: bv})
+	}
+	if av, bv := a.GetFlag(), b.GetFlag(); av != bv {
+		changes = append(changes, diff.Change{Path: path + "/flag", Kind: diff.Modified, Old: av, New: bv})
+	}
+	return changes
+}
+
+// DiffUpper returns the changes from a to b.
+// Paths use the key/value layout, for example /lower_kv/test/value.
+func DiffUpper(a, b IUpper) []diff.Change {
+	return appendDiffUpper(nil, "", a, b)
+}
+
+// appendDiffUpper appends the changes from a to b below the path.
+func appendDiffUpper(changes []diff.Change, path string, a, b IUpper) []diff.Change {
+	if a == nil || b == nil {
+		if a != nil {
+			changes = append(changes, diff.Change{Path: path, Kind: diff.Removed, Old: a})
+		} else if b != nil {
+			changes = append(changes, diff.Change{Path: path, Kind: diff.Added, New: b})
+		}
+		return changes
+	}
+	if av, bv := a.GetId(), b.GetId(); av != bv {
+		changes = append(changes, diff.Change{Path: path + "/id", Kind: diff.Modified, Old: av, New: bv})
+	}
+	changes = appendDiffLower(changes, path+"/lower", a.GetLowerInter(), b.GetLowerInter())
+	changes = appendDiffStringLowerMap(changes, path+"/lower_kv", a.GetLowerKvInter(), b.GetLowerKvInter())
+	if av, bv := a.GetScore(), b.GetScore(); !(av == bv || math.IsNaN(av) && math.IsNaN(bv)) {
+		changes = append(changes, diff.Change{Path: path + "/score", Kind: diff.Modified, Old: av, New: bv})
+	}
+	if av, bv := a.GetData(), b.GetData(); !bytes.Equal(av, bv) {
+		changes = append(changes, diff.Change{Path: path + "/data", Kind: diff.Modified, Old: av, New: bv})
+	}
+	changes = appendDiffStringInt64Map(changes, path+"/counts", a.GetCountsInter(), b.GetCountsInter())
+	if av, bv := a.GetBig(), b.GetBig(); av != bv {
+		changes = append(changes, diff.Change{Path: path + "/big", Kind: diff.Modified, Old: av, New: bv})
+	}
+	return changes
+}
@@ -0,0 +1,95 @@
+package rich
+
+import (
+	"bytes"
+	"math"
+)
+
+// EqualStringInt64Map checks if a and b have the same entries.
+// A nil map is equal to an empty map.
+func EqualStringInt64Map(a, b IStringInt64Map) bool {
+	entries := make(map[string]int64)
+	if b != nil {
+		b.ForEach(func(key string, val int64) bool {
+			entries[key] = val
+			return true
+		})
+	}
+	if a == nil {
+		return len(entries) == 0
+	}
+	count := 0
+	equal := a.ForEach(func(key string, val int64) bool {
+		count++
+		other, ok := entries[key]
+		return ok && val == other
+	})
+	return equal && count == len(entries)
+}
+
+// EqualStringLowerMap checks if a and b have the same entries.
+// A nil map is equal to an empty map.
+func EqualStringLowerMap(a, b IStringLowerMap) bool {
+	entries := make(map[string]ILower)
+	if b != nil {
+		b.ForEach(func(key string, val ILower) bool {
+			entries[key] = val
+			return true
+		})
+	}
+	if a == nil {
+		return len(entries) == 0
+	}
+	count := 0
+	equal := a.ForEach(func(key string, val ILower) bool {
+		count++
+		other, ok := entries[key]
+		return ok && EqualLower(val, other)
+	})
+	return equal && count == len(entries)
+}
+
+// EqualLower checks if the fields of a and b are deeply equal.
+// Two nil values are equal, a nil value is not equal to an empty value.
+func EqualLower(a, b ILower) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if av, bv := a.GetValue(), b.GetValue(); av != bv {
+		return false
+	}
+	if av, bv := a.GetFlag(), b.GetFlag(); av != bv {
+		return false
+	}
+	return true
+}
+
+// EqualUpper checks if the fields of a and b are deeply equal.
+// Two nil values are equal, a nil value is not equal to an empty value.
+func EqualUpper(a, b IUpper) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if av, bv := a.GetId(), b.GetId(); av != bv {
+		return false
+	}
+	if !EqualLower(a.GetLowerInter(), b.GetLowerInter()) {
+		return false
+	}
+	if !EqualStringLowerMap(a.GetLowerKvInter(), b.GetLowerKvInter()) {
+		return false
+	}
+	if av, bv := a.GetScore(), b.GetScore(); !(av == bv || math.IsNaN(av) && math.IsNaN(bv)) {
+		return false
+	}
+	if av, bv := a.GetData(), b.GetData(); !bytes.Equal(av, bv) {
+		return false
+	}
+	if !EqualStringInt64Map(a.GetCountsInter(), b.GetCountsInter()) {
+		return false
+	}
+	if av, bv := a.GetBig(), b.GetBig(); av != bv {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,901 @@
+package rich
+
+import (
+	"context"
+)
+
+// IStringInt64Map is the map type for map<string, int64>
+type IStringInt64Map interface {
+	IStringInt64MapReader
+	IStringInt64MapWriter
+}
+
+// IStringInt64MapReader is the read-only part of IStringInt64Map.
+type IStringInt64MapReader interface {
+	Get(key string) int64
+	ForEach(cb func(key string, val int64) bool) bool
+}
+
+// IStringInt64MapWriter is the mutating part of IStringInt64Map.
+type IStringInt64MapWriter interface {
+	Set(key string, val int64)
+}
+
+// StringInt64Map satisfies IStringInt64Map.
+type StringInt64Map map[string]int64
+
+// Get returns a value from the map.
+func (m StringInt64Map) Get(key string) int64 {
+	return m[key]
+}
+
+// Set sets a value in the map.
+func (m StringInt64Map) Set(key string, value int64) {
+	m[key] = value
+}
+
+// ForEach iterates over the map.
+func (m StringInt64Map) ForEach(cb func(key string, val int64) bool) bool {
+	for k, v := range m {
+		if !cb(k, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IStringLowerMap is the map type for map<string, ILower>
+type IStringLowerMap interface {
+	IStringLowerMapReader
+	IStringLowerMapWriter
+	Get(key string) ILower
+	ForEach(cb func(key string, val ILower) bool) bool
+}
+
+// IStringLowerMapReader is the read-only part of IStringLowerMap.
+type IStringLowerMapReader interface {
+	GetReader(key string) ILowerReader
+	ForEachReader(cb func(key string, val ILowerReader) bool) bool
+}
+
+// IStringLowerMapWriter is the mutating part of IStringLowerMap.
+type IStringLowerMapWriter interface {
+	Set(key string, val ILower)
+}
+
+// StringLowerMap satisfies IStringLowerMap.
+type StringLowerMap map[string]*Lower
+
+// Get returns a value from the map.
+func (m StringLowerMap) Get(key string) ILower {
+	v := m[key]
+	if v == nil {
+		return nil
+	}
+	return v
+}
+
+// Set sets a value in the map.
+func (m StringLowerMap) Set(key string, value ILower) {
+	if value == nil {
+		m[key] = nil
+		return
+	}
+	m[key] = value.(*Lower)
+}
+
+// ForEach iterates over the map.
+func (m StringLowerMap) ForEach(cb func(key string, val ILower) bool) bool {
+	for k, v := range m {
+		if !cb(k, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (m StringLowerMap) GetReader(key string) ILowerReader {
+	return m.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (m StringLowerMap) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return m.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// ILower is the interface type for Lower.
+// Lower is the lower message.
+type ILower interface {
+	ILowerReader
+	ILowerWriter
+}
+
+// ILowerReader is the read-only part of ILower.
+type ILowerReader interface {
+	GetValue() string
+	GetFlag() bool
+}
+
+// ILowerWriter is the mutating part of ILower.
+type ILowerWriter interface {
+	SetValue(val string)
+	SetFlag(val bool)
+}
+
+func (m *Lower) ToILower() ILower {
+	return (ILower)(m)
+}
+
+func (m *Lower) SetValue(val string) {
+	m.Value = val
+}
+
+func (m *Lower) SetFlag(val bool) {
+	m.Flag = val
+}
+
+// _ is a type assertion
+var _ ILower = &Lower{}
+
+// IUpper is the interface type for Upper.
+// Upper is the upper message.
+type IUpper interface {
+	IUpperReader
+	IUpperWriter
+	GetLowerInter() ILower
+	GetLowerKvInter() IStringLowerMap
+	GetCountsInter() IStringInt64Map
+}
+
+// IUpperReader is the read-only part of IUpper.
+type IUpperReader interface {
+	GetId() string
+	GetLowerReader() ILowerReader
+	GetLowerKvReader() IStringLowerMapReader
+	GetScore() float64
+	GetData() []byte
+	GetCountsReader() IStringInt64MapReader
+	GetBig() uint64
+}
+
+// IUpperWriter is the mutating part of IUpper.
+type IUpperWriter interface {
+	SetId(val string)
+	SetLower(val ILower)
+	NewLower() ILower
+	SetLowerKv(val IStringLowerMap)
+	NewLowerKv() IStringLowerMap
+	SetScore(val float64)
+	SetData(val []byte)
+	SetCounts(val IStringInt64Map)
+	NewCounts() IStringInt64Map
+	SetBig(val uint64)
+}
+
+func (m *Upper) ToIUpper() IUpper {
+	return (IUpper)(m)
+}
+
+func (m *Upper) SetId(val string) {
+	m.Id = val
+}
+
+func (m *Upper) NewLower() ILower {
+	return &Lower{}
+}
+
+func (m *Upper) GetLowerInter() ILower {
+	v := m.GetLower()
+	if v == nil {
+		return nil
+	}
+	return v
+}
+
+func (m *Upper) SetLower(val ILower) {
+	if val == nil {
+		m.Lower = nil
+		return
+	}
+	m.Lower = val.(*Lower)
+}
+
+func (m *Upper) NewLowerKv() IStringLowerMap {
+	return make(StringLowerMap)
+}
+
+func (m *Upper) GetLowerKvInter() IStringLowerMap {
+	return StringLowerMap(m.GetLowerKv())
+}
+
+func (m *Upper) SetLowerKv(val IStringLowerMap) {
+	if val == nil {
+		m.LowerKv = nil
+		return
+	}
+	m.LowerKv = (map[string]*Lower)(val.(StringLowerMap))
+}
+
+func (m *Upper) SetScore(val float64) {
+	m.Score = val
+}
+
+func (m *Upper) SetData(val []byte) {
+	m.Data = val
+}
+
+func (m *Upper) NewCounts() IStringInt64Map {
+	return make(StringInt64Map)
+}
+
+func (m *Upper) GetCountsInter() IStringInt64Map {
+	return StringInt64Map(m.GetCounts())
+}
+
+func (m *Upper) SetCounts(val IStringInt64Map) {
+	if val == nil {
+		m.Counts = nil
+		return
+	}
+	m.Counts = (map[string]int64)(val.(StringInt64Map))
+}
+
+func (m *Upper) SetBig(val uint64) {
+	m.Big = val
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (m *Upper) GetLowerReader() ILowerReader {
+	return m.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (m *Upper) GetLowerKvReader() IStringLowerMapReader {
+	return m.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (m *Upper) GetCountsReader() IStringInt64MapReader {
+	return m.GetCountsInter()
+}
+
+// _ is a type assertion
+var _ IUpper = &Upper{}
+
+// IStringInt64MapCtx is the context aware map type for map<string, int64>
+type IStringInt64MapCtx interface {
+	Get(ctx context.Context, key string) (int64, error)
+	Set(ctx context.Context, key string, val int64) error
+	ForEach(ctx context.Context, cb func(key string, val int64) bool) (bool, error)
+}
+
+// stringInt64MapCtx lifts an IStringInt64Map into an IStringInt64MapCtx.
+type stringInt64MapCtx struct {
+	v IStringInt64Map
+}
+
+// ToIStringInt64MapCtx lifts an IStringInt64Map into an IStringInt64MapCtx.
+// The calls return the context error if the context is done, and the
+// error recorded by v if it has an Err method.
+func ToIStringInt64MapCtx(v IStringInt64Map) IStringInt64MapCtx {
+	if v == nil {
+		return nil
+	}
+	return &stringInt64MapCtx{v: v}
+}
+
+// Get returns a value from the map.
+func (a *stringInt64MapCtx) Get(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		var val int64
+		return val, err
+	}
+	val := a.v.Get(key)
+	return val, a.backendErr()
+}
+
+// Set sets a value in the map.
+func (a *stringInt64MapCtx) Set(ctx context.Context, key string, val int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.Set(key, val)
+	return a.backendErr()
+}
+
+// ForEach iterates over the map.
+func (a *stringInt64MapCtx) ForEach(ctx context.Context, cb func(key string, val int64) bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	var err error
+	done := a.v.ForEach(func(key string, val int64) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		return cb(key, val)
+	})
+	if err == nil {
+		err = a.backendErr()
+	}
+	return done, err
+}
+
+// backendErr returns the error recorded by the wrapped value, if any.
+func (a *stringInt64MapCtx) backendErr() error {
+	if e, ok := a.v.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// fromIStringInt64MapCtx returns the IStringInt64Map lifted into val, or a copy of val.
+func fromIStringInt64MapCtx(ctx context.Context, val IStringInt64MapCtx) (IStringInt64Map, error) {
+	if val == nil {
+		return nil, nil
+	}
+	if a, ok := val.(*stringInt64MapCtx); ok {
+		return a.v, nil
+	}
+	v, err := copyIStringInt64MapCtx(ctx, val)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// copyIStringInt64MapCtx copies the entries of val into a new StringInt64Map.
+func copyIStringInt64MapCtx(ctx context.Context, val IStringInt64MapCtx) (StringInt64Map, error) {
+	m := make(StringInt64Map)
+	_, err := val.ForEach(ctx, func(key string, v int64) bool {
+		m[key] = v
+		return true
+	})
+	return m, err
+}
+
+// _ is a type assertion
+var _ IStringInt64MapCtx = ((*stringInt64MapCtx)(nil))
+
+// IStringLowerMapCtx is the context aware map type for map<string, ILower>
+type IStringLowerMapCtx interface {
+	Get(ctx context.Context, key string) (ILowerCtx, error)
+	Set(ctx context.Context, key string, val ILowerCtx) error
+	ForEach(ctx context.Context, cb func(key string, val ILowerCtx) bool) (bool, error)
+}
+
+// stringLowerMapCtx lifts an IStringLowerMap into an IStringLowerMapCtx.
+type stringLowerMapCtx struct {
+	v IStringLowerMap
+}
+
+// ToIStringLowerMapCtx lifts an IStringLowerMap into an IStringLowerMapCtx.
+// The calls return the context error if the context is done, and the
+// error recorded by v if it has an Err method.
+func ToIStringLowerMapCtx(v IStringLowerMap) IStringLowerMapCtx {
+	if v == nil {
+		return nil
+	}
+	return &stringLowerMapCtx{v: v}
+}
+
+// Get returns a value from the map.
+func (a *stringLowerMapCtx) Get(ctx context.Context, key string) (ILowerCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.Get(key)
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToILowerCtx(val), nil
+}
+
+// Set sets a value in the map.
+func (a *stringLowerMapCtx) Set(ctx context.Context, key string, val ILowerCtx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v, err := fromILowerCtx(ctx, val)
+	if err != nil {
+		return err
+	}
+	a.v.Set(key, v)
+	return a.backendErr()
+}
+
+// ForEach iterates over the map.
+func (a *stringLowerMapCtx) ForEach(ctx context.Context, cb func(key string, val ILowerCtx) bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	var err error
+	done := a.v.ForEach(func(key string, val ILower) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		return cb(key, ToILowerCtx(val))
+	})
+	if err == nil {
+		err = a.backendErr()
+	}
+	return done, err
+}
+
+// backendErr returns the error recorded by the wrapped value, if any.
+func (a *stringLowerMapCtx) backendErr() error {
+	if e, ok := a.v.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// fromIStringLowerMapCtx returns the IStringLowerMap lifted into val, or a copy of val.
+func fromIStringLowerMapCtx(ctx context.Context, val IStringLowerMapCtx) (IStringLowerMap, error) {
+	if val == nil {
+		return nil, nil
+	}
+	if a, ok := val.(*stringLowerMapCtx); ok {
+		return a.v, nil
+	}
+	v, err := copyIStringLowerMapCtx(ctx, val)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// copyIStringLowerMapCtx copies the entries of val into a new StringLowerMap.
+func copyIStringLowerMapCtx(ctx context.Context, val IStringLowerMapCtx) (StringLowerMap, error) {
+	m := make(StringLowerMap)
+	var cerr error
+	_, err := val.ForEach(ctx, func(key string, v ILowerCtx) bool {
+		if v == nil {
+			m[key] = nil
+			return true
+		}
+		m[key], cerr = copyILowerCtx(ctx, v)
+		return cerr == nil
+	})
+	if err == nil {
+		err = cerr
+	}
+	return m, err
+}
+
+// _ is a type assertion
+var _ IStringLowerMapCtx = ((*stringLowerMapCtx)(nil))
+
+// ILowerCtx is the context aware interface type for Lower.
+type ILowerCtx interface {
+	GetValue(ctx context.Context) (string, error)
+	SetValue(ctx context.Context, val string) error
+	GetFlag(ctx context.Context) (bool, error)
+	SetFlag(ctx context.Context, val bool) error
+}
+
+// lowerCtx lifts an ILower into an ILowerCtx.
+type lowerCtx struct {
+	v ILower
+}
+
+// ToILowerCtx lifts an ILower into an ILowerCtx.
+// The calls return the context error if the context is done, and the
+// error recorded by v if it has an Err method.
+func ToILowerCtx(v ILower) ILowerCtx {
+	if v == nil {
+		return nil
+	}
+	return &lowerCtx{v: v}
+}
+
+// GetValue returns value.
+func (a *lowerCtx) GetValue(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		var val string
+		return val, err
+	}
+	val := a.v.GetValue()
+	return val, a.backendErr()
+}
+
+// SetValue sets value.
+func (a *lowerCtx) SetValue(ctx context.Context, val string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetValue(val)
+	return a.backendErr()
+}
+
+// GetFlag returns flag.
+func (a *lowerCtx) GetFlag(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		var val bool
+		return val, err
+	}
+	val := a.v.GetFlag()
+	return val, a.backendErr()
+}
+
+// SetFlag sets flag.
+func (a *lowerCtx) SetFlag(ctx context.Context, val bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetFlag(val)
+	return a.backendErr()
+}
+
+// backendErr returns the error recorded by the wrapped value, if any.
+func (a *lowerCtx) backendErr() error {
+	if e, ok := a.v.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// fromILowerCtx returns the ILower lifted into val, or a copy of val.
+func fromILowerCtx(ctx context.Context, val ILowerCtx) (ILower, error) {
+	if val == nil {
+		return nil, nil
+	}
+	if a, ok := val.(*lowerCtx); ok {
+		return a.v, nil
+	}
+	v, err := copyILowerCtx(ctx, val)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// copyILowerCtx copies the fields of val into a new Lower.
+func copyILowerCtx(ctx context.Context, val ILowerCtx) (*Lower, error) {
+	m := &Lower{}
+	{
+		v, err := val.GetValue(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetValue(v)
+	}
+	{
+		v, err := val.GetFlag(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetFlag(v)
+	}
+	return m, nil
+}
+
+// _ is a type assertion
+var _ ILowerCtx = ((*lowerCtx)(nil))
+
+// IUpperCtx is the context aware interface type for Upper.
+type IUpperCtx interface {
+	GetId(ctx context.Context) (string, error)
+	SetId(ctx context.Context, val string) error
+	GetLowerInter(ctx context.Context) (ILowerCtx, error)
+	SetLower(ctx context.Context, val ILowerCtx) error
+	NewLower(ctx context.Context) (ILowerCtx, error)
+	GetLowerKvInter(ctx context.Context) (IStringLowerMapCtx, error)
+	SetLowerKv(ctx context.Context, val IStringLowerMapCtx) error
+	NewLowerKv(ctx context.Context) (IStringLowerMapCtx, error)
+	GetScore(ctx context.Context) (float64, error)
+	SetScore(ctx context.Context, val float64) error
+	GetData(ctx context.Context) ([]byte, error)
+	SetData(ctx context.Context, val []byte) error
+	GetCountsInter(ctx context.Context) (IStringInt64MapCtx, error)
+	SetCounts(ctx context.Context, val IStringInt64MapCtx) error
+	NewCounts(ctx context.Context) (IStringInt64MapCtx, error)
+	GetBig(ctx context.Context) (uint64, error)
+	SetBig(ctx context.Context, val uint64) error
+}
+
+// upperCtx lifts an IUpper into an IUpperCtx.
+type upperCtx struct {
+	v IUpper
+}
+
+// ToIUpperCtx lifts an IUpper into an IUpperCtx.
+// The calls return the context error if the context is done, and the
+// error recorded by v if it has an Err method.
+func ToIUpperCtx(v IUpper) IUpperCtx {
+	if v == nil {
+		return nil
+	}
+	return &upperCtx{v: v}
+}
+
+// GetId returns id.
+func (a *upperCtx) GetId(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		var val string
+		return val, err
+	}
+	val := a.v.GetId()
+	return val, a.backendErr()
+}
+
+// SetId sets id.
+func (a *upperCtx) SetId(ctx context.Context, val string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetId(val)
+	return a.backendErr()
+}
+
+// GetLowerInter returns lower.
+func (a *upperCtx) GetLowerInter(ctx context.Context) (ILowerCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.GetLowerInter()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToILowerCtx(val), nil
+}
+
+// SetLower sets lower.
+func (a *upperCtx) SetLower(ctx context.Context, val ILowerCtx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v, err := fromILowerCtx(ctx, val)
+	if err != nil {
+		return err
+	}
+	a.v.SetLower(v)
+	return a.backendErr()
+}
+
+// NewLower builds a new lower.
+func (a *upperCtx) NewLower(ctx context.Context) (ILowerCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.NewLower()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToILowerCtx(val), nil
+}
+
+// GetLowerKvInter returns lower_kv.
+func (a *upperCtx) GetLowerKvInter(ctx context.Context) (IStringLowerMapCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.GetLowerKvInter()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToIStringLowerMapCtx(val), nil
+}
+
+// SetLowerKv sets lower_kv.
+func (a *upperCtx) SetLowerKv(ctx context.Context, val IStringLowerMapCtx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v, err := fromIStringLowerMapCtx(ctx, val)
+	if err != nil {
+		return err
+	}
+	a.v.SetLowerKv(v)
+	return a.backendErr()
+}
+
+// NewLowerKv builds a new lower_kv.
+func (a *upperCtx) NewLowerKv(ctx context.Context) (IStringLowerMapCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.NewLowerKv()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToIStringLowerMapCtx(val), nil
+}
+
+// GetScore returns score.
+func (a *upperCtx) GetScore(ctx context.Context) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		var val float64
+		return val, err
+	}
+	val := a.v.GetScore()
+	return val, a.backendErr()
+}
+
+// SetScore sets score.
+func (a *upperCtx) SetScore(ctx context.Context, val float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetScore(val)
+	return a.backendErr()
+}
+
+// GetData returns data.
+func (a *upperCtx) GetData(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		var val []byte
+		return val, err
+	}
+	val := a.v.GetData()
+	return val, a.backendErr()
+}
+
+// SetData sets data.
+func (a *upperCtx) SetData(ctx context.Context, val []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetData(val)
+	return a.backendErr()
+}
+
+// GetCountsInter returns counts.
+func (a *upperCtx) GetCountsInter(ctx context.Context) (IStringInt64MapCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.GetCountsInter()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToIStringInt64MapCtx(val), nil
+}
+
+// SetCounts sets counts.
+func (a *upperCtx) SetCounts(ctx context.Context, val IStringInt64MapCtx) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v, err := fromIStringInt64MapCtx(ctx, val)
+	if err != nil {
+		return err
+	}
+	a.v.SetCounts(v)
+	return a.backendErr()
+}
+
+// NewCounts builds a new counts.
+func (a *upperCtx) NewCounts(ctx context.Context) (IStringInt64MapCtx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val := a.v.NewCounts()
+	if err := a.backendErr(); err != nil {
+		return nil, err
+	}
+	return ToIStringInt64MapCtx(val), nil
+}
+
+// GetBig returns big.
+func (a *upperCtx) GetBig(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		var val uint64
+		return val, err
+	}
+	val := a.v.GetBig()
+	return val, a.backendErr()
+}
+
+// SetBig sets big.
+func (a *upperCtx) SetBig(ctx context.Context, val uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.v.SetBig(val)
+	return a.backendErr()
+}
+
+// backendErr returns the error recorded by the wrapped value, if any.
+func (a *upperCtx) backendErr() error {
+	if e, ok := a.v.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// fromIUpperCtx returns the IUpper lifted into val, or a copy of val.
+func fromIUpperCtx(ctx context.Context, val IUpperCtx) (IUpper, error) {
+	if val == nil {
+		return nil, nil
+	}
+	if a, ok := val.(*upperCtx); ok {
+		return a.v, nil
+	}
+	v, err := copyIUpperCtx(ctx, val)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// copyIUpperCtx copies the fields of val into a new Upper.
+func copyIUpperCtx(ctx context.Context, val IUpperCtx) (*Upper, error) {
+	m := &Upper{}
+	{
+		v, err := val.GetId(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetId(v)
+	}
+	{
+		v, err := val.GetLowerInter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			c, err := copyILowerCtx(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+			m.SetLower(c)
+		}
+	}
+	{
+		v, err := val.GetLowerKvInter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			c, err := copyIStringLowerMapCtx(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+			m.SetLowerKv(c)
+		}
+	}
+	{
+		v, err := val.GetScore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetScore(v)
+	}
+	{
+		v, err := val.GetData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetData(v)
+	}
+	{
+		v, err := val.GetCountsInter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			c, err := copyIStringInt64MapCtx(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+			m.SetCounts(c)
+		}
+	}
+	{
+		v, err := val.GetBig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.SetBig(v)
+	}
+	return m, nil
+}
+
+// _ is a type assertion
+var _ IUpperCtx = ((*upperCtx)(nil))
@@ -0,0 +1,594 @@
+package rich
+
+import (
+	"net/url"
+
+	"github.com/paralin/protods/journal"
+)
+
+// StringInt64MapJournal wraps an IStringInt64Map, recording the mutations to a journal.
+type StringInt64MapJournal struct {
+	v      IStringInt64Map
+	j      *journal.Journal
+	prefix string
+}
+
+// NewStringInt64MapJournal wraps the map at the key path prefix.
+func NewStringInt64MapJournal(v IStringInt64Map, j *journal.Journal, prefix string) *StringInt64MapJournal {
+	return &StringInt64MapJournal{v: v, j: j, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapJournal)(nil))
+
+// Get returns a value from the map.
+func (s *StringInt64MapJournal) Get(key string) int64 {
+	return s.v.Get(key)
+}
+
+// Set sets a value in the map.
+func (s *StringInt64MapJournal) Set(key string, val int64) {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	s.j.Set(keyPath, val)
+	s.v.Set(key, val)
+}
+
+// ForEach iterates over the map.
+func (s *StringInt64MapJournal) ForEach(cb func(key string, val int64) bool) bool {
+	return s.v.ForEach(cb)
+}
+
+// unwrapStringInt64Map returns the map wrapped by val, or val.
+func unwrapStringInt64Map(val IStringInt64Map) IStringInt64Map {
+	if w, ok := val.(*StringInt64MapJournal); ok {
+		return w.v
+	}
+	return val
+}
+
+// recordStringInt64Map records setting the map at the key path to the entries of val.
+func recordStringInt64Map(j *journal.Journal, keyPath string, val IStringInt64Map) {
+	j.Object(keyPath)
+	val.ForEach(func(key string, v int64) bool {
+		j.Set(keyPath+"/"+url.PathEscape(key), v)
+		return true
+	})
+}
+
+// applyStringInt64Map applies a journal entry below the map.
+func applyStringInt64Map(m IStringInt64Map, segs []string, e *journal.Entry) error {
+	key, err := url.PathUnescape(segs[0])
+	if err != nil {
+		return journal.InvalidEntry(e)
+	}
+	if len(segs) != 1 || e.Op != journal.OpSet {
+		return journal.InvalidEntry(e)
+	}
+	dv, err := e.DecodeValue()
+	if err != nil {
+		return err
+	}
+	val, ok := dv.(int64)
+	if !ok {
+		return journal.InvalidEntry(e)
+	}
+	m.Set(key, val)
+	return nil
+}
+
+// StringLowerMapJournal wraps an IStringLowerMap, recording the mutations to a journal.
+type StringLowerMapJournal struct {
+	v      IStringLowerMap
+	j      *journal.Journal
+	prefix string
+}
+
+// NewStringLowerMapJournal wraps the map at the key path prefix.
+func NewStringLowerMapJournal(v IStringLowerMap, j *journal.Journal, prefix string) *StringLowerMapJournal {
+	return &StringLowerMapJournal{v: v, j: j, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapJournal)(nil))
+
+// Get returns a value from the map.
+func (s *StringLowerMapJournal) Get(key string) ILower {
+	v := s.v.Get(key)
+	if v == nil {
+		return nil
+	}
+	return NewLowerJournal(v, s.j, s.prefix+"/"+url.PathEscape(key))
+}
+
+// Set sets a value in the map.
+func (s *StringLowerMapJournal) Set(key string, val ILower) {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	if val == nil {
+		s.j.Delete(keyPath)
+		s.v.Set(key, nil)
+		return
+	}
+	recordLower(s.j, keyPath, val)
+	s.v.Set(key, unwrapLower(val))
+}
+
+// ForEach iterates over the map.
+func (s *StringLowerMapJournal) ForEach(cb func(key string, val ILower) bool) bool {
+	return s.v.ForEach(func(key string, val ILower) bool {
+		if val != nil {
+			val = NewLowerJournal(val, s.j, s.prefix+"/"+url.PathEscape(key))
+		}
+		return cb(key, val)
+	})
+}
+
+// unwrapStringLowerMap returns the map wrapped by val, or val.
+func unwrapStringLowerMap(val IStringLowerMap) IStringLowerMap {
+	if w, ok := val.(*StringLowerMapJournal); ok {
+		return w.v
+	}
+	return val
+}
+
+// recordStringLowerMap records setting the map at the key path to the entries of val.
+func recordStringLowerMap(j *journal.Journal, keyPath string, val IStringLowerMap) {
+	j.Object(keyPath)
+	val.ForEach(func(key string, v ILower) bool {
+		if v == nil {
+			j.Delete(keyPath + "/" + url.PathEscape(key))
+		} else {
+			recordLower(j, keyPath+"/"+url.PathEscape(key), v)
+		}
+		return true
+	})
+}
+
+// applyStringLowerMap applies a journal entry below the map.
+func applyStringLowerMap(m IStringLowerMap, segs []string, e *journal.Entry) error {
+	key, err := url.PathUnescape(segs[0])
+	if err != nil {
+		return journal.InvalidEntry(e)
+	}
+	if len(segs) == 1 {
+		switch e.Op {
+		case journal.OpObject:
+			m.Set(key, &Lower{})
+			return nil
+		case journal.OpDelete:
+			m.Set(key, nil)
+			return nil
+		}
+		return journal.InvalidEntry(e)
+	}
+	v := m.Get(key)
+	if v == nil {
+		return journal.InvalidEntry(e)
+	}
+	return applyLower(v, segs[1:], e)
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapJournal) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapJournal) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerJournal wraps an ILower, recording the mutations to a journal.
+type LowerJournal struct {
+	v      ILower
+	j      *journal.Journal
+	prefix string
+}
+
+// NewLowerJournal wraps the object at the key path prefix, empty for the root object.
+func NewLowerJournal(v ILower, j *journal.Journal, prefix string) *LowerJournal {
+	return &LowerJournal{v: v, j: j, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerJournal)(nil))
+
+// GetValue returns value.
+func (s *LowerJournal) GetValue() string {
+	return s.v.GetValue()
+}
+
+// SetValue sets value and records it.
+func (s *LowerJournal) SetValue(val string) {
+	s.j.Set(s.prefix+"/value", val)
+	s.v.SetValue(val)
+}
+
+// GetFlag returns flag.
+func (s *LowerJournal) GetFlag() bool {
+	return s.v.GetFlag()
+}
+
+// SetFlag sets flag and records it.
+func (s *LowerJournal) SetFlag(val bool) {
+	s.j.Set(s.prefix+"/flag", val)
+	s.v.SetFlag(val)
+}
+
+// unwrapLower returns the object wrapped by val, or val.
+func unwrapLower(val ILower) ILower {
+	if w, ok := val.(*LowerJournal); ok {
+		return w.v
+	}
+	return val
+}
+
+// recordLower records setting the object at the key path to the fields of val.
+func recordLower(j *journal.Journal, keyPath string, val ILower) {
+	j.Object(keyPath)
+	j.Set(keyPath+"/value", val.GetValue())
+	j.Set(keyPath+"/flag", val.GetFlag())
+}
+
+// applyLower applies a journal entry below the object.
+func applyLower(v ILower, segs []string, e *journal.Entry) error {
+	if len(segs) == 0 {
+		if e.Op != journal.OpObject {
+			return journal.InvalidEntry(e)
+		}
+		return nil
+	}
+
+	switch segs[0] {
+	case "value":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.(string)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetValue(val)
+		return nil
+	case "flag":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.(bool)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetFlag(val)
+		return nil
+	}
+	return journal.InvalidEntry(e)
+}
+
+// ReplayLower applies the operations of the journal to target, in order.
+func ReplayLower(j *journal.Journal, target ILower) error {
+	entries := j.Entries()
+	for i := range entries {
+		e := &entries[i]
+		if err := applyLower(target, journal.SplitPath(e.Path), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpperJournal wraps an IUpper, recording the mutations to a journal.
+type UpperJournal struct {
+	v      IUpper
+	j      *journal.Journal
+	prefix string
+}
+
+// NewUpperJournal wraps the object at the key path prefix, empty for the root object.
+func NewUpperJournal(v IUpper, j *journal.Journal, prefix string) *UpperJournal {
+	return &UpperJournal{v: v, j: j, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperJournal)(nil))
+
+// GetId returns id.
+func (s *UpperJournal) GetId() string {
+	return s.v.GetId()
+}
+
+// SetId sets id and records it.
+func (s *UpperJournal) SetId(val string) {
+	s.j.Set(s.prefix+"/id", val)
+	s.v.SetId(val)
+}
+
+// GetLowerInter returns lower.
+func (s *UpperJournal) GetLowerInter() ILower {
+	v := s.v.GetLowerInter()
+	if v == nil {
+		return nil
+	}
+	return NewLowerJournal(v, s.j, s.prefix+"/lower")
+}
+
+// SetLower sets lower and records it.
+func (s *UpperJournal) SetLower(val ILower) {
+	if val == nil {
+		s.j.Delete(s.prefix + "/lower")
+		s.v.SetLower(nil)
+		return
+	}
+	recordLower(s.j, s.prefix+"/lower", val)
+	s.v.SetLower(unwrapLower(val))
+}
+
+// NewLower builds a new lower, recorded when it is set.
+func (s *UpperJournal) NewLower() ILower {
+	return s.v.NewLower()
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *UpperJournal) GetLowerKvInter() IStringLowerMap {
+	v := s.v.GetLowerKvInter()
+	if v == nil {
+		return nil
+	}
+	return NewStringLowerMapJournal(v, s.j, s.prefix+"/lower_kv")
+}
+
+// SetLowerKv sets lower_kv and records it.
+func (s *UpperJournal) SetLowerKv(val IStringLowerMap) {
+	if val == nil {
+		s.j.Delete(s.prefix + "/lower_kv")
+		s.v.SetLowerKv(nil)
+		return
+	}
+	recordStringLowerMap(s.j, s.prefix+"/lower_kv", val)
+	s.v.SetLowerKv(unwrapStringLowerMap(val))
+}
+
+// NewLowerKv builds a new lower_kv, recorded when it is set.
+func (s *UpperJournal) NewLowerKv() IStringLowerMap {
+	return s.v.NewLowerKv()
+}
+
+// GetScore returns score.
+func (s *UpperJournal) GetScore() float64 {
+	return s.v.GetScore()
+}
+
+// SetScore sets score and records it.
+func (s *UpperJournal) SetScore(val float64) {
+	s.j.Set(s.prefix+"/score", val)
+	s.v.SetScore(val)
+}
+
+// GetData returns data.
+func (s *UpperJournal) GetData() []byte {
+	return s.v.GetData()
+}
+
+// SetData sets data and records it.
+func (s *UpperJournal) SetData(val []byte) {
+	s.j.Set(s.prefix+"/data", val)
+	s.v.SetData(val)
+}
+
+// GetCountsInter returns counts.
+func (s *UpperJournal) GetCountsInter() IStringInt64Map {
+	v := s.v.GetCountsInter()
+	if v == nil {
+		return nil
+	}
+	return NewStringInt64MapJournal(v, s.j, s.prefix+"/counts")
+}
+
+// SetCounts sets counts and records it.
+func (s *UpperJournal) SetCounts(val IStringInt64Map) {
+	if val == nil {
+		s.j.Delete(s.prefix + "/counts")
+		s.v.SetCounts(nil)
+		return
+	}
+	recordStringInt64Map(s.j, s.prefix+"/counts", val)
+	s.v.SetCounts(unwrapStringInt64Map(val))
+}
+
+// NewCounts builds a new counts, recorded when it is set.
+func (s *UpperJournal) NewCounts() IStringInt64Map {
+	return s.v.NewCounts()
+}
+
+// GetBig returns big.
+func (s *UpperJournal) GetBig() uint64 {
+	return s.v.GetBig()
+}
+
+// SetBig sets big and records it.
+func (s *UpperJournal) SetBig(val uint64) {
+	s.j.Set(s.prefix+"/big", val)
+	s.v.SetBig(val)
+}
+
+// unwrapUpper returns the object wrapped by val, or val.
+func unwrapUpper(val IUpper) IUpper {
+	if w, ok := val.(*UpperJournal); ok {
+		return w.v
+	}
+	return val
+}
+
+// recordUpper records setting the object at the key path to the fields of val.
+func recordUpper(j *journal.Journal, keyPath string, val IUpper) {
+	j.Object(keyPath)
+	j.Set(keyPath+"/id", val.GetId())
+	if v := val.GetLowerInter(); v != nil {
+		recordLower(j, keyPath+"/lower", v)
+	}
+	if v := val.GetLowerKvInter(); v != nil {
+		recordStringLowerMap(j, keyPath+"/lower_kv", v)
+	}
+	j.Set(keyPath+"/score", val.GetScore())
+	j.Set(keyPath+"/data", val.GetData())
+	if v := val.GetCountsInter(); v != nil {
+		recordStringInt64Map(j, keyPath+"/counts", v)
+	}
+	j.Set(keyPath+"/big", val.GetBig())
+}
+
+// applyUpper applies a journal entry below the object.
+func applyUpper(v IUpper, segs []string, e *journal.Entry) error {
+	if len(segs) == 0 {
+		if e.Op != journal.OpObject {
+			return journal.InvalidEntry(e)
+		}
+		return nil
+	}
+
+	switch segs[0] {
+	case "id":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.(string)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetId(val)
+		return nil
+	case "lower":
+		if len(segs) == 1 {
+			switch e.Op {
+			case journal.OpObject:
+				v.SetLower(v.NewLower())
+				return nil
+			case journal.OpDelete:
+				v.SetLower(nil)
+				return nil
+			}
+			return journal.InvalidEntry(e)
+		}
+		c := v.GetLowerInter()
+		if c == nil {
+			return journal.InvalidEntry(e)
+		}
+		return applyLower(c, segs[1:], e)
+	case "lower_kv":
+		if len(segs) == 1 {
+			switch e.Op {
+			case journal.OpObject:
+				v.SetLowerKv(v.NewLowerKv())
+				return nil
+			case journal.OpDelete:
+				v.SetLowerKv(nil)
+				return nil
+			}
+			return journal.InvalidEntry(e)
+		}
+		c := v.GetLowerKvInter()
+		if c == nil {
+			return journal.InvalidEntry(e)
+		}
+		return applyStringLowerMap(c, segs[1:], e)
+	case "score":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.(float64)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetScore(val)
+		return nil
+	case "data":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.([]byte)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetData(val)
+		return nil
+	case "counts":
+		if len(segs) == 1 {
+			switch e.Op {
+			case journal.OpObject:
+				v.SetCounts(v.NewCounts())
+				return nil
+			case journal.OpDelete:
+				v.SetCounts(nil)
+				return nil
+			}
+			return journal.InvalidEntry(e)
+		}
+		c := v.GetCountsInter()
+		if c == nil {
+			return journal.InvalidEntry(e)
+		}
+		return applyStringInt64Map(c, segs[1:], e)
+	case "big":
+		if len(segs) != 1 || e.Op != journal.OpSet {
+			return journal.InvalidEntry(e)
+		}
+		dv, err := e.DecodeValue()
+		if err != nil {
+			return err
+		}
+		val, ok := dv.(uint64)
+		if !ok {
+			return journal.InvalidEntry(e)
+		}
+		v.SetBig(val)
+		return nil
+	}
+	return journal.InvalidEntry(e)
+}
+
+// ReplayUpper applies the operations of the journal to target, in order.
+func ReplayUpper(j *journal.Journal, target IUpper) error {
+	entries := j.Entries()
+	for i := range entries {
+		e := &entries[i]
+		if err := applyUpper(target, journal.SplitPath(e.Path), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperJournal) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperJournal) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperJournal) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
@@ -0,0 +1,441 @@
+package rich
+
+import (
+	"net/url"
+
+	"github.com/paralin/protods/kv"
+)
+
+// StringInt64MapKV is an IStringInt64Map stored in a key/value store.
+// Values are loaded when the getters are called.
+type StringInt64MapKV struct {
+	tree   *kv.Tree
+	prefix string
+}
+
+// NewStringInt64MapKV builds a new StringInt64MapKV at the key path prefix of the tree.
+// The root object has an empty prefix.
+func NewStringInt64MapKV(tree *kv.Tree, prefix string) *StringInt64MapKV {
+	return &StringInt64MapKV{tree: tree, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapKV)(nil))
+
+// Get returns a value from the map.
+func (s *StringInt64MapKV) Get(key string) int64 {
+	_, v := s.tree.Get(s.prefix + "/" + url.PathEscape(key))
+	val, _ := v.(int64)
+	return val
+}
+
+// Set sets a value in the map.
+func (s *StringInt64MapKV) Set(key string, val int64) {
+	s.tree.Set(s.prefix+"/"+url.PathEscape(key), val)
+}
+
+// ForEach iterates over the map.
+// Requires a store implementing kv.Lister.
+func (s *StringInt64MapKV) ForEach(cb func(key string, val int64) bool) bool {
+	for _, seg := range s.tree.List(s.prefix) {
+		key, err := url.PathUnescape(seg)
+		if err != nil {
+			continue
+		}
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PrefetchKeys returns the keys of the map entries.
+func (s *StringInt64MapKV) PrefetchKeys() []string {
+	var keys []string
+	for _, seg := range s.tree.List(s.prefix) {
+		keys = append(keys, s.prefix+"/"+seg)
+	}
+	return keys
+}
+
+// PrefetchChildren returns the message values of the map.
+func (s *StringInt64MapKV) PrefetchChildren() []kv.Prefetcher {
+	return nil
+}
+
+// StringLowerMapKV is an IStringLowerMap stored in a key/value store.
+// Values are loaded when the getters are called.
+type StringLowerMapKV struct {
+	tree   *kv.Tree
+	prefix string
+}
+
+// NewStringLowerMapKV builds a new StringLowerMapKV at the key path prefix of the tree.
+// The root object has an empty prefix.
+func NewStringLowerMapKV(tree *kv.Tree, prefix string) *StringLowerMapKV {
+	return &StringLowerMapKV{tree: tree, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapKV)(nil))
+
+// Get returns a value from the map.
+func (s *StringLowerMapKV) Get(key string) ILower {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	if found, _ := s.tree.Get(keyPath); !found {
+		return nil
+	}
+	return NewLowerKV(s.tree, keyPath)
+}
+
+// Set sets a value in the map.
+func (s *StringLowerMapKV) Set(key string, val ILower) {
+	keyPath := s.prefix + "/" + url.PathEscape(key)
+	if val == nil {
+		s.tree.DeleteTree(keyPath)
+		return
+	}
+	if v, ok := val.(*LowerKV); ok && v.tree == s.tree && v.prefix == keyPath {
+		return
+	}
+
+	s.tree.Set(keyPath, kv.Object{})
+	NewLowerKV(s.tree, keyPath).copyFrom(val)
+}
+
+// ForEach iterates over the map.
+// Requires a store implementing kv.Lister.
+func (s *StringLowerMapKV) ForEach(cb func(key string, val ILower) bool) bool {
+	for _, seg := range s.tree.List(s.prefix) {
+		key, err := url.PathUnescape(seg)
+		if err != nil {
+			continue
+		}
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PrefetchKeys returns the keys of the map entries.
+func (s *StringLowerMapKV) PrefetchKeys() []string {
+	var keys []string
+	for _, seg := range s.tree.List(s.prefix) {
+		keys = append(keys, s.prefix+"/"+seg)
+	}
+	return keys
+}
+
+// PrefetchChildren returns the message values of the map.
+func (s *StringLowerMapKV) PrefetchChildren() []kv.Prefetcher {
+	var children []kv.Prefetcher
+	for _, seg := range s.tree.List(s.prefix) {
+		keyPath := s.prefix + "/" + seg
+		if found, _ := s.tree.Get(keyPath); found {
+			children = append(children, NewLowerKV(s.tree, keyPath))
+		}
+	}
+	return children
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapKV) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapKV) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerKV is an ILower stored in a key/value store.
+// Values are loaded when the getters are called.
+type LowerKV struct {
+	tree   *kv.Tree
+	prefix string
+}
+
+// NewLowerKV builds a new LowerKV at the key path prefix of the tree.
+// The root object has an empty prefix.
+func NewLowerKV(tree *kv.Tree, prefix string) *LowerKV {
+	return &LowerKV{tree: tree, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerKV)(nil))
+
+// Prefetch loads the fields of the object and the objects below it,
+// with one batch per level of the tree.
+func (s *LowerKV) Prefetch() {
+	s.tree.Prefetch(s)
+}
+
+// PrefetchKeys returns the keys of the fields of the object.
+func (s *LowerKV) PrefetchKeys() []string {
+	return []string{
+		s.prefix + "/value",
+		s.prefix + "/flag",
+	}
+}
+
+// PrefetchChildren returns the present message and map fields.
+func (s *LowerKV) PrefetchChildren() []kv.Prefetcher {
+	var children []kv.Prefetcher
+	return children
+}
+
+// copyFrom stores the fields of val.
+func (s *LowerKV) copyFrom(val ILower) {
+	s.SetValue(val.GetValue())
+	s.SetFlag(val.GetFlag())
+}
+
+// GetValue loads value.
+func (s *LowerKV) GetValue() string {
+	_, v := s.tree.Get(s.prefix + "/value")
+	val, _ := v.(string)
+	return val
+}
+
+// SetValue stores value.
+func (s *LowerKV) SetValue(val string) {
+	s.tree.Set(s.prefix+"/value", val)
+}
+
+// GetFlag loads flag.
+func (s *LowerKV) GetFlag() bool {
+	_, v := s.tree.Get(s.prefix + "/flag")
+	val, _ := v.(bool)
+	return val
+}
+
+// SetFlag stores flag.
+func (s *LowerKV) SetFlag(val bool) {
+	s.tree.Set(s.prefix+"/flag", val)
+}
+
+// UpperKV is an IUpper stored in a key/value store.
+// Values are loaded when the getters are called.
+type UpperKV struct {
+	tree   *kv.Tree
+	prefix string
+}
+
+// NewUpperKV builds a new UpperKV at the key path prefix of the tree.
+// The root object has an empty prefix.
+func NewUpperKV(tree *kv.Tree, prefix string) *UpperKV {
+	return &UpperKV{tree: tree, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperKV)(nil))
+
+// Prefetch loads the fields of the object and the objects below it,
+// with one batch per level of the tree.
+func (s *UpperKV) Prefetch() {
+	s.tree.Prefetch(s)
+}
+
+// PrefetchKeys returns the keys of the fields of the object.
+func (s *UpperKV) PrefetchKeys() []string {
+	return []string{
+		s.prefix + "/id",
+		s.prefix + "/lower",
+		s.prefix + "/lower_kv",
+		s.prefix + "/score",
+		s.prefix + "/data",
+		s.prefix + "/counts",
+		s.prefix + "/big",
+	}
+}
+
+// PrefetchChildren returns the present message and map fields.
+func (s *UpperKV) PrefetchChildren() []kv.Prefetcher {
+	var children []kv.Prefetcher
+	if found, _ := s.tree.Get(s.prefix + "/lower"); found {
+		children = append(children, NewLowerKV(s.tree, s.prefix+"/lower"))
+	}
+	if found, _ := s.tree.Get(s.prefix + "/lower_kv"); found {
+		children = append(children, NewStringLowerMapKV(s.tree, s.prefix+"/lower_kv"))
+	}
+	if found, _ := s.tree.Get(s.prefix + "/counts"); found {
+		children = append(children, NewStringInt64MapKV(s.tree, s.prefix+"/counts"))
+	}
+	return children
+}
+
+// copyFrom stores the fields of val.
+func (s *UpperKV) copyFrom(val IUpper) {
+	s.SetId(val.GetId())
+	s.SetLower(val.GetLowerInter())
+	s.SetLowerKv(val.GetLowerKvInter())
+	s.SetScore(val.GetScore())
+	s.SetData(val.GetData())
+	s.SetCounts(val.GetCountsInter())
+	s.SetBig(val.GetBig())
+}
+
+// GetId loads id.
+func (s *UpperKV) GetId() string {
+	_, v := s.tree.Get(s.prefix + "/id")
+	val, _ := v.(string)
+	return val
+}
+
+// SetId stores id.
+func (s *UpperKV) SetId(val string) {
+	s.tree.Set(s.prefix+"/id", val)
+}
+
+// GetLowerInter loads lower.
+func (s *UpperKV) GetLowerInter() ILower {
+	keyPath := s.prefix + "/lower"
+	if found, _ := s.tree.Get(keyPath); !found {
+		return nil
+	}
+	return NewLowerKV(s.tree, keyPath)
+}
+
+// SetLower stores lower.
+func (s *UpperKV) SetLower(val ILower) {
+	keyPath := s.prefix + "/lower"
+	if val == nil {
+		s.tree.DeleteTree(keyPath)
+		return
+	}
+	if v, ok := val.(*LowerKV); ok && v.tree == s.tree && v.prefix == keyPath {
+		return
+	}
+
+	s.tree.Set(keyPath, kv.Object{})
+	NewLowerKV(s.tree, keyPath).copyFrom(val)
+}
+
+// NewLower builds a new detached lower, stored by SetLower.
+func (s *UpperKV) NewLower() ILower {
+	return &Lower{}
+}
+
+// GetLowerKvInter loads lower_kv.
+func (s *UpperKV) GetLowerKvInter() IStringLowerMap {
+	keyPath := s.prefix + "/lower_kv"
+	if found, _ := s.tree.Get(keyPath); !found {
+		return nil
+	}
+	return NewStringLowerMapKV(s.tree, keyPath)
+}
+
+// SetLowerKv stores lower_kv.
+func (s *UpperKV) SetLowerKv(val IStringLowerMap) {
+	keyPath := s.prefix + "/lower_kv"
+	if val == nil {
+		s.tree.DeleteTree(keyPath)
+		return
+	}
+	if v, ok := val.(*StringLowerMapKV); ok && v.tree == s.tree && v.prefix == keyPath {
+		return
+	}
+
+	s.tree.DeleteTree(keyPath)
+	s.tree.Set(keyPath, kv.Object{})
+	dst := NewStringLowerMapKV(s.tree, keyPath)
+	val.ForEach(func(key string, v ILower) bool {
+		dst.Set(key, v)
+		return true
+	})
+}
+
+// NewLowerKv builds a new detached lower_kv, stored by SetLowerKv.
+func (s *UpperKV) NewLowerKv() IStringLowerMap {
+	return make(StringLowerMap)
+}
+
+// GetScore loads score.
+func (s *UpperKV) GetScore() float64 {
+	_, v := s.tree.Get(s.prefix + "/score")
+	val, _ := v.(float64)
+	return val
+}
+
+// SetScore stores score.
+func (s *UpperKV) SetScore(val float64) {
+	s.tree.Set(s.prefix+"/score", val)
+}
+
+// GetData loads data.
+func (s *UpperKV) GetData() []byte {
+	_, v := s.tree.Get(s.prefix + "/data")
+	val, _ := v.([]byte)
+	return val
+}
+
+// SetData stores data.
+func (s *UpperKV) SetData(val []byte) {
+	s.tree.Set(s.prefix+"/data", val)
+}
+
+// GetCountsInter loads counts.
+func (s *UpperKV) GetCountsInter() IStringInt64Map {
+	keyPath := s.prefix + "/counts"
+	if found, _ := s.tree.Get(keyPath); !found {
+		return nil
+	}
+	return NewStringInt64MapKV(s.tree, keyPath)
+}
+
+// SetCounts stores counts.
+func (s *UpperKV) SetCounts(val IStringInt64Map) {
+	keyPath := s.prefix + "/counts"
+	if val == nil {
+		s.tree.DeleteTree(keyPath)
+		return
+	}
+	if v, ok := val.(*StringInt64MapKV); ok && v.tree == s.tree && v.prefix == keyPath {
+		return
+	}
+
+	s.tree.DeleteTree(keyPath)
+	s.tree.Set(keyPath, kv.Object{})
+	dst := NewStringInt64MapKV(s.tree, keyPath)
+	val.ForEach(func(key string, v int64) bool {
+		dst.Set(key, v)
+		return true
+	})
+}
+
+// NewCounts builds a new detached counts, stored by SetCounts.
+func (s *UpperKV) NewCounts() IStringInt64Map {
+	return make(StringInt64Map)
+}
+
+// GetBig loads big.
+func (s *UpperKV) GetBig() uint64 {
+	_, v := s.tree.Get(s.prefix + "/big")
+	val, _ := v.(uint64)
+	return val
+}
+
+// SetBig stores big.
+func (s *UpperKV) SetBig(val uint64) {
+	s.tree.Set(s.prefix+"/big", val)
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperKV) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperKV) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperKV) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
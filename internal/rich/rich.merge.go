@@ -0,0 +1,101 @@
+package rich
+
+import (
+	"math"
+)
+
+// MergeStringInt64Map sets the entries of src in dst, replacing existing entries.
+func MergeStringInt64Map(dst, src IStringInt64Map) {
+	src.ForEach(func(key string, val int64) bool {
+		dst.Set(key, val)
+		return true
+	})
+}
+
+// isUnsetStringInt64Map checks if a map field is unset.
+// Empty proto maps are unset, like in proto.Merge.
+func isUnsetStringInt64Map(m IStringInt64Map) bool {
+	v, ok := m.(StringInt64Map)
+	return m == nil || ok && len(v) == 0
+}
+
+// MergeStringLowerMap sets the entries of src in dst, replacing existing entries.
+// Message values are set to an empty Lower and filled in through dst.Get.
+func MergeStringLowerMap(dst, src IStringLowerMap) {
+	src.ForEach(func(key string, val ILower) bool {
+		if val == nil {
+			dst.Set(key, nil)
+			return true
+		}
+		dst.Set(key, &Lower{})
+		if v := dst.Get(key); v != nil {
+			MergeLower(v, val)
+		}
+		return true
+	})
+}
+
+// isUnsetStringLowerMap checks if a map field is unset.
+// Empty proto maps are unset, like in proto.Merge.
+func isUnsetStringLowerMap(m IStringLowerMap) bool {
+	v, ok := m.(StringLowerMap)
+	return m == nil || ok && len(v) == 0
+}
+
+// MergeLower merges src into dst like proto.Merge.
+// Scalar fields set in src overwrite dst, message fields are merged
+// recursively through the getters of dst, and map entries are set by key.
+func MergeLower(dst, src ILower) {
+	if v := src.GetValue(); v != "" {
+		dst.SetValue(v)
+	}
+	if v := src.GetFlag(); v {
+		dst.SetFlag(v)
+	}
+}
+
+// MergeUpper merges src into dst like proto.Merge.
+// Scalar fields set in src overwrite dst, message fields are merged
+// recursively through the getters of dst, and map entries are set by key.
+// The repeated field tags is not part of the interfaces and is not merged.
+func MergeUpper(dst, src IUpper) {
+	if v := src.GetId(); v != "" {
+		dst.SetId(v)
+	}
+	if v := src.GetLowerInter(); v != nil {
+		if d := dst.GetLowerInter(); d != nil {
+			MergeLower(d, v)
+		} else {
+			c := dst.NewLower()
+			MergeLower(c, v)
+			dst.SetLower(c)
+		}
+	}
+	if v := src.GetLowerKvInter(); !isUnsetStringLowerMap(v) {
+		if d := dst.GetLowerKvInter(); isUnsetStringLowerMap(d) {
+			c := dst.NewLowerKv()
+			MergeStringLowerMap(c, v)
+			dst.SetLowerKv(c)
+		} else {
+			MergeStringLowerMap(d, v)
+		}
+	}
+	if v := src.GetScore(); math.Float64bits(v) != 0 {
+		dst.SetScore(v)
+	}
+	if v := src.GetData(); len(v) != 0 {
+		dst.SetData(append([]byte(nil), v...))
+	}
+	if v := src.GetCountsInter(); !isUnsetStringInt64Map(v) {
+		if d := dst.GetCountsInter(); isUnsetStringInt64Map(d) {
+			c := dst.NewCounts()
+			MergeStringInt64Map(c, v)
+			dst.SetCounts(c)
+		} else {
+			MergeStringInt64Map(d, v)
+		}
+	}
+	if v := src.GetBig(); v != 0 {
+		dst.SetBig(v)
+	}
+}
@@ -0,0 +1,92 @@
+package rich
+
+// The types below mirror the protoc-gen-go output for rich.proto without the
+// protobuf runtime, so the fixture builds without protoc.
+
+// Upper is the upper message.
+type Upper struct {
+	Id      string            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Lower   *Lower            `protobuf:"bytes,2,opt,name=lower,proto3" json:"lower,omitempty"`
+	LowerKv map[string]*Lower `protobuf:"bytes,3,rep,name=lower_kv,json=lowerKv,proto3" json:"lower_kv,omitempty"`
+	Score   float64           `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	Data    []byte            `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	Counts  map[string]int64  `protobuf:"bytes,6,rep,name=counts,proto3" json:"counts,omitempty"`
+	Tags    []string          `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Big     uint64            `protobuf:"varint,8,opt,name=big,proto3" json:"big,omitempty"`
+}
+
+func (m *Upper) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Upper) GetLower() *Lower {
+	if m != nil {
+		return m.Lower
+	}
+	return nil
+}
+
+func (m *Upper) GetLowerKv() map[string]*Lower {
+	if m != nil {
+		return m.LowerKv
+	}
+	return nil
+}
+
+func (m *Upper) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *Upper) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Upper) GetCounts() map[string]int64 {
+	if m != nil {
+		return m.Counts
+	}
+	return nil
+}
+
+func (m *Upper) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Upper) GetBig() uint64 {
+	if m != nil {
+		return m.Big
+	}
+	return 0
+}
+
+// Lower is the lower message.
+type Lower struct {
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Flag  bool   `protobuf:"varint,2,opt,name=flag,proto3" json:"flag,omitempty"`
+}
+
+func (m *Lower) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Lower) GetFlag() bool {
+	if m != nil {
+		return m.Flag
+	}
+	return false
+}
@@ -0,0 +1,282 @@
+package rich
+
+import (
+	"net/url"
+
+	"github.com/paralin/protods/readonly"
+)
+
+// ReadOnlyStringInt64Map is a read-only view of an IStringInt64Map.
+// Nested messages and maps are returned as read-only views, byte slices are
+// copied, and the setters panic with a *readonly.Error.
+type ReadOnlyStringInt64Map struct {
+	v      IStringInt64MapReader
+	prefix string
+}
+
+// NewReadOnlyStringInt64Map builds a read-only view of the map.
+func NewReadOnlyStringInt64Map(v IStringInt64MapReader) *ReadOnlyStringInt64Map {
+	return &ReadOnlyStringInt64Map{v: v}
+}
+
+// FreezeStringInt64Map returns a read-only view of the map typed as IStringInt64MapReader,
+// so the setters are not available without a type assertion.
+func FreezeStringInt64Map(v IStringInt64MapReader) IStringInt64MapReader {
+	return NewReadOnlyStringInt64Map(v)
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*ReadOnlyStringInt64Map)(nil))
+
+// Get returns a value from the map.
+func (s *ReadOnlyStringInt64Map) Get(key string) int64 {
+	return s.v.Get(key)
+}
+
+// Set panics with a *readonly.Error.
+func (s *ReadOnlyStringInt64Map) Set(key string, val int64) {
+	readonly.Panic(s.prefix + "/" + url.PathEscape(key))
+}
+
+// ForEach iterates over the map.
+func (s *ReadOnlyStringInt64Map) ForEach(cb func(key string, val int64) bool) bool {
+	return s.v.ForEach(cb)
+}
+
+// ReadOnlyStringLowerMap is a read-only view of an IStringLowerMap.
+// Nested messages and maps are returned as read-only views, byte slices are
+// copied, and the setters panic with a *readonly.Error.
+type ReadOnlyStringLowerMap struct {
+	v      IStringLowerMapReader
+	prefix string
+}
+
+// NewReadOnlyStringLowerMap builds a read-only view of the map.
+func NewReadOnlyStringLowerMap(v IStringLowerMapReader) *ReadOnlyStringLowerMap {
+	return &ReadOnlyStringLowerMap{v: v}
+}
+
+// FreezeStringLowerMap returns a read-only view of the map typed as IStringLowerMapReader,
+// so the setters are not available without a type assertion.
+func FreezeStringLowerMap(v IStringLowerMapReader) IStringLowerMapReader {
+	return NewReadOnlyStringLowerMap(v)
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*ReadOnlyStringLowerMap)(nil))
+
+// Get returns a value from the map.
+func (s *ReadOnlyStringLowerMap) Get(key string) ILower {
+	v := s.v.GetReader(key)
+	if v == nil {
+		return nil
+	}
+	return &ReadOnlyLower{v: v, prefix: s.prefix + "/" + url.PathEscape(key)}
+}
+
+// Set panics with a *readonly.Error.
+func (s *ReadOnlyStringLowerMap) Set(key string, val ILower) {
+	readonly.Panic(s.prefix + "/" + url.PathEscape(key))
+}
+
+// ForEach iterates over the map.
+func (s *ReadOnlyStringLowerMap) ForEach(cb func(key string, val ILower) bool) bool {
+	return s.v.ForEachReader(func(key string, val ILowerReader) bool {
+		var v ILower
+		if val != nil {
+			v = &ReadOnlyLower{v: val, prefix: s.prefix + "/" + url.PathEscape(key)}
+		}
+		return cb(key, v)
+	})
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *ReadOnlyStringLowerMap) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *ReadOnlyStringLowerMap) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// ReadOnlyLower is a read-only view of an ILower.
+// Nested messages and maps are returned as read-only views, byte slices are
+// copied, and the setters panic with a *readonly.Error.
+type ReadOnlyLower struct {
+	v      ILowerReader
+	prefix string
+}
+
+// NewReadOnlyLower builds a read-only view of the object.
+func NewReadOnlyLower(v ILowerReader) *ReadOnlyLower {
+	return &ReadOnlyLower{v: v}
+}
+
+// FreezeLower returns a read-only view of the object typed as ILowerReader,
+// so the setters are not available without a type assertion.
+func FreezeLower(v ILowerReader) ILowerReader {
+	return NewReadOnlyLower(v)
+}
+
+// _ is a type assertion
+var _ ILower = ((*ReadOnlyLower)(nil))
+
+// GetValue returns value.
+func (s *ReadOnlyLower) GetValue() string {
+	return s.v.GetValue()
+}
+
+// SetValue panics with a *readonly.Error.
+func (s *ReadOnlyLower) SetValue(val string) {
+	readonly.Panic(s.prefix + "/value")
+}
+
+// GetFlag returns flag.
+func (s *ReadOnlyLower) GetFlag() bool {
+	return s.v.GetFlag()
+}
+
+// SetFlag panics with a *readonly.Error.
+func (s *ReadOnlyLower) SetFlag(val bool) {
+	readonly.Panic(s.prefix + "/flag")
+}
+
+// ReadOnlyUpper is a read-only view of an IUpper.
+// Nested messages and maps are returned as read-only views, byte slices are
+// copied, and the setters panic with a *readonly.Error.
+type ReadOnlyUpper struct {
+	v      IUpperReader
+	prefix string
+}
+
+// NewReadOnlyUpper builds a read-only view of the object.
+func NewReadOnlyUpper(v IUpperReader) *ReadOnlyUpper {
+	return &ReadOnlyUpper{v: v}
+}
+
+// FreezeUpper returns a read-only view of the object typed as IUpperReader,
+// so the setters are not available without a type assertion.
+func FreezeUpper(v IUpperReader) IUpperReader {
+	return NewReadOnlyUpper(v)
+}
+
+// _ is a type assertion
+var _ IUpper = ((*ReadOnlyUpper)(nil))
+
+// GetId returns id.
+func (s *ReadOnlyUpper) GetId() string {
+	return s.v.GetId()
+}
+
+// SetId panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetId(val string) {
+	readonly.Panic(s.prefix + "/id")
+}
+
+// GetLowerInter returns lower.
+func (s *ReadOnlyUpper) GetLowerInter() ILower {
+	v := s.v.GetLowerReader()
+	if v == nil {
+		return nil
+	}
+	return &ReadOnlyLower{v: v, prefix: s.prefix + "/lower"}
+}
+
+// SetLower panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetLower(val ILower) {
+	readonly.Panic(s.prefix + "/lower")
+}
+
+// NewLower panics with a *readonly.Error.
+func (s *ReadOnlyUpper) NewLower() ILower {
+	readonly.Panic(s.prefix + "/lower")
+	return nil
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *ReadOnlyUpper) GetLowerKvInter() IStringLowerMap {
+	v := s.v.GetLowerKvReader()
+	if v == nil {
+		return nil
+	}
+	return &ReadOnlyStringLowerMap{v: v, prefix: s.prefix + "/lower_kv"}
+}
+
+// SetLowerKv panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetLowerKv(val IStringLowerMap) {
+	readonly.Panic(s.prefix + "/lower_kv")
+}
+
+// NewLowerKv panics with a *readonly.Error.
+func (s *ReadOnlyUpper) NewLowerKv() IStringLowerMap {
+	readonly.Panic(s.prefix + "/lower_kv")
+	return nil
+}
+
+// GetScore returns score.
+func (s *ReadOnlyUpper) GetScore() float64 {
+	return s.v.GetScore()
+}
+
+// SetScore panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetScore(val float64) {
+	readonly.Panic(s.prefix + "/score")
+}
+
+// GetData returns data.
+func (s *ReadOnlyUpper) GetData() []byte {
+	return append([]byte(nil), s.v.GetData()...)
+}
+
+// SetData panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetData(val []byte) {
+	readonly.Panic(s.prefix + "/data")
+}
+
+// GetCountsInter returns counts.
+func (s *ReadOnlyUpper) GetCountsInter() IStringInt64Map {
+	v := s.v.GetCountsReader()
+	if v == nil {
+		return nil
+	}
+	return &ReadOnlyStringInt64Map{v: v, prefix: s.prefix + "/counts"}
+}
+
+// SetCounts panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetCounts(val IStringInt64Map) {
+	readonly.Panic(s.prefix + "/counts")
+}
+
+// NewCounts panics with a *readonly.Error.
+func (s *ReadOnlyUpper) NewCounts() IStringInt64Map {
+	readonly.Panic(s.prefix + "/counts")
+	return nil
+}
+
+// GetBig returns big.
+func (s *ReadOnlyUpper) GetBig() uint64 {
+	return s.v.GetBig()
+}
+
+// SetBig panics with a *readonly.Error.
+func (s *ReadOnlyUpper) SetBig(val uint64) {
+	readonly.Panic(s.prefix + "/big")
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *ReadOnlyUpper) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *ReadOnlyUpper) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *ReadOnlyUpper) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
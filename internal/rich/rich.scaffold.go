@@ -0,0 +1,295 @@
+package rich
+
+import (
+	"net/url"
+)
+
+// ScaffoldHooks are the hooks the scaffold types load and store values with.
+// Values are addressed by key path, for example /lower_kv/test/value.
+type ScaffoldHooks interface {
+	// LoadField loads the value at the key path.
+	// Returns false if the value is not set.
+	LoadField(keyPath string) (interface{}, bool)
+	// StoreField stores the value at the key path.
+	// A nil value clears the key path. Message and map values are the
+	// interface types; their contents are stored below the key path.
+	StoreField(keyPath string, val interface{})
+	// ListKeys lists the path escaped keys directly below the key path.
+	ListKeys(keyPath string) []string
+}
+
+// StringInt64MapScaffold is a skeleton IStringInt64Map routed through ScaffoldHooks.
+type StringInt64MapScaffold struct {
+	// Hooks load and store the values.
+	Hooks ScaffoldHooks
+	// Prefix is the key path of the object.
+	Prefix string
+}
+
+// NewStringInt64MapScaffold builds a new StringInt64MapScaffold at the key path prefix.
+func NewStringInt64MapScaffold(hooks ScaffoldHooks, prefix string) *StringInt64MapScaffold {
+	return &StringInt64MapScaffold{Hooks: hooks, Prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapScaffold)(nil))
+
+// Get returns a value from the map.
+func (s *StringInt64MapScaffold) Get(key string) int64 {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/" + url.PathEscape(key))
+	val, _ := v.(int64)
+	return val
+}
+
+// Set sets a value in the map.
+func (s *StringInt64MapScaffold) Set(key string, val int64) {
+	s.Hooks.StoreField(s.Prefix+"/"+url.PathEscape(key), val)
+}
+
+// ForEach iterates over the map.
+func (s *StringInt64MapScaffold) ForEach(cb func(key string, val int64) bool) bool {
+	for _, k := range s.Hooks.ListKeys(s.Prefix) {
+		key, err := url.PathUnescape(k)
+		if err != nil {
+			continue
+		}
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StringLowerMapScaffold is a skeleton IStringLowerMap routed through ScaffoldHooks.
+type StringLowerMapScaffold struct {
+	// Hooks load and store the values.
+	Hooks ScaffoldHooks
+	// Prefix is the key path of the object.
+	Prefix string
+}
+
+// NewStringLowerMapScaffold builds a new StringLowerMapScaffold at the key path prefix.
+func NewStringLowerMapScaffold(hooks ScaffoldHooks, prefix string) *StringLowerMapScaffold {
+	return &StringLowerMapScaffold{Hooks: hooks, Prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapScaffold)(nil))
+
+// Get returns a value from the map.
+func (s *StringLowerMapScaffold) Get(key string) ILower {
+	keyPath := s.Prefix + "/" + url.PathEscape(key)
+	if _, ok := s.Hooks.LoadField(keyPath); !ok {
+		return nil
+	}
+	return NewLowerScaffold(s.Hooks, keyPath)
+}
+
+// Set sets a value in the map.
+func (s *StringLowerMapScaffold) Set(key string, val ILower) {
+	s.Hooks.StoreField(s.Prefix+"/"+url.PathEscape(key), val)
+}
+
+// ForEach iterates over the map.
+func (s *StringLowerMapScaffold) ForEach(cb func(key string, val ILower) bool) bool {
+	for _, k := range s.Hooks.ListKeys(s.Prefix) {
+		key, err := url.PathUnescape(k)
+		if err != nil {
+			continue
+		}
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapScaffold) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapScaffold) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerScaffold is a skeleton ILower routed through ScaffoldHooks.
+type LowerScaffold struct {
+	// Hooks load and store the values.
+	Hooks ScaffoldHooks
+	// Prefix is the key path of the object.
+	Prefix string
+}
+
+// NewLowerScaffold builds a new LowerScaffold at the key path prefix.
+func NewLowerScaffold(hooks ScaffoldHooks, prefix string) *LowerScaffold {
+	return &LowerScaffold{Hooks: hooks, Prefix: prefix}
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerScaffold)(nil))
+
+// GetValue loads value.
+func (s *LowerScaffold) GetValue() string {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/value")
+	val, _ := v.(string)
+	return val
+}
+
+// SetValue stores value.
+func (s *LowerScaffold) SetValue(val string) {
+	s.Hooks.StoreField(s.Prefix+"/value", val)
+}
+
+// GetFlag loads flag.
+func (s *LowerScaffold) GetFlag() bool {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/flag")
+	val, _ := v.(bool)
+	return val
+}
+
+// SetFlag stores flag.
+func (s *LowerScaffold) SetFlag(val bool) {
+	s.Hooks.StoreField(s.Prefix+"/flag", val)
+}
+
+// UpperScaffold is a skeleton IUpper routed through ScaffoldHooks.
+type UpperScaffold struct {
+	// Hooks load and store the values.
+	Hooks ScaffoldHooks
+	// Prefix is the key path of the object.
+	Prefix string
+}
+
+// NewUpperScaffold builds a new UpperScaffold at the key path prefix.
+func NewUpperScaffold(hooks ScaffoldHooks, prefix string) *UpperScaffold {
+	return &UpperScaffold{Hooks: hooks, Prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperScaffold)(nil))
+
+// GetId loads id.
+func (s *UpperScaffold) GetId() string {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/id")
+	val, _ := v.(string)
+	return val
+}
+
+// SetId stores id.
+func (s *UpperScaffold) SetId(val string) {
+	s.Hooks.StoreField(s.Prefix+"/id", val)
+}
+
+// GetLowerInter loads lower.
+func (s *UpperScaffold) GetLowerInter() ILower {
+	keyPath := s.Prefix + "/lower"
+	if _, ok := s.Hooks.LoadField(keyPath); !ok {
+		return nil
+	}
+	return NewLowerScaffold(s.Hooks, keyPath)
+}
+
+// SetLower stores lower.
+func (s *UpperScaffold) SetLower(val ILower) {
+	s.Hooks.StoreField(s.Prefix+"/lower", val)
+}
+
+// NewLower builds a new lower at the key path of the field.
+func (s *UpperScaffold) NewLower() ILower {
+	return NewLowerScaffold(s.Hooks, s.Prefix+"/lower")
+}
+
+// GetLowerKvInter loads lower_kv.
+func (s *UpperScaffold) GetLowerKvInter() IStringLowerMap {
+	keyPath := s.Prefix + "/lower_kv"
+	if _, ok := s.Hooks.LoadField(keyPath); !ok {
+		return nil
+	}
+	return NewStringLowerMapScaffold(s.Hooks, keyPath)
+}
+
+// SetLowerKv stores lower_kv.
+func (s *UpperScaffold) SetLowerKv(val IStringLowerMap) {
+	s.Hooks.StoreField(s.Prefix+"/lower_kv", val)
+}
+
+// NewLowerKv builds a new lower_kv at the key path of the field.
+func (s *UpperScaffold) NewLowerKv() IStringLowerMap {
+	return NewStringLowerMapScaffold(s.Hooks, s.Prefix+"/lower_kv")
+}
+
+// GetScore loads score.
+func (s *UpperScaffold) GetScore() float64 {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/score")
+	val, _ := v.(float64)
+	return val
+}
+
+// SetScore stores score.
+func (s *UpperScaffold) SetScore(val float64) {
+	s.Hooks.StoreField(s.Prefix+"/score", val)
+}
+
+// GetData loads data.
+func (s *UpperScaffold) GetData() []byte {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/data")
+	val, _ := v.([]byte)
+	return val
+}
+
+// SetData stores data.
+func (s *UpperScaffold) SetData(val []byte) {
+	s.Hooks.StoreField(s.Prefix+"/data", val)
+}
+
+// GetCountsInter loads counts.
+func (s *UpperScaffold) GetCountsInter() IStringInt64Map {
+	keyPath := s.Prefix + "/counts"
+	if _, ok := s.Hooks.LoadField(keyPath); !ok {
+		return nil
+	}
+	return NewStringInt64MapScaffold(s.Hooks, keyPath)
+}
+
+// SetCounts stores counts.
+func (s *UpperScaffold) SetCounts(val IStringInt64Map) {
+	s.Hooks.StoreField(s.Prefix+"/counts", val)
+}
+
+// NewCounts builds a new counts at the key path of the field.
+func (s *UpperScaffold) NewCounts() IStringInt64Map {
+	return NewStringInt64MapScaffold(s.Hooks, s.Prefix+"/counts")
+}
+
+// GetBig loads big.
+func (s *UpperScaffold) GetBig() uint64 {
+	v, _ := s.Hooks.LoadField(s.Prefix + "/big")
+	val, _ := v.(uint64)
+	return val
+}
+
+// SetBig stores big.
+func (s *UpperScaffold) SetBig(val uint64) {
+	s.Hooks.StoreField(s.Prefix+"/big", val)
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperScaffold) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperScaffold) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperScaffold) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "lower" (
+	"_id" INTEGER PRIMARY KEY,
+	"value" TEXT NOT NULL DEFAULT '',
+	"flag" BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS "upper" (
+	"_id" INTEGER PRIMARY KEY,
+	"id" TEXT NOT NULL DEFAULT '',
+	"lower_id" INTEGER REFERENCES "lower" ("_id") ON DELETE SET NULL,
+	"score" REAL NOT NULL DEFAULT 0,
+	"data" BLOB NOT NULL DEFAULT X'',
+	"big" INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS "upper_lower_kv" (
+	"parent_id" INTEGER NOT NULL REFERENCES "upper" ("_id") ON DELETE CASCADE,
+	"key" TEXT NOT NULL,
+	"value_id" INTEGER REFERENCES "lower" ("_id") ON DELETE SET NULL,
+	PRIMARY KEY ("parent_id", "key")
+);
+
+CREATE TABLE IF NOT EXISTS "upper_counts" (
+	"parent_id" INTEGER NOT NULL REFERENCES "upper" ("_id") ON DELETE CASCADE,
+	"key" TEXT NOT NULL,
+	"value" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("parent_id", "key")
+);
+
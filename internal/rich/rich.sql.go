@@ -0,0 +1,463 @@
+package rich
+
+import (
+	"database/sql"
+
+	"github.com/paralin/protods/sqldb"
+)
+
+// RichSQLSchema contains the CREATE TABLE statements for the messages.
+var RichSQLSchema = []string{
+	`CREATE TABLE IF NOT EXISTS "lower" (
+	"_id" INTEGER PRIMARY KEY,
+	"value" TEXT NOT NULL DEFAULT '',
+	"flag" BOOLEAN NOT NULL DEFAULT 0
+)`,
+	`CREATE TABLE IF NOT EXISTS "upper" (
+	"_id" INTEGER PRIMARY KEY,
+	"id" TEXT NOT NULL DEFAULT '',
+	"lower_id" INTEGER REFERENCES "lower" ("_id") ON DELETE SET NULL,
+	"score" REAL NOT NULL DEFAULT 0,
+	"data" BLOB NOT NULL DEFAULT X'',
+	"big" INTEGER NOT NULL DEFAULT 0
+)`,
+	`CREATE TABLE IF NOT EXISTS "upper_lower_kv" (
+	"parent_id" INTEGER NOT NULL REFERENCES "upper" ("_id") ON DELETE CASCADE,
+	"key" TEXT NOT NULL,
+	"value_id" INTEGER REFERENCES "lower" ("_id") ON DELETE SET NULL,
+	PRIMARY KEY ("parent_id", "key")
+)`,
+	`CREATE TABLE IF NOT EXISTS "upper_counts" (
+	"parent_id" INTEGER NOT NULL REFERENCES "upper" ("_id") ON DELETE CASCADE,
+	"key" TEXT NOT NULL,
+	"value" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY ("parent_id", "key")
+)`,
+}
+
+// StringInt64MapSQL is an IStringInt64Map stored in the child table of a map field.
+type StringInt64MapSQL struct {
+	db       *sqldb.DB
+	table    string
+	parentID int64
+}
+
+// NewStringInt64MapSQL builds a new StringInt64MapSQL for the entries of the parent row in the table.
+func NewStringInt64MapSQL(db *sqldb.DB, table string, parentID int64) *StringInt64MapSQL {
+	return &StringInt64MapSQL{db: db, table: table, parentID: parentID}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapSQL)(nil))
+
+// Err returns the first error recorded by the database, if any.
+func (s *StringInt64MapSQL) Err() error {
+	return s.db.Err()
+}
+
+// Get returns a value from the map.
+func (s *StringInt64MapSQL) Get(key string) int64 {
+	var val int64
+	s.db.Get(`SELECT "value" FROM "`+s.table+`" WHERE "parent_id" = ? AND "key" = ?`, []interface{}{s.parentID, key}, &val)
+	return val
+}
+
+// Set sets a value in the map.
+func (s *StringInt64MapSQL) Set(key string, val int64) {
+	s.db.Exec(`INSERT INTO "`+s.table+`" ("parent_id", "key", "value") VALUES (?, ?, ?) ON CONFLICT ("parent_id", "key") DO UPDATE SET "value" = excluded."value"`, s.parentID, key, val)
+}
+
+// ForEach iterates over the map in key order.
+func (s *StringInt64MapSQL) ForEach(cb func(key string, val int64) bool) bool {
+	for _, key := range s.keys() {
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keys returns the keys of the map in order.
+func (s *StringInt64MapSQL) keys() []string {
+	return s.db.Strings(`SELECT "key" FROM "`+s.table+`" WHERE "parent_id" = ? ORDER BY "key"`, s.parentID)
+}
+
+// deleteAll deletes the entries of the map.
+func (s *StringInt64MapSQL) deleteAll() {
+	s.db.Exec(`DELETE FROM "`+s.table+`" WHERE "parent_id" = ?`, s.parentID)
+}
+
+// StringLowerMapSQL is an IStringLowerMap stored in the child table of a map field.
+type StringLowerMapSQL struct {
+	db       *sqldb.DB
+	table    string
+	parentID int64
+}
+
+// NewStringLowerMapSQL builds a new StringLowerMapSQL for the entries of the parent row in the table.
+func NewStringLowerMapSQL(db *sqldb.DB, table string, parentID int64) *StringLowerMapSQL {
+	return &StringLowerMapSQL{db: db, table: table, parentID: parentID}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapSQL)(nil))
+
+// Err returns the first error recorded by the database, if any.
+func (s *StringLowerMapSQL) Err() error {
+	return s.db.Err()
+}
+
+// Get returns a value from the map.
+func (s *StringLowerMapSQL) Get(key string) ILower {
+	var id sql.NullInt64
+	if !s.db.Get(`SELECT "value_id" FROM "`+s.table+`" WHERE "parent_id" = ? AND "key" = ?`, []interface{}{s.parentID, key}, &id) || !id.Valid {
+		return nil
+	}
+	return NewLowerSQL(s.db, id.Int64)
+}
+
+// Set sets a value in the map.
+func (s *StringLowerMapSQL) Set(key string, val ILower) {
+	if val == nil {
+		old := s.Get(key)
+		s.db.Exec(`DELETE FROM "`+s.table+`" WHERE "parent_id" = ? AND "key" = ?`, s.parentID, key)
+		if old != nil {
+			old.(*LowerSQL).Delete()
+		}
+		return
+	}
+
+	old := s.Get(key)
+	if v, ok := val.(*LowerSQL); ok && old != nil && v.db == s.db && v.id == old.(*LowerSQL).id {
+		return
+	}
+
+	var newID interface{}
+	if val != nil {
+		c, err := CreateLowerSQL(s.db)
+		if err != nil {
+			return
+		}
+		c.copyFrom(val)
+		newID = c.id
+	}
+	s.db.Exec(`INSERT INTO "`+s.table+`" ("parent_id", "key", "value_id") VALUES (?, ?, ?) ON CONFLICT ("parent_id", "key") DO UPDATE SET "value_id" = excluded."value_id"`, s.parentID, key, newID)
+	if old != nil {
+		old.(*LowerSQL).Delete()
+	}
+}
+
+// ForEach iterates over the map in key order.
+func (s *StringLowerMapSQL) ForEach(cb func(key string, val ILower) bool) bool {
+	for _, key := range s.keys() {
+		if !cb(key, s.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keys returns the keys of the map in order.
+func (s *StringLowerMapSQL) keys() []string {
+	return s.db.Strings(`SELECT "key" FROM "`+s.table+`" WHERE "parent_id" = ? ORDER BY "key"`, s.parentID)
+}
+
+// deleteAll deletes the entries of the map.
+func (s *StringLowerMapSQL) deleteAll() {
+	for _, key := range s.keys() {
+		if v := s.Get(key); v != nil {
+			v.(*LowerSQL).Delete()
+		}
+	}
+	s.db.Exec(`DELETE FROM "`+s.table+`" WHERE "parent_id" = ?`, s.parentID)
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapSQL) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapSQL) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerSQL is an ILower stored as a row of the lower table.
+type LowerSQL struct {
+	db *sqldb.DB
+	id int64
+}
+
+// NewLowerSQL builds a new LowerSQL for the row with the ID.
+func NewLowerSQL(db *sqldb.DB, id int64) *LowerSQL {
+	return &LowerSQL{db: db, id: id}
+}
+
+// CreateLowerSQL inserts a new empty row.
+func CreateLowerSQL(db *sqldb.DB) (*LowerSQL, error) {
+	id, err := db.Insert(`INSERT INTO "lower" DEFAULT VALUES`)
+	if err != nil {
+		return nil, err
+	}
+	return NewLowerSQL(db, id), nil
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerSQL)(nil))
+
+// GetRowID returns the ID of the row.
+func (s *LowerSQL) GetRowID() int64 {
+	return s.id
+}
+
+// Err returns the first error recorded by the database, if any.
+func (s *LowerSQL) Err() error {
+	return s.db.Err()
+}
+
+// Delete deletes the row, the rows of its message fields and its map entries.
+func (s *LowerSQL) Delete() {
+	s.db.Exec(`DELETE FROM "lower" WHERE "_id" = ?`, s.id)
+}
+
+// copyFrom stores the fields of val.
+func (s *LowerSQL) copyFrom(val ILower) {
+	s.SetValue(val.GetValue())
+	s.SetFlag(val.GetFlag())
+}
+
+// GetValue returns value.
+func (s *LowerSQL) GetValue() string {
+	var val string
+	s.db.Get(`SELECT "value" FROM "lower" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return val
+}
+
+// SetValue sets value.
+func (s *LowerSQL) SetValue(val string) {
+	s.db.Exec(`UPDATE "lower" SET "value" = ? WHERE "_id" = ?`, val, s.id)
+}
+
+// GetFlag returns flag.
+func (s *LowerSQL) GetFlag() bool {
+	var val bool
+	s.db.Get(`SELECT "flag" FROM "lower" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return val
+}
+
+// SetFlag sets flag.
+func (s *LowerSQL) SetFlag(val bool) {
+	s.db.Exec(`UPDATE "lower" SET "flag" = ? WHERE "_id" = ?`, val, s.id)
+}
+
+// UpperSQL is an IUpper stored as a row of the upper table.
+type UpperSQL struct {
+	db *sqldb.DB
+	id int64
+}
+
+// NewUpperSQL builds a new UpperSQL for the row with the ID.
+func NewUpperSQL(db *sqldb.DB, id int64) *UpperSQL {
+	return &UpperSQL{db: db, id: id}
+}
+
+// CreateUpperSQL inserts a new empty row.
+func CreateUpperSQL(db *sqldb.DB) (*UpperSQL, error) {
+	id, err := db.Insert(`INSERT INTO "upper" DEFAULT VALUES`)
+	if err != nil {
+		return nil, err
+	}
+	return NewUpperSQL(db, id), nil
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperSQL)(nil))
+
+// GetRowID returns the ID of the row.
+func (s *UpperSQL) GetRowID() int64 {
+	return s.id
+}
+
+// Err returns the first error recorded by the database, if any.
+func (s *UpperSQL) Err() error {
+	return s.db.Err()
+}
+
+// Delete deletes the row, the rows of its message fields and its map entries.
+func (s *UpperSQL) Delete() {
+	if v := s.GetLowerInter(); v != nil {
+		v.(*LowerSQL).Delete()
+	}
+	s.GetLowerKvInter().(*StringLowerMapSQL).deleteAll()
+	s.GetCountsInter().(*StringInt64MapSQL).deleteAll()
+	s.db.Exec(`DELETE FROM "upper" WHERE "_id" = ?`, s.id)
+}
+
+// copyFrom stores the fields of val.
+func (s *UpperSQL) copyFrom(val IUpper) {
+	s.SetId(val.GetId())
+	s.SetLower(val.GetLowerInter())
+	s.SetLowerKv(val.GetLowerKvInter())
+	s.SetScore(val.GetScore())
+	s.SetData(val.GetData())
+	s.SetCounts(val.GetCountsInter())
+	s.SetBig(val.GetBig())
+}
+
+// GetId returns id.
+func (s *UpperSQL) GetId() string {
+	var val string
+	s.db.Get(`SELECT "id" FROM "upper" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return val
+}
+
+// SetId sets id.
+func (s *UpperSQL) SetId(val string) {
+	s.db.Exec(`UPDATE "upper" SET "id" = ? WHERE "_id" = ?`, val, s.id)
+}
+
+// GetLowerInter returns lower.
+func (s *UpperSQL) GetLowerInter() ILower {
+	var id sql.NullInt64
+	if !s.db.Get(`SELECT "lower_id" FROM "upper" WHERE "_id" = ?`, []interface{}{s.id}, &id) || !id.Valid {
+		return nil
+	}
+	return NewLowerSQL(s.db, id.Int64)
+}
+
+// SetLower sets lower.
+func (s *UpperSQL) SetLower(val ILower) {
+	old := s.GetLowerInter()
+	if v, ok := val.(*LowerSQL); ok && old != nil && v.db == s.db && v.id == old.(*LowerSQL).id {
+		return
+	}
+
+	var newID interface{}
+	if val != nil {
+		c, err := CreateLowerSQL(s.db)
+		if err != nil {
+			return
+		}
+		c.copyFrom(val)
+		newID = c.id
+	}
+	s.db.Exec(`UPDATE "upper" SET "lower_id" = ? WHERE "_id" = ?`, newID, s.id)
+	if old != nil {
+		old.(*LowerSQL).Delete()
+	}
+}
+
+// NewLower builds a new detached lower, stored by SetLower.
+func (s *UpperSQL) NewLower() ILower {
+	return &Lower{}
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *UpperSQL) GetLowerKvInter() IStringLowerMap {
+	return NewStringLowerMapSQL(s.db, "upper_lower_kv", s.id)
+}
+
+// SetLowerKv sets lower_kv.
+func (s *UpperSQL) SetLowerKv(val IStringLowerMap) {
+	dst := s.GetLowerKvInter().(*StringLowerMapSQL)
+	if v, ok := val.(*StringLowerMapSQL); ok && *v == *dst {
+		return
+	}
+
+	dst.deleteAll()
+	if val != nil {
+		val.ForEach(func(key string, v ILower) bool {
+			dst.Set(key, v)
+			return true
+		})
+	}
+}
+
+// NewLowerKv builds a new detached lower_kv, stored by SetLowerKv.
+func (s *UpperSQL) NewLowerKv() IStringLowerMap {
+	return make(StringLowerMap)
+}
+
+// GetScore returns score.
+func (s *UpperSQL) GetScore() float64 {
+	var val float64
+	s.db.Get(`SELECT "score" FROM "upper" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return val
+}
+
+// SetScore sets score.
+func (s *UpperSQL) SetScore(val float64) {
+	s.db.Exec(`UPDATE "upper" SET "score" = ? WHERE "_id" = ?`, val, s.id)
+}
+
+// GetData returns data.
+func (s *UpperSQL) GetData() []byte {
+	var val []byte
+	s.db.Get(`SELECT "data" FROM "upper" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return val
+}
+
+// SetData sets data.
+func (s *UpperSQL) SetData(val []byte) {
+	if val == nil {
+		val = []byte{}
+	}
+	s.db.Exec(`UPDATE "upper" SET "data" = ? WHERE "_id" = ?`, val, s.id)
+}
+
+// GetCountsInter returns counts.
+func (s *UpperSQL) GetCountsInter() IStringInt64Map {
+	return NewStringInt64MapSQL(s.db, "upper_counts", s.id)
+}
+
+// SetCounts sets counts.
+func (s *UpperSQL) SetCounts(val IStringInt64Map) {
+	dst := s.GetCountsInter().(*StringInt64MapSQL)
+	if v, ok := val.(*StringInt64MapSQL); ok && *v == *dst {
+		return
+	}
+
+	dst.deleteAll()
+	if val != nil {
+		val.ForEach(func(key string, v int64) bool {
+			dst.Set(key, v)
+			return true
+		})
+	}
+}
+
+// NewCounts builds a new detached counts, stored by SetCounts.
+func (s *UpperSQL) NewCounts() IStringInt64Map {
+	return make(StringInt64Map)
+}
+
+// GetBig returns big.
+func (s *UpperSQL) GetBig() uint64 {
+	var val int64
+	s.db.Get(`SELECT "big" FROM "upper" WHERE "_id" = ?`, []interface{}{s.id}, &val)
+	return uint64(val)
+}
+
+// SetBig sets big.
+func (s *UpperSQL) SetBig(val uint64) {
+	s.db.Exec(`UPDATE "upper" SET "big" = ? WHERE "_id" = ?`, int64(val), s.id)
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperSQL) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperSQL) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperSQL) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
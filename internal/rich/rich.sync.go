@@ -0,0 +1,530 @@
+package rich
+
+import (
+	"sync"
+)
+
+// SyncStringInt64Map wraps an IStringInt64Map, guarding it with a RWMutex shared by the nested
+// messages and maps returned by the getters.
+type SyncStringInt64Map struct {
+	v   IStringInt64Map
+	mtx *sync.RWMutex
+}
+
+// NewSyncStringInt64Map wraps the map with a new mutex.
+// v must not be used directly while it is wrapped.
+func NewSyncStringInt64Map(v IStringInt64Map) *SyncStringInt64Map {
+	return &SyncStringInt64Map{v: v, mtx: new(sync.RWMutex)}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*SyncStringInt64Map)(nil))
+
+// Update calls cb with the write lock held, for atomic multi-field updates.
+// cb is called with the unguarded map, which must not be retained.
+func (s *SyncStringInt64Map) Update(cb func(v IStringInt64Map)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cb(s.v)
+}
+
+// View calls cb with the read lock held, for consistent multi-field reads.
+// cb is called with the unguarded map, which must not be modified or retained.
+func (s *SyncStringInt64Map) View(cb func(v IStringInt64Map)) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	cb(s.v)
+}
+
+// unwrapSyncStringInt64Map returns the value wrapped by val, or val.
+func unwrapSyncStringInt64Map(val IStringInt64Map) IStringInt64Map {
+	if w, ok := val.(*SyncStringInt64Map); ok {
+		return w.v
+	}
+	return val
+}
+
+// Get returns a value from the map.
+func (s *SyncStringInt64Map) Get(key string) int64 {
+	s.mtx.RLock()
+	v := s.v.Get(key)
+	s.mtx.RUnlock()
+	return v
+}
+
+// Set sets a value in the map.
+func (s *SyncStringInt64Map) Set(key string, val int64) {
+	s.mtx.Lock()
+	s.v.Set(key, val)
+	s.mtx.Unlock()
+}
+
+// ForEach iterates over a snapshot of the entries taken with the read lock
+// held, so cb can modify the map.
+func (s *SyncStringInt64Map) ForEach(cb func(key string, val int64) bool) bool {
+	var keys []string
+	var vals []int64
+	s.mtx.RLock()
+	s.v.ForEach(func(key string, val int64) bool {
+		keys = append(keys, key)
+		vals = append(vals, val)
+		return true
+	})
+	s.mtx.RUnlock()
+
+	for i, key := range keys {
+		if !cb(key, vals[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncStringLowerMap wraps an IStringLowerMap, guarding it with a RWMutex shared by the nested
+// messages and maps returned by the getters.
+type SyncStringLowerMap struct {
+	v   IStringLowerMap
+	mtx *sync.RWMutex
+}
+
+// NewSyncStringLowerMap wraps the map with a new mutex.
+// v must not be used directly while it is wrapped.
+func NewSyncStringLowerMap(v IStringLowerMap) *SyncStringLowerMap {
+	return &SyncStringLowerMap{v: v, mtx: new(sync.RWMutex)}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*SyncStringLowerMap)(nil))
+
+// Update calls cb with the write lock held, for atomic multi-field updates.
+// cb is called with the unguarded map, which must not be retained.
+func (s *SyncStringLowerMap) Update(cb func(v IStringLowerMap)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cb(s.v)
+}
+
+// View calls cb with the read lock held, for consistent multi-field reads.
+// cb is called with the unguarded map, which must not be modified or retained.
+func (s *SyncStringLowerMap) View(cb func(v IStringLowerMap)) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	cb(s.v)
+}
+
+// unwrapSyncStringLowerMap returns the value wrapped by val, or val.
+func unwrapSyncStringLowerMap(val IStringLowerMap) IStringLowerMap {
+	if w, ok := val.(*SyncStringLowerMap); ok {
+		return w.v
+	}
+	return val
+}
+
+// Get returns a value from the map.
+func (s *SyncStringLowerMap) Get(key string) ILower {
+	s.mtx.RLock()
+	v := s.v.Get(key)
+	s.mtx.RUnlock()
+	if v == nil {
+		return nil
+	}
+	return &SyncLower{v: v, mtx: s.mtx}
+}
+
+// Set sets a value in the map.
+// A value guarded by another mutex is copied under its read lock.
+func (s *SyncStringLowerMap) Set(key string, val ILower) {
+	if w, ok := val.(*SyncLower); ok && w.mtx != s.mtx {
+		c := &Lower{}
+		w.mtx.RLock()
+		syncCopyLower(c, w.v)
+		w.mtx.RUnlock()
+		val = c
+	}
+	s.mtx.Lock()
+	s.v.Set(key, unwrapSyncLower(val))
+	s.mtx.Unlock()
+}
+
+// ForEach iterates over a snapshot of the entries taken with the read lock
+// held, so cb can modify the map.
+func (s *SyncStringLowerMap) ForEach(cb func(key string, val ILower) bool) bool {
+	var keys []string
+	var vals []ILower
+	s.mtx.RLock()
+	s.v.ForEach(func(key string, val ILower) bool {
+		keys = append(keys, key)
+		vals = append(vals, val)
+		return true
+	})
+	s.mtx.RUnlock()
+
+	for i, key := range keys {
+		val := vals[i]
+		if val != nil {
+			val = &SyncLower{v: val, mtx: s.mtx}
+		}
+		if !cb(key, val) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *SyncStringLowerMap) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *SyncStringLowerMap) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// SyncLower wraps an ILower, guarding it with a RWMutex shared by the nested
+// messages and maps returned by the getters.
+type SyncLower struct {
+	v   ILower
+	mtx *sync.RWMutex
+}
+
+// NewSyncLower wraps the object with a new mutex.
+// v must not be used directly while it is wrapped.
+func NewSyncLower(v ILower) *SyncLower {
+	return &SyncLower{v: v, mtx: new(sync.RWMutex)}
+}
+
+// _ is a type assertion
+var _ ILower = ((*SyncLower)(nil))
+
+// Update calls cb with the write lock held, for atomic multi-field updates.
+// cb is called with the unguarded object, which must not be retained.
+func (s *SyncLower) Update(cb func(v ILower)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cb(s.v)
+}
+
+// View calls cb with the read lock held, for consistent multi-field reads.
+// cb is called with the unguarded object, which must not be modified or retained.
+func (s *SyncLower) View(cb func(v ILower)) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	cb(s.v)
+}
+
+// unwrapSyncLower returns the value wrapped by val, or val.
+func unwrapSyncLower(val ILower) ILower {
+	if w, ok := val.(*SyncLower); ok {
+		return w.v
+	}
+	return val
+}
+
+// GetValue returns value.
+func (s *SyncLower) GetValue() string {
+	s.mtx.RLock()
+	v := s.v.GetValue()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetValue sets value.
+func (s *SyncLower) SetValue(val string) {
+	s.mtx.Lock()
+	s.v.SetValue(val)
+	s.mtx.Unlock()
+}
+
+// GetFlag returns flag.
+func (s *SyncLower) GetFlag() bool {
+	s.mtx.RLock()
+	v := s.v.GetFlag()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetFlag sets flag.
+func (s *SyncLower) SetFlag(val bool) {
+	s.mtx.Lock()
+	s.v.SetFlag(val)
+	s.mtx.Unlock()
+}
+
+// SyncUpper wraps an IUpper, guarding it with a RWMutex shared by the nested
+// messages and maps returned by the getters.
+type SyncUpper struct {
+	v   IUpper
+	mtx *sync.RWMutex
+}
+
+// NewSyncUpper wraps the object with a new mutex.
+// v must not be used directly while it is wrapped.
+func NewSyncUpper(v IUpper) *SyncUpper {
+	return &SyncUpper{v: v, mtx: new(sync.RWMutex)}
+}
+
+// _ is a type assertion
+var _ IUpper = ((*SyncUpper)(nil))
+
+// Update calls cb with the write lock held, for atomic multi-field updates.
+// cb is called with the unguarded object, which must not be retained.
+func (s *SyncUpper) Update(cb func(v IUpper)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cb(s.v)
+}
+
+// View calls cb with the read lock held, for consistent multi-field reads.
+// cb is called with the unguarded object, which must not be modified or retained.
+func (s *SyncUpper) View(cb func(v IUpper)) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	cb(s.v)
+}
+
+// unwrapSyncUpper returns the value wrapped by val, or val.
+func unwrapSyncUpper(val IUpper) IUpper {
+	if w, ok := val.(*SyncUpper); ok {
+		return w.v
+	}
+	return val
+}
+
+// GetId returns id.
+func (s *SyncUpper) GetId() string {
+	s.mtx.RLock()
+	v := s.v.GetId()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetId sets id.
+func (s *SyncUpper) SetId(val string) {
+	s.mtx.Lock()
+	s.v.SetId(val)
+	s.mtx.Unlock()
+}
+
+// GetLowerInter returns lower.
+func (s *SyncUpper) GetLowerInter() ILower {
+	s.mtx.RLock()
+	v := s.v.GetLowerInter()
+	s.mtx.RUnlock()
+	if v == nil {
+		return nil
+	}
+	return &SyncLower{v: v, mtx: s.mtx}
+}
+
+// SetLower sets lower.
+// A value guarded by another mutex is copied under its read lock.
+func (s *SyncUpper) SetLower(val ILower) {
+	if w, ok := val.(*SyncLower); ok && w.mtx != s.mtx {
+		c := s.NewLower()
+		w.mtx.RLock()
+		syncCopyLower(c, w.v)
+		w.mtx.RUnlock()
+		val = c
+	}
+	s.mtx.Lock()
+	s.v.SetLower(unwrapSyncLower(val))
+	s.mtx.Unlock()
+}
+
+// NewLower builds a new unguarded lower, guarded once it is set.
+func (s *SyncUpper) NewLower() ILower {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.v.NewLower()
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *SyncUpper) GetLowerKvInter() IStringLowerMap {
+	s.mtx.RLock()
+	v := s.v.GetLowerKvInter()
+	s.mtx.RUnlock()
+	if v == nil {
+		return nil
+	}
+	return &SyncStringLowerMap{v: v, mtx: s.mtx}
+}
+
+// SetLowerKv sets lower_kv.
+// A value guarded by another mutex is copied under its read lock.
+func (s *SyncUpper) SetLowerKv(val IStringLowerMap) {
+	if w, ok := val.(*SyncStringLowerMap); ok && w.mtx != s.mtx {
+		c := s.NewLowerKv()
+		w.mtx.RLock()
+		syncCopyStringLowerMap(c, w.v)
+		w.mtx.RUnlock()
+		val = c
+	}
+	s.mtx.Lock()
+	s.v.SetLowerKv(unwrapSyncStringLowerMap(val))
+	s.mtx.Unlock()
+}
+
+// NewLowerKv builds a new unguarded lower_kv, guarded once it is set.
+func (s *SyncUpper) NewLowerKv() IStringLowerMap {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.v.NewLowerKv()
+}
+
+// GetScore returns score.
+func (s *SyncUpper) GetScore() float64 {
+	s.mtx.RLock()
+	v := s.v.GetScore()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetScore sets score.
+func (s *SyncUpper) SetScore(val float64) {
+	s.mtx.Lock()
+	s.v.SetScore(val)
+	s.mtx.Unlock()
+}
+
+// GetData returns data.
+func (s *SyncUpper) GetData() []byte {
+	s.mtx.RLock()
+	v := s.v.GetData()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetData sets data.
+func (s *SyncUpper) SetData(val []byte) {
+	s.mtx.Lock()
+	s.v.SetData(val)
+	s.mtx.Unlock()
+}
+
+// GetCountsInter returns counts.
+func (s *SyncUpper) GetCountsInter() IStringInt64Map {
+	s.mtx.RLock()
+	v := s.v.GetCountsInter()
+	s.mtx.RUnlock()
+	if v == nil {
+		return nil
+	}
+	return &SyncStringInt64Map{v: v, mtx: s.mtx}
+}
+
+// SetCounts sets counts.
+// A value guarded by another mutex is copied under its read lock.
+func (s *SyncUpper) SetCounts(val IStringInt64Map) {
+	if w, ok := val.(*SyncStringInt64Map); ok && w.mtx != s.mtx {
+		c := s.NewCounts()
+		w.mtx.RLock()
+		syncCopyStringInt64Map(c, w.v)
+		w.mtx.RUnlock()
+		val = c
+	}
+	s.mtx.Lock()
+	s.v.SetCounts(unwrapSyncStringInt64Map(val))
+	s.mtx.Unlock()
+}
+
+// NewCounts builds a new unguarded counts, guarded once it is set.
+func (s *SyncUpper) NewCounts() IStringInt64Map {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.v.NewCounts()
+}
+
+// GetBig returns big.
+func (s *SyncUpper) GetBig() uint64 {
+	s.mtx.RLock()
+	v := s.v.GetBig()
+	s.mtx.RUnlock()
+	return v
+}
+
+// SetBig sets big.
+func (s *SyncUpper) SetBig(val uint64) {
+	s.mtx.Lock()
+	s.v.SetBig(val)
+	s.mtx.Unlock()
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *SyncUpper) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *SyncUpper) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *SyncUpper) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
+
+// syncCopyStringInt64Map sets the entries of src in dst.
+func syncCopyStringInt64Map(dst, src IStringInt64Map) {
+	src.ForEach(func(key string, val int64) bool {
+		dst.Set(key, val)
+		return true
+	})
+}
+
+// syncCopyStringLowerMap sets the entries of src in dst.
+// Message values are set to an empty Lower and filled in through dst.Get,
+// so they are stored by the implementation of dst.
+func syncCopyStringLowerMap(dst, src IStringLowerMap) {
+	src.ForEach(func(key string, val ILower) bool {
+		if val == nil {
+			dst.Set(key, nil)
+			return true
+		}
+		dst.Set(key, &Lower{})
+		if v := dst.Get(key); v != nil {
+			syncCopyLower(v, val)
+		}
+		return true
+	})
+}
+
+// syncCopyLower sets the fields of dst to deep copies of the fields of src.
+// Message and map fields are built with the constructors of dst.
+func syncCopyLower(dst, src ILower) {
+	dst.SetValue(src.GetValue())
+	dst.SetFlag(src.GetFlag())
+}
+
+// syncCopyUpper sets the fields of dst to deep copies of the fields of src.
+// Message and map fields are built with the constructors of dst.
+func syncCopyUpper(dst, src IUpper) {
+	dst.SetId(src.GetId())
+	if v := src.GetLowerInter(); v != nil {
+		c := dst.NewLower()
+		syncCopyLower(c, v)
+		dst.SetLower(c)
+	} else {
+		dst.SetLower(nil)
+	}
+	if v := src.GetLowerKvInter(); v != nil {
+		c := dst.NewLowerKv()
+		syncCopyStringLowerMap(c, v)
+		dst.SetLowerKv(c)
+	} else {
+		dst.SetLowerKv(nil)
+	}
+	dst.SetScore(src.GetScore())
+	dst.SetData(append([]byte(nil), src.GetData()...))
+	if v := src.GetCountsInter(); v != nil {
+		c := dst.NewCounts()
+		syncCopyStringInt64Map(c, v)
+		dst.SetCounts(c)
+	} else {
+		dst.SetCounts(nil)
+	}
+	dst.SetBig(src.GetBig())
+}
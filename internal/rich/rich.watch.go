@@ -0,0 +1,374 @@
+package rich
+
+import (
+	"net/url"
+
+	"github.com/paralin/protods/diff"
+	"github.com/paralin/protods/watch"
+)
+
+// StringInt64MapObservable wraps an IStringInt64Map, notifying watchers after each mutation.
+type StringInt64MapObservable struct {
+	v      IStringInt64Map
+	hub    *watch.Hub
+	prefix string
+}
+
+// NewStringInt64MapObservable wraps the map at the key path prefix.
+func NewStringInt64MapObservable(v IStringInt64Map, hub *watch.Hub, prefix string) *StringInt64MapObservable {
+	return &StringInt64MapObservable{v: v, hub: hub, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringInt64Map = ((*StringInt64MapObservable)(nil))
+
+// Watch calls cb after each change at or below the path, relative to the map,
+// or replacing a path above it, for example /lower_kv or an empty path for
+// all changes.
+func (s *StringInt64MapObservable) Watch(path string, cb func(diff.Change)) *watch.Subscription {
+	return s.hub.Watch(s.prefix+path, cb)
+}
+
+// WatchChan delivers the changes matching the path to a buffered channel.
+func (s *StringInt64MapObservable) WatchChan(path string, size int) (<-chan diff.Change, *watch.Subscription) {
+	return s.hub.WatchChan(s.prefix+path, size)
+}
+
+// Get returns a value from the map.
+func (s *StringInt64MapObservable) Get(key string) int64 {
+	return s.v.Get(key)
+}
+
+// Set sets a value in the map and notifies the watchers.
+func (s *StringInt64MapObservable) Set(key string, val int64) {
+	kind := diff.Added
+	if hasKeyStringInt64Map(s.v, key) {
+		kind = diff.Modified
+	}
+	old := s.v.Get(key)
+	s.v.Set(key, val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/" + url.PathEscape(key), Kind: kind, Old: old, New: val})
+}
+
+// ForEach iterates over the map.
+func (s *StringInt64MapObservable) ForEach(cb func(key string, val int64) bool) bool {
+	return s.v.ForEach(cb)
+}
+
+// unwrapStringInt64MapObservable returns the value wrapped by val, or val.
+func unwrapStringInt64MapObservable(val IStringInt64Map) IStringInt64Map {
+	if w, ok := val.(*StringInt64MapObservable); ok {
+		return w.v
+	}
+	return val
+}
+
+// hasKeyStringInt64Map checks if the key is in the map.
+func hasKeyStringInt64Map(m IStringInt64Map, key string) bool {
+	if v, ok := m.(StringInt64Map); ok {
+		_, ok = v[key]
+		return ok
+	}
+	return !m.ForEach(func(k string, _ int64) bool {
+		return k != key
+	})
+}
+
+// StringLowerMapObservable wraps an IStringLowerMap, notifying watchers after each mutation.
+type StringLowerMapObservable struct {
+	v      IStringLowerMap
+	hub    *watch.Hub
+	prefix string
+}
+
+// NewStringLowerMapObservable wraps the map at the key path prefix.
+func NewStringLowerMapObservable(v IStringLowerMap, hub *watch.Hub, prefix string) *StringLowerMapObservable {
+	return &StringLowerMapObservable{v: v, hub: hub, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IStringLowerMap = ((*StringLowerMapObservable)(nil))
+
+// Watch calls cb after each change at or below the path, relative to the map,
+// or replacing a path above it, for example /lower_kv or an empty path for
+// all changes.
+func (s *StringLowerMapObservable) Watch(path string, cb func(diff.Change)) *watch.Subscription {
+	return s.hub.Watch(s.prefix+path, cb)
+}
+
+// WatchChan delivers the changes matching the path to a buffered channel.
+func (s *StringLowerMapObservable) WatchChan(path string, size int) (<-chan diff.Change, *watch.Subscription) {
+	return s.hub.WatchChan(s.prefix+path, size)
+}
+
+// Get returns a value from the map.
+func (s *StringLowerMapObservable) Get(key string) ILower {
+	v := s.v.Get(key)
+	if v == nil {
+		return nil
+	}
+	return NewLowerObservable(v, s.hub, s.prefix+"/"+url.PathEscape(key))
+}
+
+// Set sets a value in the map and notifies the watchers.
+func (s *StringLowerMapObservable) Set(key string, val ILower) {
+	old := s.v.Get(key)
+	s.v.Set(key, unwrapLowerObservable(val))
+	s.hub.Notify(diff.Change{Path: s.prefix + "/" + url.PathEscape(key), Kind: watch.ChangeKind(old != nil, val != nil), Old: old, New: val})
+}
+
+// ForEach iterates over the map.
+func (s *StringLowerMapObservable) ForEach(cb func(key string, val ILower) bool) bool {
+	return s.v.ForEach(func(key string, val ILower) bool {
+		if val != nil {
+			val = NewLowerObservable(val, s.hub, s.prefix+"/"+url.PathEscape(key))
+		}
+		return cb(key, val)
+	})
+}
+
+// unwrapStringLowerMapObservable returns the value wrapped by val, or val.
+func unwrapStringLowerMapObservable(val IStringLowerMap) IStringLowerMap {
+	if w, ok := val.(*StringLowerMapObservable); ok {
+		return w.v
+	}
+	return val
+}
+
+// GetReader returns a value from the map as an ILowerReader.
+func (s *StringLowerMapObservable) GetReader(key string) ILowerReader {
+	return s.Get(key)
+}
+
+// ForEachReader iterates over the map with the values as ILowerReader.
+func (s *StringLowerMapObservable) ForEachReader(cb func(key string, val ILowerReader) bool) bool {
+	return s.ForEach(func(key string, val ILower) bool {
+		return cb(key, val)
+	})
+}
+
+// LowerObservable wraps an ILower, notifying watchers after each mutation.
+type LowerObservable struct {
+	v      ILower
+	hub    *watch.Hub
+	prefix string
+}
+
+// NewLowerObservable wraps the object at the key path prefix, empty for the root object.
+func NewLowerObservable(v ILower, hub *watch.Hub, prefix string) *LowerObservable {
+	return &LowerObservable{v: v, hub: hub, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ ILower = ((*LowerObservable)(nil))
+
+// Watch calls cb after each change at or below the path, relative to the object,
+// or replacing a path above it, for example /lower_kv or an empty path for
+// all changes.
+func (s *LowerObservable) Watch(path string, cb func(diff.Change)) *watch.Subscription {
+	return s.hub.Watch(s.prefix+path, cb)
+}
+
+// WatchChan delivers the changes matching the path to a buffered channel.
+func (s *LowerObservable) WatchChan(path string, size int) (<-chan diff.Change, *watch.Subscription) {
+	return s.hub.WatchChan(s.prefix+path, size)
+}
+
+// GetValue returns value.
+func (s *LowerObservable) GetValue() string {
+	return s.v.GetValue()
+}
+
+// SetValue sets value and notifies the watchers.
+func (s *LowerObservable) SetValue(val string) {
+	old := s.v.GetValue()
+	s.v.SetValue(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/value", Kind: diff.Modified, Old: old, New: val})
+}
+
+// GetFlag returns flag.
+func (s *LowerObservable) GetFlag() bool {
+	return s.v.GetFlag()
+}
+
+// SetFlag sets flag and notifies the watchers.
+func (s *LowerObservable) SetFlag(val bool) {
+	old := s.v.GetFlag()
+	s.v.SetFlag(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/flag", Kind: diff.Modified, Old: old, New: val})
+}
+
+// unwrapLowerObservable returns the value wrapped by val, or val.
+func unwrapLowerObservable(val ILower) ILower {
+	if w, ok := val.(*LowerObservable); ok {
+		return w.v
+	}
+	return val
+}
+
+// UpperObservable wraps an IUpper, notifying watchers after each mutation.
+type UpperObservable struct {
+	v      IUpper
+	hub    *watch.Hub
+	prefix string
+}
+
+// NewUpperObservable wraps the object at the key path prefix, empty for the root object.
+func NewUpperObservable(v IUpper, hub *watch.Hub, prefix string) *UpperObservable {
+	return &UpperObservable{v: v, hub: hub, prefix: prefix}
+}
+
+// _ is a type assertion
+var _ IUpper = ((*UpperObservable)(nil))
+
+// Watch calls cb after each change at or below the path, relative to the object,
+// or replacing a path above it, for example /lower_kv or an empty path for
+// all changes.
+func (s *UpperObservable) Watch(path string, cb func(diff.Change)) *watch.Subscription {
+	return s.hub.Watch(s.prefix+path, cb)
+}
+
+// WatchChan delivers the changes matching the path to a buffered channel.
+func (s *UpperObservable) WatchChan(path string, size int) (<-chan diff.Change, *watch.Subscription) {
+	return s.hub.WatchChan(s.prefix+path, size)
+}
+
+// GetId returns id.
+func (s *UpperObservable) GetId() string {
+	return s.v.GetId()
+}
+
+// SetId sets id and notifies the watchers.
+func (s *UpperObservable) SetId(val string) {
+	old := s.v.GetId()
+	s.v.SetId(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/id", Kind: diff.Modified, Old: old, New: val})
+}
+
+// GetLowerInter returns lower.
+func (s *UpperObservable) GetLowerInter() ILower {
+	v := s.v.GetLowerInter()
+	if v == nil {
+		return nil
+	}
+	return NewLowerObservable(v, s.hub, s.prefix+"/lower")
+}
+
+// SetLower sets lower and notifies the watchers.
+func (s *UpperObservable) SetLower(val ILower) {
+	old := s.v.GetLowerInter()
+	s.v.SetLower(unwrapLowerObservable(val))
+	s.hub.Notify(diff.Change{Path: s.prefix + "/lower", Kind: watch.ChangeKind(old != nil, val != nil), Old: old, New: val})
+}
+
+// NewLower builds a new lower, notified when it is set.
+func (s *UpperObservable) NewLower() ILower {
+	return s.v.NewLower()
+}
+
+// GetLowerKvInter returns lower_kv.
+func (s *UpperObservable) GetLowerKvInter() IStringLowerMap {
+	v := s.v.GetLowerKvInter()
+	if v == nil {
+		return nil
+	}
+	return NewStringLowerMapObservable(v, s.hub, s.prefix+"/lower_kv")
+}
+
+// SetLowerKv sets lower_kv and notifies the watchers.
+func (s *UpperObservable) SetLowerKv(val IStringLowerMap) {
+	old := s.v.GetLowerKvInter()
+	if m, ok := old.(StringLowerMap); ok && m == nil {
+		old = nil
+	}
+	s.v.SetLowerKv(unwrapStringLowerMapObservable(val))
+	s.hub.Notify(diff.Change{Path: s.prefix + "/lower_kv", Kind: watch.ChangeKind(old != nil, val != nil), Old: old, New: val})
+}
+
+// NewLowerKv builds a new lower_kv, notified when it is set.
+func (s *UpperObservable) NewLowerKv() IStringLowerMap {
+	return s.v.NewLowerKv()
+}
+
+// GetScore returns score.
+func (s *UpperObservable) GetScore() float64 {
+	return s.v.GetScore()
+}
+
+// SetScore sets score and notifies the watchers.
+func (s *UpperObservable) SetScore(val float64) {
+	old := s.v.GetScore()
+	s.v.SetScore(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/score", Kind: diff.Modified, Old: old, New: val})
+}
+
+// GetData returns data.
+func (s *UpperObservable) GetData() []byte {
+	return s.v.GetData()
+}
+
+// SetData sets data and notifies the watchers.
+func (s *UpperObservable) SetData(val []byte) {
+	old := s.v.GetData()
+	s.v.SetData(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/data", Kind: diff.Modified, Old: old, New: val})
+}
+
+// GetCountsInter returns counts.
+func (s *UpperObservable) GetCountsInter() IStringInt64Map {
+	v := s.v.GetCountsInter()
+	if v == nil {
+		return nil
+	}
+	return NewStringInt64MapObservable(v, s.hub, s.prefix+"/counts")
+}
+
+// SetCounts sets counts and notifies the watchers.
+func (s *UpperObservable) SetCounts(val IStringInt64Map) {
+	old := s.v.GetCountsInter()
+	if m, ok := old.(StringInt64Map); ok && m == nil {
+		old = nil
+	}
+	s.v.SetCounts(unwrapStringInt64MapObservable(val))
+	s.hub.Notify(diff.Change{Path: s.prefix + "/counts", Kind: watch.ChangeKind(old != nil, val != nil), Old: old, New: val})
+}
+
+// NewCounts builds a new counts, notified when it is set.
+func (s *UpperObservable) NewCounts() IStringInt64Map {
+	return s.v.NewCounts()
+}
+
+// GetBig returns big.
+func (s *UpperObservable) GetBig() uint64 {
+	return s.v.GetBig()
+}
+
+// SetBig sets big and notifies the watchers.
+func (s *UpperObservable) SetBig(val uint64) {
+	old := s.v.GetBig()
+	s.v.SetBig(val)
+	s.hub.Notify(diff.Change{Path: s.prefix + "/big", Kind: diff.Modified, Old: old, New: val})
+}
+
+// unwrapUpperObservable returns the value wrapped by val, or val.
+func unwrapUpperObservable(val IUpper) IUpper {
+	if w, ok := val.(*UpperObservable); ok {
+		return w.v
+	}
+	return val
+}
+
+// GetLowerReader returns lower as an ILowerReader.
+func (s *UpperObservable) GetLowerReader() ILowerReader {
+	return s.GetLowerInter()
+}
+
+// GetLowerKvReader returns lower_kv as an IStringLowerMapReader.
+func (s *UpperObservable) GetLowerKvReader() IStringLowerMapReader {
+	return s.GetLowerKvInter()
+}
+
+// GetCountsReader returns counts as an IStringInt64MapReader.
+func (s *UpperObservable) GetCountsReader() IStringInt64MapReader {
+	return s.GetCountsInter()
+}
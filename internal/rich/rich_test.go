@@ -0,0 +1,88 @@
+package rich
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// fillUpper sets the fields of an upper through its interface.
+func fillUpper(u IUpper) {
+	u.SetId("upper")
+	u.SetScore(1.5)
+	u.SetData([]byte{1, 2, 3})
+	u.SetBig(math.MaxUint64)
+
+	lower := u.NewLower()
+	lower.SetValue("lower")
+	lower.SetFlag(true)
+	u.SetLower(lower)
+
+	lowerKv := u.NewLowerKv()
+	lowerKv.Set("a/b", &Lower{Value: "escaped"})
+	lowerKv.Set("c", &Lower{Value: "c", Flag: true})
+	u.SetLowerKv(lowerKv)
+
+	counts := u.NewCounts()
+	counts.Set("x", 1)
+	counts.Set("y", -2)
+	u.SetCounts(counts)
+}
+
+// checkUpper checks an upper has the fields set by fillUpper.
+func checkUpper(t *testing.T, u IUpperReader) {
+	t.Helper()
+
+	if id := u.GetId(); id != "upper" {
+		t.Errorf("id: expected upper, got %q", id)
+	}
+	if score := u.GetScore(); score != 1.5 {
+		t.Errorf("score: expected 1.5, got %v", score)
+	}
+	if data := u.GetData(); !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("data: expected [1 2 3], got %v", data)
+	}
+
+	if big := u.GetBig(); big != math.MaxUint64 {
+		t.Errorf("big: expected %d, got %d", uint64(math.MaxUint64), big)
+	}
+
+	lower := u.GetLowerReader()
+	if lower == nil {
+		t.Fatal("lower: expected set")
+	}
+	if lower.GetValue() != "lower" || !lower.GetFlag() {
+		t.Errorf("lower: unexpected value %q flag %v", lower.GetValue(), lower.GetFlag())
+	}
+
+	lowerKv := u.GetLowerKvReader()
+	if lowerKv == nil {
+		t.Fatal("lower_kv: expected set")
+	}
+	values := make(map[string]string)
+	lowerKv.ForEachReader(func(key string, val ILowerReader) bool {
+		values[key] = val.GetValue()
+		return true
+	})
+	if len(values) != 2 || values["a/b"] != "escaped" || values["c"] != "c" {
+		t.Errorf("lower_kv: unexpected entries %v", values)
+	}
+	if c := lowerKv.GetReader("c"); c == nil || !c.GetFlag() {
+		t.Error("lower_kv: expected c with flag set")
+	}
+
+	counts := u.GetCountsReader()
+	if counts == nil {
+		t.Fatal("counts: expected set")
+	}
+	if counts.Get("x") != 1 || counts.Get("y") != -2 {
+		t.Errorf("counts: unexpected values %v %v", counts.Get("x"), counts.Get("y"))
+	}
+}
+
+// TestProto tests the proto setters generated by itypes.
+func TestProto(t *testing.T) {
+	u := &Upper{}
+	fillUpper(u)
+	checkUpper(t, u)
+}
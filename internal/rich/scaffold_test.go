@@ -0,0 +1,128 @@
+package rich
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// setMarker marks a message or map as set at a key path.
+type setMarker struct{}
+
+// mapHooks are ScaffoldHooks storing values in a map by key path.
+type mapHooks struct {
+	vals map[string]interface{}
+}
+
+// newMapHooks builds new empty map hooks.
+func newMapHooks() *mapHooks {
+	return &mapHooks{vals: make(map[string]interface{})}
+}
+
+// LoadField loads the value at the key path.
+func (h *mapHooks) LoadField(keyPath string) (interface{}, bool) {
+	val, ok := h.vals[keyPath]
+	return val, ok
+}
+
+// StoreField stores the value at the key path.
+func (h *mapHooks) StoreField(keyPath string, val interface{}) {
+	switch v := val.(type) {
+	case nil:
+		for k := range h.vals {
+			if k == keyPath || strings.HasPrefix(k, keyPath+"/") {
+				delete(h.vals, k)
+			}
+		}
+	case ILower:
+		h.vals[keyPath] = setMarker{}
+		h.StoreField(keyPath+"/value", v.GetValue())
+		h.StoreField(keyPath+"/flag", v.GetFlag())
+	case IStringLowerMap:
+		h.vals[keyPath] = setMarker{}
+		v.ForEach(func(key string, val ILower) bool {
+			h.StoreField(keyPath+"/"+url.PathEscape(key), val)
+			return true
+		})
+	case IStringInt64Map:
+		h.vals[keyPath] = setMarker{}
+		v.ForEach(func(key string, val int64) bool {
+			h.StoreField(keyPath+"/"+url.PathEscape(key), val)
+			return true
+		})
+	default:
+		h.vals[keyPath] = val
+	}
+}
+
+// ListKeys lists the keys directly below the key path.
+func (h *mapHooks) ListKeys(keyPath string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for k := range h.vals {
+		if !strings.HasPrefix(k, keyPath+"/") {
+			continue
+		}
+		key := strings.SplitN(k[len(keyPath)+1:], "/", 2)[0]
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// _ is a type assertion
+var _ ScaffoldHooks = ((*mapHooks)(nil))
+
+// TestScaffold tests the scaffold types round trip through the hooks.
+func TestScaffold(t *testing.T) {
+	hooks := newMapHooks()
+	u := NewUpperScaffold(hooks, "/upper")
+	fillUpper(u)
+	checkUpper(t, NewUpperScaffold(hooks, "/upper"))
+
+	// nested map keys are path escaped.
+	if val := hooks.vals["/upper/lower_kv/a%2Fb/value"]; val != "escaped" {
+		t.Fatalf("expected escaped key path, got %v", hooks.vals)
+	}
+	if keys := hooks.ListKeys("/upper/lower_kv"); strings.Join(keys, ",") != "a%2Fb,c" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if val := hooks.vals["/upper/counts/y"]; val != int64(-2) {
+		t.Fatalf("unexpected count: %v", val)
+	}
+
+	var keys []string
+	u.GetLowerKvInter().ForEach(func(key string, val ILower) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if strings.Join(keys, ",") != "a/b,c" {
+		t.Fatalf("expected unescaped keys, got %v", keys)
+	}
+
+	// copy into a proto message and back.
+	msg := &Upper{}
+	fillUpper(msg)
+	other := NewUpperScaffold(newMapHooks(), "")
+	other.SetId(msg.GetId())
+	other.SetScore(msg.GetScore())
+	other.SetData(msg.GetData())
+	other.SetBig(msg.GetBig())
+	other.SetLower(msg.GetLowerInter())
+	other.SetLowerKv(msg.GetLowerKvInter())
+	other.SetCounts(msg.GetCountsInter())
+	checkUpper(t, other)
+
+	// clearing a message field removes the nested values.
+	u.SetLower(nil)
+	if u.GetLowerInter() != nil {
+		t.Fatal("expected lower to be cleared")
+	}
+	if _, ok := hooks.vals["/upper/lower/value"]; ok {
+		t.Fatal("expected nested values to be cleared")
+	}
+}
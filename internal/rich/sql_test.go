@@ -0,0 +1,45 @@
+package rich
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/paralin/protods/kv/sqlite"
+	"github.com/paralin/protods/sqldb"
+)
+
+// openSQL opens a new database with the fixture schema.
+func openSQL(t *testing.T) *sqldb.DB {
+	t.Helper()
+	sqlDB, err := sql.Open(sqlite.DriverName, filepath.Join(t.TempDir(), "rich.db"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db := sqldb.New(sqlDB)
+	if err := db.CreateSchema(RichSQLSchema); err != nil {
+		t.Fatal(err.Error())
+	}
+	return db
+}
+
+func TestSQL(t *testing.T) {
+	db := openSQL(t)
+	u, err := CreateUpperSQL(db)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fillUpper(u)
+	checkUpper(t, NewUpperSQL(db, u.GetRowID()))
+
+	// nil bytes are stored as empty.
+	u.SetData(nil)
+	if data := u.GetData(); len(data) != 0 {
+		t.Errorf("expected empty data, got %v", data)
+	}
+	if err := db.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
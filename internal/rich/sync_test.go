@@ -0,0 +1,62 @@
+package rich
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestSync tests the wrappers with concurrent readers and writers.
+func TestSync(t *testing.T) {
+	s := NewSyncUpper(&Upper{})
+	fillUpper(s)
+	checkUpper(t, s)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.GetCountsInter().Set("x", int64(j))
+				s.GetLowerKvInter().Get("c").SetValue("c")
+				s.GetLowerKvInter().ForEach(func(key string, val ILower) bool {
+					_ = val.GetValue()
+					return true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestSyncAcrossTrees tests setting values wrapped for another tree.
+func TestSyncAcrossTrees(t *testing.T) {
+	a := NewSyncUpper(&Upper{})
+	b := NewSyncUpper(&Upper{})
+	fillUpper(a)
+	fillUpper(b)
+
+	// setting values of each tree in the other concurrently must not
+	// deadlock or share the values.
+	var wg sync.WaitGroup
+	for _, p := range [][2]*SyncUpper{{a, b}, {b, a}} {
+		dst, src := p[0], p[1]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				dst.SetLower(src.GetLowerInter())
+				dst.SetLowerKv(src.GetLowerKvInter())
+				dst.GetLowerKvInter().Set("d", src.GetLowerKvInter().Get("c"))
+				src.GetLowerInter().SetFlag(j%2 == 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	a.SetLower(b.GetLowerInter())
+	b.GetLowerInter().SetValue("changed")
+	if v := a.GetLowerInter().GetValue(); v != "lower" {
+		t.Fatalf("expected a copy of lower, got %q", v)
+	}
+}
@@ -0,0 +1,79 @@
+package rich
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/diff"
+	"github.com/paralin/protods/watch"
+)
+
+// TestWatch tests the changes delivered by the observable wrappers.
+func TestWatch(t *testing.T) {
+	for name, v := range map[string]IUpper{
+		"proto": &Upper{},
+		"ctrie": NewUpperCtrie(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			hub := watch.NewHub()
+			w := NewUpperObservable(v, hub, "")
+
+			var all, value, counts []diff.Change
+			defer w.Watch("", func(c diff.Change) { all = append(all, c) }).Unsubscribe()
+			defer w.Watch("/lower/value", func(c diff.Change) { value = append(value, c) }).Unsubscribe()
+			defer w.Watch("/counts", func(c diff.Change) { counts = append(counts, c) }).Unsubscribe()
+
+			fillUpper(w)
+			checkUpper(t, v)
+
+			// replacing lower notifies the watcher of a field below it.
+			if len(value) != 1 || value[0].Path != "/lower" || value[0].Kind != diff.Added {
+				t.Fatalf("lower value: unexpected changes %v", value)
+			}
+			w.GetLowerInter().SetValue("changed")
+			if len(value) != 2 || value[1].Path != "/lower/value" || value[1].Kind != diff.Modified {
+				t.Fatalf("lower value: unexpected changes %v", value)
+			}
+
+			// setting a scalar map entry reports if the key was absent.
+			counts = nil
+			w.GetCountsInter().Set("x", 5)
+			w.GetCountsInter().Set("z", 0)
+			if len(counts) != 2 {
+				t.Fatalf("counts: unexpected changes %v", counts)
+			}
+			if c := counts[0]; c.Path != "/counts/x" || c.Kind != diff.Modified || c.Old != int64(1) {
+				t.Errorf("counts: unexpected change %v", c)
+			}
+			if c := counts[1]; c.Path != "/counts/z" || c.Kind != diff.Added {
+				t.Errorf("counts: unexpected change %v", c)
+			}
+
+			// map keys are path escaped.
+			w.GetLowerKvInter().Get("a/b").SetFlag(true)
+			if c := all[len(all)-1]; c.Path != "/lower_kv/a%2Fb/flag" {
+				t.Errorf("lower_kv: unexpected path %q", c.Path)
+			}
+		})
+	}
+}
+
+// TestWatchMatch tests matching changes to watched paths.
+func TestWatchMatch(t *testing.T) {
+	for _, c := range []struct {
+		path, changePath string
+		match            bool
+	}{
+		{"", "/lower/value", true},
+		{"/lower", "/lower", true},
+		{"/lower", "/lower/value", true},
+		{"/lower/value", "/lower", true},
+		{"/lower/value", "", true},
+		{"/lower", "/lower_kv", false},
+		{"/lower_kv", "/lower", false},
+		{"/lower/value", "/lower/flag", false},
+	} {
+		if m := watch.Match(c.path, c.changePath); m != c.match {
+			t.Errorf("Match(%q, %q): expected %v", c.path, c.changePath, c.match)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package journal records mutations of objects as typed operations, used by
+// the code generated with the journal generator.
+//
+// Operations are addressed by key path in the key/value layout, for example
+// /lower_kv/test/value, and values are encoded with kv.EncodeValue. A
+// journal can be written as JSON lines and replayed onto any implementation
+// of the interfaces to rebuild the same state.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+)
+
+// Op is the kind of a journal operation.
+type Op string
+
+const (
+	// OpSet sets the scalar value at the path.
+	OpSet Op = "set"
+	// OpObject sets an empty message or map at the path.
+	OpObject Op = "object"
+	// OpDelete clears the message or map at the path.
+	// For map entries with message values, sets the entry to nil.
+	OpDelete Op = "delete"
+)
+
+// Entry is an operation in a journal.
+type Entry struct {
+	// Path is the key path of the operation.
+	Path string `json:"path"`
+	// Op is the kind of operation.
+	Op Op `json:"op"`
+	// Value is the value encoded with kv.EncodeValue, for OpSet.
+	Value []byte `json:"value,omitempty"`
+}
+
+// DecodeValue decodes the value of the entry.
+func (e *Entry) DecodeValue() (interface{}, error) {
+	val, err := kv.DecodeValue(e.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %s", e.Path)
+	}
+	return val, nil
+}
+
+// ErrInvalidEntry is returned when replaying an entry which does not match
+// the fields of the target object.
+var ErrInvalidEntry = errors.New("invalid journal entry")
+
+// InvalidEntry returns an ErrInvalidEntry error for the entry.
+func InvalidEntry(e *Entry) error {
+	return errors.Wrapf(ErrInvalidEntry, "%s %s", e.Op, e.Path)
+}
+
+// SplitPath splits a key path into path segments.
+// Returns nil for the empty path of the root object.
+func SplitPath(keyPath string) []string {
+	if keyPath == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(keyPath, "/"), "/")
+}
+
+// Journal is a list of operations, safe for concurrent use.
+//
+// The recording methods do not return errors: the first error is recorded
+// and returned by Err.
+type Journal struct {
+	mtx     sync.Mutex
+	entries []Entry
+	err     error
+}
+
+// New builds a new empty journal.
+func New() *Journal {
+	return &Journal{}
+}
+
+// Err returns the first error encountered while recording, if any.
+func (j *Journal) Err() error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return j.err
+}
+
+// Append appends entries to the journal.
+func (j *Journal) Append(entries ...Entry) {
+	j.mtx.Lock()
+	j.entries = append(j.entries, entries...)
+	j.mtx.Unlock()
+}
+
+// Set records setting the scalar value at the path.
+func (j *Journal) Set(path string, value interface{}) {
+	data, err := kv.EncodeValue(value)
+	if err != nil {
+		j.mtx.Lock()
+		if j.err == nil {
+			j.err = errors.Wrapf(err, "encode %s", path)
+		}
+		j.mtx.Unlock()
+		return
+	}
+	j.Append(Entry{Path: path, Op: OpSet, Value: data})
+}
+
+// Object records setting an empty message or map at the path.
+func (j *Journal) Object(path string) {
+	j.Append(Entry{Path: path, Op: OpObject})
+}
+
+// Delete records clearing the message or map at the path.
+func (j *Journal) Delete(path string) {
+	j.Append(Entry{Path: path, Op: OpDelete})
+}
+
+// Len returns the number of entries.
+func (j *Journal) Len() int {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return len(j.entries)
+}
+
+// Entries returns a copy of the entries.
+func (j *Journal) Entries() []Entry {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return append([]Entry(nil), j.entries...)
+}
+
+// Reset removes all entries and the recorded error.
+func (j *Journal) Reset() {
+	j.mtx.Lock()
+	j.entries = nil
+	j.err = nil
+	j.mtx.Unlock()
+}
+
+// WriteTo writes the entries as JSON lines.
+func (j *Journal) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, entry := range j.Entries() {
+		data, err := json.Marshal(&entry)
+		if err != nil {
+			return n, err
+		}
+		data = append(data, '\n')
+		wn, err := w.Write(data)
+		n += int64(wn)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Read reads a journal written with WriteTo.
+func Read(r io.Reader) (*Journal, error) {
+	j := New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		j.entries = append(j.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// _ is a type assertion
+var _ io.WriterTo = ((*Journal)(nil))
@@ -0,0 +1,62 @@
+package bolt
+
+import (
+	"os"
+
+	"github.com/paralin/protods/kv"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DB is a bbolt database storing objects in top level buckets.
+type DB struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database file at the path.
+func Open(path string, mode os.FileMode, opts *bolt.Options) (*DB, error) {
+	db, err := bolt.Open(path, mode, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewDB(db), nil
+}
+
+// NewDB builds a new DB using an open bbolt database.
+func NewDB(db *bolt.DB) *DB {
+	return &DB{db: db}
+}
+
+// GetDB returns the underlying bbolt database.
+func (d *DB) GetDB() *bolt.DB {
+	return d.db
+}
+
+// View calls fn with the tree of the object in the named bucket, inside a
+// read-only transaction. Setters called within fn fail the transaction.
+func (d *DB) View(bucket string, fn func(tree *kv.Tree) error) error {
+	return d.db.View(func(tx *bolt.Tx) error {
+		store := NewStore(tx, bucket)
+		if err := fn(kv.NewTree(store)); err != nil {
+			return err
+		}
+		return store.Err()
+	})
+}
+
+// Update calls fn with the tree of the object in the named bucket, inside a
+// read-write transaction. All changes made within fn are committed
+// atomically if fn and the store do not return an error.
+func (d *DB) Update(bucket string, fn func(tree *kv.Tree) error) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		store := NewStore(tx, bucket)
+		if err := fn(kv.NewTree(store)); err != nil {
+			return err
+		}
+		return store.Err()
+	})
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,173 @@
+// Package bolt implements the kv.KeyValue contract over an embedded bbolt
+// database, with objects read and written inside transactions.
+package bolt
+
+import (
+	"strings"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store is a kv.KeyValue stored in a bucket of a bbolt transaction.
+//
+// Each key path segment is a nested bucket: /lower_kv/test/value is the
+// value key in the test bucket of the lower_kv bucket. Present message and
+// map fields are buckets, so iterating a map walks a bucket cursor.
+//
+// The KeyValue methods do not return errors: the first error is recorded
+// and returned by Err.
+type Store struct {
+	tx     *bolt.Tx
+	bucket []byte
+	err    error
+}
+
+// NewStore builds a new store in the named top level bucket of the transaction.
+// The store is valid until the transaction is closed.
+func NewStore(tx *bolt.Tx, bucket string) *Store {
+	return &Store{tx: tx, bucket: []byte(bucket)}
+}
+
+// Err returns the first error encountered by the store, if any.
+func (s *Store) Err() error {
+	return s.err
+}
+
+// setErr records an error.
+func (s *Store) setErr(err error) {
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// splitKey splits a key path into the parent bucket path and the last segment.
+func splitKey(key string) ([]string, []byte) {
+	segs := strings.Split(strings.Trim(key, "/"), "/")
+	return segs[:len(segs)-1], []byte(segs[len(segs)-1])
+}
+
+// lookupBucket returns the bucket at the path, or nil if it does not exist.
+func (s *Store) lookupBucket(path []string) *bolt.Bucket {
+	b := s.tx.Bucket(s.bucket)
+	for _, seg := range path {
+		if b == nil {
+			return nil
+		}
+		b = b.Bucket([]byte(seg))
+	}
+	return b
+}
+
+// createBucket returns the bucket at the path, creating it if necessary.
+func (s *Store) createBucket(path []string) (*bolt.Bucket, error) {
+	b, err := s.tx.CreateBucketIfNotExists(s.bucket)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range path {
+		name := []byte(seg)
+		if b.Get(name) != nil {
+			// replace a value with the bucket.
+			if err := b.Delete(name); err != nil {
+				return nil, err
+			}
+		}
+		b, err = b.CreateBucketIfNotExists(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Set stores the value for the key.
+// Storing a kv.Object creates a bucket at the key.
+func (s *Store) Set(key string, value interface{}) {
+	parentPath, name := splitKey(key)
+	if _, ok := value.(kv.Object); ok {
+		_, err := s.createBucket(append(parentPath, string(name)))
+		s.setErr(err)
+		return
+	}
+
+	data, err := kv.EncodeValue(value)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+	parent, err := s.createBucket(parentPath)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+	if parent.Bucket(name) != nil {
+		if err := parent.DeleteBucket(name); err != nil {
+			s.setErr(err)
+			return
+		}
+	}
+	s.setErr(parent.Put(name, data))
+}
+
+// Get returns the value for the key.
+// Buckets are returned as kv.Object.
+func (s *Store) Get(key string) (bool, interface{}) {
+	parentPath, name := splitKey(key)
+	parent := s.lookupBucket(parentPath)
+	if parent == nil {
+		return false, nil
+	}
+	if parent.Bucket(name) != nil {
+		return true, kv.Object{}
+	}
+
+	data := parent.Get(name)
+	if data == nil {
+		return false, nil
+	}
+	value, err := kv.DecodeValue(data)
+	if err != nil {
+		s.setErr(errors.Wrapf(err, "decode %s", key))
+		return false, nil
+	}
+	return true, value
+}
+
+// Delete removes the value for the key.
+// Removing a bucket removes all keys below it.
+func (s *Store) Delete(key string) {
+	parentPath, name := splitKey(key)
+	parent := s.lookupBucket(parentPath)
+	if parent == nil {
+		return
+	}
+	if parent.Bucket(name) != nil {
+		s.setErr(parent.DeleteBucket(name))
+		return
+	}
+	s.setErr(parent.Delete(name))
+}
+
+// List returns the keys in the bucket at the key path, in byte order.
+func (s *Store) List(keyPath string) []string {
+	var path []string
+	if keyPath = strings.Trim(keyPath, "/"); keyPath != "" {
+		path = strings.Split(keyPath, "/")
+	}
+	b := s.lookupBucket(path)
+	if b == nil {
+		return nil
+	}
+
+	var segs []string
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		segs = append(segs, string(k))
+	}
+	return segs
+}
+
+// _ is a type assertion
+var _ kv.Lister = ((*Store)(nil))
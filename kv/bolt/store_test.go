@@ -0,0 +1,126 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	"github.com/paralin/protods/kv/kvtest"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStore(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+
+	err = db.Update("upper", func(tree *kv.Tree) error {
+		kvtest.TestStore(t, tree.GetStore())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// TestViewRejectsWrites tests setters within View fail the transaction.
+func TestViewRejectsWrites(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+
+	err = db.Update("upper", func(tree *kv.Tree) error {
+		tree.Set("/id", "before")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = db.View("upper", func(tree *kv.Tree) error {
+		tree.Set("/id", "after")
+		tree.Set("/score", 1.5)
+		return nil
+	})
+	if err != bolt.ErrTxNotWritable {
+		t.Fatalf("expected ErrTxNotWritable, got %v", err)
+	}
+
+	err = db.View("upper", func(tree *kv.Tree) error {
+		expectGet(t, tree, "/id", "before")
+		expectGet(t, tree, "/score", nil)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// TestUpdateRollback tests a failed Update discards its changes.
+func TestUpdateRollback(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+
+	err = db.Update("upper", func(tree *kv.Tree) error {
+		tree.Set("/id", "before")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// an error returned by the callback.
+	errFail := errors.New("fail")
+	err = db.Update("upper", func(tree *kv.Tree) error {
+		tree.Set("/id", "after")
+		tree.Set("/lower_kv", kv.Object{})
+		tree.Set("/lower_kv/a/value", "a")
+		return errFail
+	})
+	if err != errFail {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+
+	// an error recorded by the store.
+	err = db.Update("upper", func(tree *kv.Tree) error {
+		tree.Set("/id", "after")
+		tree.Set("/invalid", struct{}{})
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error encoding an unsupported value")
+	}
+
+	err = db.View("upper", func(tree *kv.Tree) error {
+		expectGet(t, tree, "/id", "before")
+		expectGet(t, tree, "/lower_kv", nil)
+		expectGet(t, tree, "/invalid", nil)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// expectGet checks the value at the key, nil expects the key to be unset.
+func expectGet(t *testing.T, tree *kv.Tree, key string, expected interface{}) {
+	t.Helper()
+	found, value := tree.Get(key)
+	if expected == nil {
+		if found {
+			t.Errorf("%s: expected unset, got %v", key, value)
+		}
+		return
+	}
+	if !found || value != expected {
+		t.Errorf("%s: expected %v, got %v", key, expected, value)
+	}
+}
@@ -0,0 +1,87 @@
+package kv
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Value type tags used by EncodeValue.
+const (
+	tagObject  = 'o'
+	tagString  = 's'
+	tagBytes   = 'y'
+	tagBool    = 'b'
+	tagInt32   = 'i'
+	tagInt64   = 'I'
+	tagUint32  = 'u'
+	tagUint64  = 'U'
+	tagFloat32 = 'f'
+	tagFloat64 = 'F'
+)
+
+// EncodeValue encodes a value for stores holding bytes.
+// Supports Object and the Go types of proto scalar fields.
+// The encoding is a type tag followed by the value as text.
+func EncodeValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case Object:
+		return []byte{tagObject}, nil
+	case string:
+		return append([]byte{tagString}, v...), nil
+	case []byte:
+		return append([]byte{tagBytes}, v...), nil
+	case bool:
+		return strconv.AppendBool([]byte{tagBool}, v), nil
+	case int32:
+		return strconv.AppendInt([]byte{tagInt32}, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt([]byte{tagInt64}, v, 10), nil
+	case uint32:
+		return strconv.AppendUint([]byte{tagUint32}, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint([]byte{tagUint64}, v, 10), nil
+	case float32:
+		return strconv.AppendFloat([]byte{tagFloat32}, float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.AppendFloat([]byte{tagFloat64}, v, 'g', -1, 64), nil
+	default:
+		return nil, errors.Errorf("unsupported value type %T", value)
+	}
+}
+
+// DecodeValue decodes a value encoded with EncodeValue.
+func DecodeValue(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	text := string(data[1:])
+	switch data[0] {
+	case tagObject:
+		return Object{}, nil
+	case tagString:
+		return text, nil
+	case tagBytes:
+		return append([]byte{}, data[1:]...), nil
+	case tagBool:
+		return strconv.ParseBool(text)
+	case tagInt32:
+		v, err := strconv.ParseInt(text, 10, 32)
+		return int32(v), err
+	case tagInt64:
+		return strconv.ParseInt(text, 10, 64)
+	case tagUint32:
+		v, err := strconv.ParseUint(text, 10, 32)
+		return uint32(v), err
+	case tagUint64:
+		return strconv.ParseUint(text, 10, 64)
+	case tagFloat32:
+		v, err := strconv.ParseFloat(text, 32)
+		return float32(v), err
+	case tagFloat64:
+		return strconv.ParseFloat(text, 64)
+	default:
+		return nil, errors.Errorf("unknown value type tag %q", data[0])
+	}
+}
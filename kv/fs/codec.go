@@ -0,0 +1,123 @@
+package fs
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+)
+
+// Codec encodes the values stored in the files.
+type Codec interface {
+	// Encode encodes a scalar value.
+	Encode(value interface{}) ([]byte, error)
+	// Decode decodes a value encoded with Encode.
+	Decode(data []byte) (interface{}, error)
+}
+
+// TaggedCodec encodes values with kv.EncodeValue, a type tag followed by the
+// value as text, for example "sa" for the string "a".
+type TaggedCodec struct{}
+
+// Encode encodes a scalar value.
+func (TaggedCodec) Encode(value interface{}) ([]byte, error) {
+	return kv.EncodeValue(value)
+}
+
+// Decode decodes a value encoded with Encode.
+func (TaggedCodec) Decode(data []byte) (interface{}, error) {
+	return kv.DecodeValue(data)
+}
+
+// JSONCodec encodes values as indented JSON objects with the type and the
+// value, for example {"type": "string", "value": "a"}.
+// Bytes values are base64 encoded. NaN and infinite floats are not
+// supported by JSON.
+type JSONCodec struct{}
+
+// jsonValue is the JSON encoding of a value.
+type jsonValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Encode encodes a scalar value.
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	var typeName string
+	switch value.(type) {
+	case string:
+		typeName = "string"
+	case []byte:
+		typeName = "bytes"
+	case bool:
+		typeName = "bool"
+	case int32:
+		typeName = "int32"
+	case int64:
+		typeName = "int64"
+	case uint32:
+		typeName = "uint32"
+	case uint64:
+		typeName = "uint64"
+	case float32:
+		typeName = "float"
+	case float64:
+		typeName = "double"
+	default:
+		return nil, errors.Errorf("unsupported value type %T", value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.MarshalIndent(&jsonValue{Type: typeName, Value: data}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Decode decodes a value encoded with Encode.
+func (JSONCodec) Decode(data []byte) (interface{}, error) {
+	var jv jsonValue
+	if err := json.Unmarshal(data, &jv); err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch jv.Type {
+	case "string":
+		value = new(string)
+	case "bytes":
+		value = new([]byte)
+	case "bool":
+		value = new(bool)
+	case "int32":
+		value = new(int32)
+	case "int64":
+		value = new(int64)
+	case "uint32":
+		value = new(uint32)
+	case "uint64":
+		value = new(uint64)
+	case "float":
+		value = new(float32)
+	case "double":
+		value = new(float64)
+	default:
+		return nil, errors.Errorf("unknown value type %q", jv.Type)
+	}
+	if err := json.Unmarshal(jv.Value, value); err != nil {
+		return nil, err
+	}
+
+	return reflect.ValueOf(value).Elem().Interface(), nil
+}
+
+// _ is a type assertion
+var (
+	_ Codec = TaggedCodec{}
+	_ Codec = JSONCodec{}
+)
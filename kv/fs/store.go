@@ -0,0 +1,228 @@
+// Package fs implements the kv.KeyValue contract over a directory tree, with
+// one file per key so objects can be reviewed and diffed in version control.
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+)
+
+// Permissions of the created files and directories, before the umask.
+const (
+	FileMode os.FileMode = 0644
+	DirMode  os.FileMode = 0755
+)
+
+// tempPrefix is the prefix of the temporary files written before a rename.
+const tempPrefix = ".tmp-"
+
+// escapedPrefix replaces the leading "." of path segments and names empty
+// segments. Path escaped map keys never contain it.
+const escapedPrefix = "%2E"
+
+// Store is a kv.KeyValue stored as files below a root directory.
+//
+// Each key path is a path below the root: /lower_kv/test/value is the file
+// lower_kv/test/value. Present message and map fields are directories, so
+// iterating a map lists a directory. Values are written to a temporary file
+// in the same directory and renamed over the old file, so readers never see
+// partial writes.
+//
+// Path segments starting with "." and empty segments are prefixed with %2E,
+// so map keys cannot name hidden files or leave the root directory.
+//
+// The KeyValue methods do not return errors: the first error is recorded
+// and returned by Err.
+type Store struct {
+	root  string
+	codec Codec
+
+	errMtx sync.Mutex
+	err    error
+}
+
+// NewStore builds a new store below the root directory.
+// The codec encodes the file contents, nil selects TaggedCodec.
+func NewStore(root string, codec Codec) *Store {
+	if codec == nil {
+		codec = TaggedCodec{}
+	}
+	return &Store{root: root, codec: codec}
+}
+
+// GetRoot returns the root directory.
+func (s *Store) GetRoot() string {
+	return s.root
+}
+
+// Err returns the first error encountered by the store, if any.
+func (s *Store) Err() error {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+	return s.err
+}
+
+// setErr records an error.
+func (s *Store) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	s.errMtx.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errMtx.Unlock()
+}
+
+// fileName returns the file name of a path segment.
+func fileName(seg string) string {
+	if seg == "" || seg[0] == '.' {
+		return escapedPrefix + seg
+	}
+	return seg
+}
+
+// segName returns the path segment of a file name.
+func segName(name string) string {
+	return strings.TrimPrefix(name, escapedPrefix)
+}
+
+// filePath returns the path of the file for a key path.
+func (s *Store) filePath(keyPath string) string {
+	elems := []string{s.root}
+	if keyPath != "" {
+		for _, seg := range strings.Split(strings.TrimPrefix(keyPath, "/"), "/") {
+			elems = append(elems, fileName(seg))
+		}
+	}
+	return filepath.Join(elems...)
+}
+
+// Set stores the value for the key.
+// Object values create a directory, replacing a file at the path.
+func (s *Store) Set(key string, value interface{}) {
+	p := s.filePath(key)
+	if _, ok := value.(kv.Object); ok {
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			if err := os.Remove(p); err != nil {
+				s.setErr(err)
+				return
+			}
+		}
+		s.setErr(os.MkdirAll(p, DirMode))
+		return
+	}
+
+	data, err := s.codec.Encode(value)
+	if err != nil {
+		s.setErr(errors.Wrapf(err, "encode %s", key))
+		return
+	}
+	s.setErr(s.writeFile(p, data))
+}
+
+// writeFile atomically replaces the file at the path, replacing a directory.
+func (s *Store) writeFile(p string, data []byte) error {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, tempPrefix)
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(FileMode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		if fi, serr := os.Stat(p); serr == nil && fi.IsDir() {
+			err = os.RemoveAll(p)
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, p)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
+
+// Get returns the value for the key. Directories are Object values.
+func (s *Store) Get(key string) (bool, interface{}) {
+	p := s.filePath(key)
+	fi, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		s.setErr(err)
+		return false, nil
+	}
+	if fi.IsDir() {
+		return true, kv.Object{}
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.setErr(err)
+		}
+		return false, nil
+	}
+	value, err := s.codec.Decode(data)
+	if err != nil {
+		s.setErr(errors.Wrapf(err, "decode %s", key))
+		return false, nil
+	}
+	return true, value
+}
+
+// Delete removes the value for the key and all keys below it.
+func (s *Store) Delete(key string) {
+	s.setErr(os.RemoveAll(s.filePath(key)))
+}
+
+// List returns the sorted path segments of the keys directly below the key
+// path. Hidden files, such as temporary files, are skipped.
+// Scalar values have no keys below them.
+func (s *Store) List(keyPath string) []string {
+	p := s.filePath(keyPath)
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		if fi, serr := os.Stat(p); serr == nil && !fi.IsDir() {
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			s.setErr(err)
+		}
+		return nil
+	}
+
+	var segs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		segs = append(segs, segName(name))
+	}
+	sort.Strings(segs)
+	return segs
+}
+
+// _ is a type assertion
+var _ kv.Lister = ((*Store)(nil))
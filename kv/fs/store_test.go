@@ -0,0 +1,15 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/kv/kvtest"
+)
+
+func TestStore(t *testing.T) {
+	kvtest.TestStore(t, NewStore(t.TempDir(), TaggedCodec{}))
+}
+
+func TestStoreJSON(t *testing.T) {
+	kvtest.TestStore(t, NewStore(t.TempDir(), JSONCodec{}))
+}
@@ -0,0 +1,191 @@
+package httpkv
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+)
+
+// maxBatchKeys is the maximum number of keys loaded in one request.
+const maxBatchKeys = 1000
+
+// Client is a kv.KeyValue stored by a Server.
+//
+// GetBatch loads the keys with one request, so kv.Tree.Prefetch reads a
+// subtree with one request per level of the tree.
+//
+// The KeyValue methods do not return errors: the first error is recorded
+// and returned by Err.
+type Client struct {
+	baseURL string
+	client  *http.Client
+
+	errMtx sync.Mutex
+	err    error
+}
+
+// NewClient builds a new client for the server at the base URL, for example
+// "http://localhost:8080/upper". A nil client selects http.DefaultClient.
+func NewClient(baseURL string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Err returns the first error encountered by the client, if any.
+func (c *Client) Err() error {
+	c.errMtx.Lock()
+	defer c.errMtx.Unlock()
+	return c.err
+}
+
+// setErr records an error.
+func (c *Client) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	c.errMtx.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.errMtx.Unlock()
+}
+
+// do sends a request and returns the response body.
+// Returns nil and no error if the server responds that the value is not set.
+// Other 404 responses, for example to a wrong base URL, are errors.
+func (c *Client) do(method, urlPath, contentType string, body []byte) ([]byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.baseURL+urlPath, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && resp.Header.Get(NotFoundHeader) != "" {
+		return nil, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf(
+			"%s %s: %s: %s",
+			method, urlPath, resp.Status, strings.TrimSpace(string(data)),
+		)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// Set stores the value for the key.
+func (c *Client) Set(key string, value interface{}) {
+	data, err := kv.EncodeValue(value)
+	if err != nil {
+		c.setErr(errors.Wrapf(err, "encode %s", key))
+		return
+	}
+	_, err = c.do(http.MethodPut, valuePath+EscapeKey(key), "application/octet-stream", data)
+	c.setErr(err)
+}
+
+// Get returns the value for the key.
+func (c *Client) Get(key string) (bool, interface{}) {
+	data, err := c.do(http.MethodGet, valuePath+EscapeKey(key), "", nil)
+	if err != nil || data == nil {
+		c.setErr(err)
+		return false, nil
+	}
+	value, err := kv.DecodeValue(data)
+	if err != nil {
+		c.setErr(errors.Wrapf(err, "decode %s", key))
+		return false, nil
+	}
+	return true, value
+}
+
+// GetBatch returns the values for the keys that are set.
+func (c *Client) GetBatch(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	for len(keys) != 0 {
+		batch := keys
+		if len(batch) > maxBatchKeys {
+			batch = batch[:maxBatchKeys]
+		}
+		keys = keys[len(batch):]
+
+		body, err := json.Marshal(&batchRequest{Keys: batch})
+		if err != nil {
+			c.setErr(err)
+			return values
+		}
+		data, err := c.do(http.MethodPost, batchPath, "application/json", body)
+		if err != nil {
+			c.setErr(err)
+			return values
+		}
+		var resp batchResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			c.setErr(err)
+			return values
+		}
+		for key, data := range resp.Values {
+			value, err := kv.DecodeValue(data)
+			if err != nil {
+				c.setErr(errors.Wrapf(err, "decode %s", key))
+				continue
+			}
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// Delete removes the value for the key.
+func (c *Client) Delete(key string) {
+	_, err := c.do(http.MethodDelete, valuePath+EscapeKey(key), "", nil)
+	c.setErr(err)
+}
+
+// List returns the path segments of the keys directly below the key path.
+func (c *Client) List(keyPath string) []string {
+	data, err := c.do(http.MethodGet, listPath+EscapeKey(keyPath), "", nil)
+	if err != nil || data == nil {
+		c.setErr(err)
+		return nil
+	}
+	var segs []string
+	if err := json.Unmarshal(data, &segs); err != nil {
+		c.setErr(err)
+		return nil
+	}
+	return segs
+}
+
+// _ is a type assertion
+var (
+	_ kv.Lister      = ((*Client)(nil))
+	_ kv.BatchGetter = ((*Client)(nil))
+)
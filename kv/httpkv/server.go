@@ -0,0 +1,272 @@
+// Package httpkv exposes a kv.KeyValue store over HTTP and implements a
+// kv.KeyValue client for it, so objects in one process can be backed by a
+// store in another.
+//
+// Values are encoded with kv.EncodeValue. The endpoints are:
+//
+//	GET    /v/<key path>     returns the value, 404 with NotFoundHeader if it is not set
+//	PUT    /v/<key path>     sets the value to the request body
+//	DELETE /v/<key path>     deletes the value
+//	GET    /list/<key path>  returns the JSON array of segments below the key path
+//	POST   /batch            returns the values of the JSON array of keys
+//
+// The key path is escaped in the URL with EscapeKey.
+package httpkv
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/paralin/protods/kv"
+)
+
+// Endpoint path prefixes.
+const (
+	valuePath = "/v"
+	listPath  = "/list"
+	batchPath = "/batch"
+)
+
+// maxValueSize is the maximum size of a PUT request body.
+const maxValueSize = 32 << 20
+
+// NotFoundHeader is set on 404 responses for values that are not set, to
+// distinguish them from requests to a wrong URL.
+const NotFoundHeader = "X-Kv-Not-Found"
+
+// batchRequest is the body of a batch request.
+type batchRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// batchResponse is the body of a batch response.
+// Values are encoded with kv.EncodeValue and base64 encoded by JSON.
+type batchResponse struct {
+	Values map[string][]byte `json:"values"`
+}
+
+// Server is a http.Handler exposing a store.
+// Use http.StripPrefix to mount it below a path.
+type Server struct {
+	newStore func() kv.KeyValue
+}
+
+// errStore is a store recording the first error, returned by Err.
+type errStore interface {
+	Err() error
+}
+
+// NewServer builds a new server exposing the store returned by newStore,
+// which is called once per request.
+// Listing requires the store to implement kv.Lister. Batches use GetBatch if
+// the store implements kv.BatchGetter. If the store has an Err method, the
+// request fails with 500 Internal Server Error if it returns an error. The
+// stores record their first error, so each request uses a new store: an
+// error is only returned to the request causing it.
+func NewServer(newStore func() kv.KeyValue) *Server {
+	return &Server{newStore: newStore}
+}
+
+// checkStoreErr writes a 500 response if the store has recorded an error.
+// Returns false if the response was written.
+func checkStoreErr(rw http.ResponseWriter, store kv.KeyValue) bool {
+	es, ok := store.(errStore)
+	if !ok {
+		return true
+	}
+	if err := es.Err(); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// ServeHTTP handles a request.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	p := req.URL.EscapedPath()
+	switch {
+	case p == batchPath:
+		s.serveBatch(rw, req, s.newStore())
+	case hasPathPrefix(p, valuePath):
+		s.serveValue(rw, req, s.newStore(), strings.TrimPrefix(p, valuePath))
+	case hasPathPrefix(p, listPath):
+		s.serveList(rw, req, s.newStore(), strings.TrimPrefix(p, listPath))
+	default:
+		http.NotFound(rw, req)
+	}
+}
+
+// hasPathPrefix checks if the path is the prefix or below it.
+func hasPathPrefix(p, prefix string) bool {
+	return p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// EscapeKey returns the URL path of a key path.
+//
+// Each segment is path escaped. Segments that are empty, start with "." or
+// start with "~" are prefixed with "~", so routers cleaning the URL path do
+// not remove or resolve them.
+func EscapeKey(keyPath string) string {
+	segs := strings.Split(keyPath, "/")
+	for i, seg := range segs {
+		if i == 0 && seg == "" {
+			continue
+		}
+		seg = url.PathEscape(seg)
+		if seg == "" || seg[0] == '.' || seg[0] == '~' {
+			seg = "~" + seg
+		}
+		segs[i] = seg
+	}
+	return strings.Join(segs, "/")
+}
+
+// UnescapeKey returns the key path of a URL path built by EscapeKey.
+func UnescapeKey(escapedPath string) (string, error) {
+	segs := strings.Split(escapedPath, "/")
+	for i, seg := range segs {
+		if i == 0 && seg == "" {
+			continue
+		}
+		seg, err := url.PathUnescape(seg)
+		if err != nil {
+			return "", err
+		}
+		segs[i] = strings.TrimPrefix(seg, "~")
+	}
+	return strings.Join(segs, "/"), nil
+}
+
+// serveValue handles a request to get, set or delete a value.
+func (s *Server) serveValue(rw http.ResponseWriter, req *http.Request, store kv.KeyValue, escapedKey string) {
+	key, err := UnescapeKey(escapedKey)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		found, value := store.Get(key)
+		if !checkStoreErr(rw, store) {
+			return
+		}
+		if !found {
+			rw.Header().Set(NotFoundHeader, "1")
+			http.NotFound(rw, req)
+			return
+		}
+		data, err := kv.EncodeValue(value)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = rw.Write(data)
+	case http.MethodPut:
+		data, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, maxValueSize))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := kv.DecodeValue(data)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		store.Set(key, value)
+		if !checkStoreErr(rw, store) {
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		store.Delete(key)
+		if !checkStoreErr(rw, store) {
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveList handles a request to list the segments below a key path.
+func (s *Server) serveList(rw http.ResponseWriter, req *http.Request, store kv.KeyValue, escapedKey string) {
+	if req.Method != http.MethodGet {
+		rw.Header().Set("Allow", "GET")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lister, ok := store.(kv.Lister)
+	if !ok {
+		http.Error(rw, "store does not support listing", http.StatusNotImplemented)
+		return
+	}
+	key, err := UnescapeKey(escapedKey)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	segs := lister.List(key)
+	if !checkStoreErr(rw, store) {
+		return
+	}
+	if segs == nil {
+		segs = []string{}
+	}
+	writeJSON(rw, segs)
+}
+
+// serveBatch handles a request to get several values.
+func (s *Server) serveBatch(rw http.ResponseWriter, req *http.Request, store kv.KeyValue) {
+	if req.Method != http.MethodPost {
+		rw.Header().Set("Allow", "POST")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var breq batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, req.Body, maxValueSize)).Decode(&breq); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var values map[string]interface{}
+	if batch, ok := store.(kv.BatchGetter); ok {
+		values = batch.GetBatch(breq.Keys)
+	} else {
+		values = make(map[string]interface{}, len(breq.Keys))
+		for _, key := range breq.Keys {
+			if found, value := store.Get(key); found {
+				values[key] = value
+			}
+		}
+	}
+	if !checkStoreErr(rw, store) {
+		return
+	}
+
+	resp := batchResponse{Values: make(map[string][]byte, len(values))}
+	for key, value := range values {
+		data, err := kv.EncodeValue(value)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Values[key] = data
+	}
+	writeJSON(rw, &resp)
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+// _ is a type assertion
+var _ http.Handler = ((*Server)(nil))
@@ -0,0 +1,142 @@
+package httpkv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	kvfs "github.com/paralin/protods/kv/fs"
+	"github.com/paralin/protods/kv/kvtest"
+	"github.com/pkg/errors"
+)
+
+// TestRoundTrip tests the client against a server over HTTP.
+func TestRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	srv := httptest.NewServer(NewServer(func() kv.KeyValue {
+		return kvfs.NewStore(root, nil)
+	}))
+	defer srv.Close()
+
+	kvtest.TestStore(t, NewClient(srv.URL, srv.Client()))
+}
+
+// failStore is a store failing every operation.
+type failStore struct{}
+
+func (failStore) Set(key string, value interface{})  {}
+func (failStore) Get(key string) (bool, interface{}) { return false, nil }
+func (failStore) Delete(key string)                  {}
+func (failStore) List(keyPath string) []string       { return nil }
+func (failStore) Err() error                         { return errors.New("store failed") }
+
+// TestStoreErr tests store errors are returned to the client.
+func TestStoreErr(t *testing.T) {
+	srv := httptest.NewServer(NewServer(func() kv.KeyValue { return failStore{} }))
+	defer srv.Close()
+
+	ops := map[string]func(c *Client){
+		"set":    func(c *Client) { c.Set("/id", "test") },
+		"get":    func(c *Client) { c.Get("/id") },
+		"delete": func(c *Client) { c.Delete("/id") },
+		"list":   func(c *Client) { c.List("/lower_kv") },
+		"batch":  func(c *Client) { c.GetBatch([]string{"/id"}) },
+	}
+	for name, op := range ops {
+		client := NewClient(srv.URL, srv.Client())
+		op(client)
+		if client.Err() == nil {
+			t.Errorf("%s: expected the store error", name)
+		}
+	}
+}
+
+// failKeyStore is a store recording an error when setting the key /fail.
+type failKeyStore struct {
+	*kvfs.Store
+	err error
+}
+
+func (s *failKeyStore) Set(key string, value interface{}) {
+	if key == "/fail" {
+		s.err = errors.New("store failed")
+		return
+	}
+	s.Store.Set(key, value)
+}
+
+func (s *failKeyStore) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.Store.Err()
+}
+
+// TestStoreErrPerRequest tests a store error fails only the request causing it.
+func TestStoreErrPerRequest(t *testing.T) {
+	root := t.TempDir()
+	srv := httptest.NewServer(NewServer(func() kv.KeyValue {
+		return &failKeyStore{Store: kvfs.NewStore(root, nil)}
+	}))
+	defer srv.Close()
+
+	failing := NewClient(srv.URL, srv.Client())
+	failing.Set("/fail", "test")
+	if failing.Err() == nil {
+		t.Fatal("expected the store error")
+	}
+
+	client := NewClient(srv.URL, srv.Client())
+	client.Set("/id", "test")
+	if found, value := client.Get("/id"); !found || value != "test" {
+		t.Fatalf("unexpected value: %v", value)
+	}
+	if err := client.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// TestNotFound tests only values that are not set are reported as not found.
+func TestNotFound(t *testing.T) {
+	root := t.TempDir()
+	mux := http.NewServeMux()
+	mux.Handle("/upper/", http.StripPrefix("/upper", NewServer(func() kv.KeyValue {
+		return kvfs.NewStore(root, nil)
+	})))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/upper", srv.Client())
+	if found, _ := client.Get("/id"); found {
+		t.Fatal("expected /id not to be set")
+	}
+	if err := client.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	wrong := NewClient(srv.URL+"/wrong", srv.Client())
+	if found, _ := wrong.Get("/id"); found {
+		t.Fatal("expected /id not to be found")
+	}
+	if wrong.Err() == nil {
+		t.Fatal("expected an error for a 404 from a wrong URL")
+	}
+}
+
+// TestEscapeKey tests escaped keys survive URL path cleaning.
+func TestEscapeKey(t *testing.T) {
+	for _, key := range []string{"/id", "/lower_kv/a%2Fb/value", "/lower_kv//value", "/lower_kv/../x", "/lower_kv/~a"} {
+		escaped := EscapeKey(key)
+		unescaped, err := UnescapeKey(escaped)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if unescaped != key {
+			t.Errorf("expected %q, got %q via %q", key, unescaped, escaped)
+		}
+	}
+}
+
+// _ is a type assertion
+var _ kv.Lister = failStore{}
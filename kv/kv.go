@@ -0,0 +1,52 @@
+// Package kv contains the key/value store contract and the runtime used by
+// the code generated with the kv generator.
+//
+// Objects are stored with one key per field, for example /lower/value for
+// upper.GetLower().GetValue(). Present message and map fields are marked
+// with an Object value at their key path, map entries are stored below it
+// with path escaped keys, for example /lower_kv/test/value.
+package kv
+
+// KeyValue contains values for a protobuf in a K/V store.
+// Keys are generated using the field name.
+type KeyValue interface {
+	// Set stores the value for the key.
+	Set(key string, value interface{})
+	// Get returns the value for the key.
+	Get(key string) (bool, interface{})
+	// Delete removes the value for the key.
+	Delete(key string)
+}
+
+// Lister is a KeyValue that can list its keys.
+// Required to iterate over map fields.
+type Lister interface {
+	KeyValue
+	// List returns the path segments of the keys directly below the key path.
+	List(keyPath string) []string
+}
+
+// BatchGetter is a KeyValue that can load several keys in one round trip.
+type BatchGetter interface {
+	KeyValue
+	// GetBatch returns the values for the keys that are set.
+	GetBatch(keys []string) map[string]interface{}
+}
+
+// Object is the value stored at the key path of a present message or map.
+type Object struct{}
+
+// JoinKey returns the key path of a child segment below keyPath.
+func JoinKey(keyPath, seg string) string {
+	return keyPath + "/" + seg
+}
+
+// SplitKey splits a key path into the parent key path and the last segment.
+func SplitKey(keyPath string) (string, string) {
+	for i := len(keyPath) - 1; i >= 0; i-- {
+		if keyPath[i] == '/' {
+			return keyPath[:i], keyPath[i+1:]
+		}
+	}
+	return "", keyPath
+}
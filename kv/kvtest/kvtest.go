@@ -0,0 +1,117 @@
+// Package kvtest implements a conformance test for kv.KeyValue stores.
+package kvtest
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/paralin/protods/kv"
+)
+
+// errStore is a store recording the first error.
+type errStore interface {
+	Err() error
+}
+
+// testValues are stored by TestStore, covering the value types.
+var testValues = map[string]interface{}{
+	"/string":            "hello / world",
+	"/bytes":             []byte{0, 1, 0xff},
+	"/bool":              true,
+	"/int32":             int32(math.MinInt32),
+	"/int64":             int64(math.MinInt64),
+	"/uint32":            uint32(math.MaxUint32),
+	"/uint64":            uint64(math.MaxUint64),
+	"/float32":           float32(1.5),
+	"/float64":           -2.25,
+	"/lower":             kv.Object{},
+	"/lower/value":       "nested",
+	"/lower_kv":          kv.Object{},
+	"/lower_kv/a%2Fb":    kv.Object{},
+	"/lower_kv/a%2Fb/id": "escaped",
+	"/lower_kv/c":        kv.Object{},
+}
+
+// TestStore tests the store implements the kv.KeyValue contract, and
+// kv.Lister and kv.BatchGetter if implemented. The store should be empty.
+// If the store has an Err method, it is checked to return nil at the end.
+func TestStore(t *testing.T, store kv.KeyValue) {
+	t.Helper()
+
+	for key, value := range testValues {
+		store.Set(key, value)
+	}
+	for key, value := range testValues {
+		expectValue(t, store, key, value)
+	}
+	if found, value := store.Get("/missing"); found {
+		t.Errorf("get /missing: expected not found, got %#v", value)
+	}
+
+	store.Set("/string", "overwritten")
+	expectValue(t, store, "/string", "overwritten")
+
+	if lister, ok := store.(kv.Lister); ok {
+		expectList(t, lister, "/lower_kv", []string{"a%2Fb", "c"})
+		expectList(t, lister, "/missing", nil)
+	}
+
+	if getter, ok := store.(kv.BatchGetter); ok {
+		values := getter.GetBatch([]string{"/int64", "/lower/value", "/missing"})
+		expected := map[string]interface{}{
+			"/int64":       testValues["/int64"],
+			"/lower/value": testValues["/lower/value"],
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("get batch: expected %#v, got %#v", expected, values)
+		}
+	}
+
+	store.Delete("/bool")
+	if found, value := store.Get("/bool"); found {
+		t.Errorf("get /bool after delete: expected not found, got %#v", value)
+	}
+
+	if lister, ok := store.(kv.Lister); ok {
+		tree := kv.NewTree(store)
+		tree.DeleteTree("/lower_kv/a%2Fb")
+		if found, value := store.Get("/lower_kv/a%2Fb/id"); found {
+			t.Errorf("get below deleted tree: expected not found, got %#v", value)
+		}
+		expectList(t, lister, "/lower_kv", []string{"c"})
+	}
+
+	if es, ok := store.(errStore); ok {
+		if err := es.Err(); err != nil {
+			t.Errorf("store error: %v", err)
+		}
+	}
+}
+
+// expectValue checks the value stored at the key.
+func expectValue(t *testing.T, store kv.KeyValue, key string, expected interface{}) {
+	t.Helper()
+	found, value := store.Get(key)
+	if !found {
+		t.Errorf("get %s: expected %#v, not found", key, expected)
+		return
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("get %s: expected %#v, got %#v", key, expected, value)
+	}
+}
+
+// expectList checks the segments listed below the key path, in any order.
+func expectList(t *testing.T, lister kv.Lister, keyPath string, expected []string) {
+	t.Helper()
+	segs := append([]string(nil), lister.List(keyPath)...)
+	sort.Strings(segs)
+	if len(segs) == 0 && len(expected) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(segs, expected) {
+		t.Errorf("list %s: expected %q, got %q", keyPath, expected, segs)
+	}
+}
@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Client is a minimal Redis client speaking RESP over a single connection.
+// It is safe for concurrent use, commands are serialized.
+// After a write, read or protocol error the connection is closed, as the
+// replies can no longer be matched to the commands, and later commands fail.
+type Client struct {
+	mtx  sync.Mutex
+	conn io.ReadWriteCloser
+	r    *bufio.Reader
+	w    *bufio.Writer
+	err  error
+}
+
+// Error is an error reply from the server.
+type Error string
+
+// Error returns the error message.
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Dial connects to a Redis server at the TCP address.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient builds a client over an established connection.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+}
+
+// Do sends a command and returns the reply.
+// Replies are nil, string, int64, Error or []interface{}.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	replies, err := c.Pipeline([][]string{args})
+	if err != nil {
+		return nil, err
+	}
+	if rerr, ok := replies[0].(Error); ok {
+		return nil, rerr
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends the commands in one round trip and returns the replies.
+// Error replies are returned in the reply list.
+func (c *Client) Pipeline(cmds [][]string) ([]interface{}, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	for _, args := range cmds {
+		writeCommand(c.w, args)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, c.fail(err)
+	}
+
+	replies := make([]interface{}, len(cmds))
+	for i := range replies {
+		reply, err := ReadReply(c.r)
+		if err != nil {
+			return nil, c.fail(err)
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// fail closes the connection after an error, the unread replies would be
+// returned for later commands otherwise. Expects mtx to be locked.
+func (c *Client) fail(err error) error {
+	c.err = errors.Wrap(err, "redis connection closed")
+	_ = c.conn.Close()
+	return err
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return nil
+	}
+	c.err = errors.New("redis client closed")
+	return c.conn.Close()
+}
+
+// writeCommand writes a command as an array of bulk strings.
+func writeCommand(w *bufio.Writer, args []string) {
+	_, _ = w.WriteString("*")
+	_, _ = w.WriteString(strconv.Itoa(len(args)))
+	_, _ = w.WriteString("\r\n")
+	for _, arg := range args {
+		_, _ = w.WriteString("$")
+		_, _ = w.WriteString(strconv.Itoa(len(arg)))
+		_, _ = w.WriteString("\r\n")
+		_, _ = w.WriteString(arg)
+		_, _ = w.WriteString("\r\n")
+	}
+}
+
+// ReadReply reads a RESP value.
+// Returns nil, string, int64, Error or []interface{}.
+func ReadReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "bulk string length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "array length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]interface{}, n)
+		for i := range arr {
+			arr[i], err = ReadReply(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	default:
+		return nil, errors.Errorf("unexpected reply type %q", line[0])
+	}
+}
+
+// readLine reads a CRLF terminated line without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("malformed line in reply")
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"bufio"
+	"net"
+	"testing"
+)
+
+// TestPipelineReadError tests the connection is closed when a reply cannot
+// be read, instead of returning the unread replies to later commands.
+func TestPipelineReadError(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	go func() {
+		defer serverConn.Close()
+		r := bufio.NewReader(serverConn)
+		for i := 0; i < 2; i++ {
+			if _, err := ReadReply(r); err != nil {
+				return
+			}
+		}
+		// the first reply is malformed, the second is left unread.
+		_, _ = serverConn.Write([]byte("?bad\r\n+OK\r\n"))
+		_, _ = ReadReply(r)
+	}()
+
+	client := NewClient(clientConn)
+	if _, err := client.Pipeline([][]string{{"GET", "a"}, {"GET", "b"}}); err == nil {
+		t.Fatal("expected protocol error")
+	}
+	reply, err := client.Do("GET", "c")
+	if err == nil {
+		t.Fatalf("expected error after protocol error, got reply %#v", reply)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,214 @@
+// Package redistest implements an in-process fake Redis server for testing
+// code using the redis store.
+package redistest
+
+import (
+	"bufio"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/kv/redis"
+)
+
+// Server is an in-memory fake Redis server.
+// Supports the string and set commands used by redis.Store.
+type Server struct {
+	ln net.Listener
+
+	mtx     sync.Mutex
+	strings map[string]string
+	sets    map[string]map[string]struct{}
+	cmds    int
+}
+
+// NewServer starts a new server listening on a local port.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		ln:      ln,
+		strings: make(map[string]string),
+		sets:    make(map[string]map[string]struct{}),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address of the server.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Dial connects a new client to the server.
+func (s *Server) Dial() (*redis.Client, error) {
+	return redis.Dial(s.Addr())
+}
+
+// Commands returns the number of commands handled by the server.
+func (s *Server) Commands() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.cmds
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.ln.Close()
+}
+
+// serve accepts connections until the listener is closed.
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle serves the commands on a connection.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		req, err := redis.ReadReply(r)
+		if err != nil {
+			return
+		}
+		arr, _ := req.([]interface{})
+		args := make([]string, len(arr))
+		for i, arg := range arr {
+			args[i], _ = arg.(string)
+		}
+
+		s.exec(w, args)
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec executes a command and writes the reply.
+func (s *Server) exec(w *bufio.Writer, args []string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.cmds++
+	if len(args) == 0 {
+		writeError(w, "ERR empty command")
+		return
+	}
+
+	switch cmd := strings.ToUpper(args[0]); {
+	case cmd == "PING":
+		_, _ = w.WriteString("+PONG\r\n")
+	case cmd == "GET" && len(args) == 2:
+		value, ok := s.strings[args[1]]
+		writeBulk(w, value, ok)
+	case cmd == "MGET" && len(args) >= 2:
+		writeArrayLen(w, len(args)-1)
+		for _, key := range args[1:] {
+			value, ok := s.strings[key]
+			writeBulk(w, value, ok)
+		}
+	case cmd == "SET" && len(args) == 3:
+		s.strings[args[1]] = args[2]
+		_, _ = w.WriteString("+OK\r\n")
+	case cmd == "DEL" && len(args) >= 2:
+		n := 0
+		for _, key := range args[1:] {
+			_, isString := s.strings[key]
+			_, isSet := s.sets[key]
+			if isString || isSet {
+				n++
+			}
+			delete(s.strings, key)
+			delete(s.sets, key)
+		}
+		writeInt(w, n)
+	case cmd == "SADD" && len(args) >= 3:
+		set := s.sets[args[1]]
+		if set == nil {
+			set = make(map[string]struct{})
+			s.sets[args[1]] = set
+		}
+		n := 0
+		for _, member := range args[2:] {
+			if _, ok := set[member]; !ok {
+				set[member] = struct{}{}
+				n++
+			}
+		}
+		writeInt(w, n)
+	case cmd == "SREM" && len(args) >= 3:
+		set := s.sets[args[1]]
+		n := 0
+		for _, member := range args[2:] {
+			if _, ok := set[member]; ok {
+				delete(set, member)
+				n++
+			}
+		}
+		if len(set) == 0 {
+			delete(s.sets, args[1])
+		}
+		writeInt(w, n)
+	case cmd == "SMEMBERS" && len(args) == 2:
+		members := make([]string, 0, len(s.sets[args[1]]))
+		for member := range s.sets[args[1]] {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		writeArrayLen(w, len(members))
+		for _, member := range members {
+			writeBulk(w, member, true)
+		}
+	default:
+		writeError(w, "ERR unknown command or wrong number of arguments for '"+args[0]+"'")
+	}
+}
+
+// writeBulk writes a bulk string, or the nil bulk string if !ok.
+func writeBulk(w *bufio.Writer, value string, ok bool) {
+	if !ok {
+		_, _ = w.WriteString("$-1\r\n")
+		return
+	}
+	_, _ = w.WriteString("$")
+	_, _ = w.WriteString(strconv.Itoa(len(value)))
+	_, _ = w.WriteString("\r\n")
+	_, _ = w.WriteString(value)
+	_, _ = w.WriteString("\r\n")
+}
+
+// writeArrayLen writes an array header.
+func writeArrayLen(w *bufio.Writer, n int) {
+	_, _ = w.WriteString("*")
+	_, _ = w.WriteString(strconv.Itoa(n))
+	_, _ = w.WriteString("\r\n")
+}
+
+// writeInt writes an integer reply.
+func writeInt(w *bufio.Writer, n int) {
+	_, _ = w.WriteString(":")
+	_, _ = w.WriteString(strconv.Itoa(n))
+	_, _ = w.WriteString("\r\n")
+}
+
+// writeError writes an error reply.
+func writeError(w *bufio.Writer, msg string) {
+	_, _ = w.WriteString("-")
+	_, _ = w.WriteString(msg)
+	_, _ = w.WriteString("\r\n")
+}
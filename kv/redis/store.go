@@ -0,0 +1,184 @@
+// Package redis implements the kv.KeyValue contract over a Redis server.
+package redis
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/paralin/protods/kv"
+)
+
+// Store is a kv.KeyValue stored in Redis.
+//
+// Values are encoded with kv.EncodeValue and stored at the namespace
+// followed by "v:" and the key path. The path segments directly below each
+// key path are indexed in a set at the namespace followed by "c:" and the key
+// path, to list map entries without scanning the keyspace.
+//
+// The KeyValue methods do not return errors: the first error is recorded
+// and returned by Err.
+type Store struct {
+	client    *Client
+	namespace string
+
+	errMtx sync.Mutex
+	err    error
+}
+
+// NewStore builds a new store using the client.
+// The namespace is prefixed to all Redis keys, for example "upper:1:".
+func NewStore(client *Client, namespace string) *Store {
+	return &Store{client: client, namespace: namespace}
+}
+
+// Err returns the first error encountered by the store, if any.
+func (s *Store) Err() error {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+	return s.err
+}
+
+// setErr records an error.
+func (s *Store) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	s.errMtx.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errMtx.Unlock()
+}
+
+// valueKey returns the Redis key of the value at a key path.
+func (s *Store) valueKey(keyPath string) string {
+	return s.namespace + "v:" + keyPath
+}
+
+// childrenKey returns the Redis key of the child index of a key path.
+func (s *Store) childrenKey(keyPath string) string {
+	return s.namespace + "c:" + keyPath
+}
+
+// Set stores the value for the key.
+// The key is added to the child index of each of its ancestors.
+func (s *Store) Set(key string, value interface{}) {
+	data, err := kv.EncodeValue(value)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+
+	cmds := [][]string{{"SET", s.valueKey(key), string(data)}}
+	for parent, seg := kv.SplitKey(key); seg != ""; parent, seg = kv.SplitKey(parent) {
+		cmds = append(cmds, []string{"SADD", s.childrenKey(parent), seg})
+		if parent == "" {
+			break
+		}
+	}
+	s.pipeline(cmds)
+}
+
+// Get returns the value for the key.
+func (s *Store) Get(key string) (bool, interface{}) {
+	reply, err := s.client.Do("GET", s.valueKey(key))
+	if err != nil {
+		s.setErr(err)
+		return false, nil
+	}
+	return s.decode(reply)
+}
+
+// GetBatch returns the values for the keys that are set, in one round trip.
+func (s *Store) GetBatch(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return values
+	}
+
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "MGET")
+	for _, key := range keys {
+		args = append(args, s.valueKey(key))
+	}
+	reply, err := s.client.Do(args...)
+	if err != nil {
+		s.setErr(err)
+		return values
+	}
+
+	replies, _ := reply.([]interface{})
+	for i, key := range keys {
+		if i >= len(replies) {
+			break
+		}
+		if found, value := s.decode(replies[i]); found {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// Delete removes the value for the key.
+// Keys below the key are not removed, see kv.Tree.DeleteTree.
+func (s *Store) Delete(key string) {
+	parent, seg := kv.SplitKey(key)
+	s.pipeline([][]string{
+		{"DEL", s.valueKey(key), s.childrenKey(key)},
+		{"SREM", s.childrenKey(parent), seg},
+	})
+}
+
+// List returns the sorted path segments of the keys directly below the key path.
+func (s *Store) List(keyPath string) []string {
+	reply, err := s.client.Do("SMEMBERS", s.childrenKey(keyPath))
+	if err != nil {
+		s.setErr(err)
+		return nil
+	}
+
+	members, _ := reply.([]interface{})
+	segs := make([]string, 0, len(members))
+	for _, member := range members {
+		if seg, ok := member.(string); ok {
+			segs = append(segs, seg)
+		}
+	}
+	sort.Strings(segs)
+	return segs
+}
+
+// pipeline runs the commands, recording errors.
+func (s *Store) pipeline(cmds [][]string) {
+	replies, err := s.client.Pipeline(cmds)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+	for _, reply := range replies {
+		if rerr, ok := reply.(Error); ok {
+			s.setErr(rerr)
+		}
+	}
+}
+
+// decode decodes a GET reply.
+func (s *Store) decode(reply interface{}) (bool, interface{}) {
+	data, ok := reply.(string)
+	if !ok {
+		return false, nil
+	}
+	value, err := kv.DecodeValue([]byte(data))
+	if err != nil {
+		s.setErr(err)
+		return false, nil
+	}
+	return true, value
+}
+
+// _ is a type assertion
+var (
+	_ kv.Lister      = ((*Store)(nil))
+	_ kv.BatchGetter = ((*Store)(nil))
+)
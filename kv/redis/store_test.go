@@ -0,0 +1,25 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/paralin/protods/kv/kvtest"
+	"github.com/paralin/protods/kv/redis"
+	"github.com/paralin/protods/kv/redis/redistest"
+)
+
+func TestStore(t *testing.T) {
+	srv, err := redistest.NewServer()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer srv.Close()
+
+	client, err := srv.Dial()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer client.Close()
+
+	kvtest.TestStore(t, redis.NewStore(client, "upper:1:"))
+}
@@ -0,0 +1,239 @@
+// Package sqlite implements the kv.KeyValue contract over a SQLite database,
+// storing each object field as a row.
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/kv"
+	"github.com/pkg/errors"
+
+	// register the pure-Go sqlite driver.
+	_ "modernc.org/sqlite"
+)
+
+// DriverName is the database/sql driver name of the SQLite driver.
+const DriverName = "sqlite"
+
+// TableName is the name of the table storing the fields.
+const TableName = "protods_fields"
+
+// schema creates the fields table.
+// The primary key orders the rows by object and path, so key path prefixes
+// are index range scans.
+const schema = `CREATE TABLE IF NOT EXISTS ` + TableName + ` (
+	object_id TEXT NOT NULL,
+	path TEXT NOT NULL,
+	value BLOB NOT NULL,
+	PRIMARY KEY (object_id, path)
+) WITHOUT ROWID`
+
+// maxBatchParams is the maximum number of keys loaded in one query.
+const maxBatchParams = 500
+
+// Querier runs queries, implemented by *sql.DB and *sql.Tx.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Open opens the SQLite database file at the path and creates the schema.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open(DriverName, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := CreateSchema(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// CreateSchema creates the fields table if it does not exist.
+func CreateSchema(db Querier) error {
+	_, err := db.Exec(schema)
+	return errors.Wrap(err, "create schema")
+}
+
+// Store is a kv.KeyValue storing one object in the fields table.
+//
+// Each value is a row with the object ID, the key path and the value
+// encoded with kv.EncodeValue. Getters are point lookups by primary key and
+// listing map entries is a range query over the key path prefix.
+//
+// The KeyValue methods do not return errors: the first error is recorded
+// and returned by Err.
+type Store struct {
+	db       Querier
+	objectID string
+
+	errMtx sync.Mutex
+	err    error
+}
+
+// NewStore builds a new store for the object in the database or transaction.
+func NewStore(db Querier, objectID string) *Store {
+	return &Store{db: db, objectID: objectID}
+}
+
+// Err returns the first error encountered by the store, if any.
+func (s *Store) Err() error {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+	return s.err
+}
+
+// setErr records an error.
+func (s *Store) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	s.errMtx.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errMtx.Unlock()
+}
+
+// prefixRange returns the bounds of the paths below a key path.
+// '0' is the byte after '/'.
+func prefixRange(keyPath string) (string, string) {
+	return keyPath + "/", keyPath + "0"
+}
+
+// Set stores the value for the key.
+func (s *Store) Set(key string, value interface{}) {
+	data, err := kv.EncodeValue(value)
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO `+TableName+` (object_id, path, value) VALUES (?, ?, ?)
+		ON CONFLICT (object_id, path) DO UPDATE SET value = excluded.value`,
+		s.objectID, key, data,
+	)
+	s.setErr(err)
+}
+
+// Get returns the value for the key.
+func (s *Store) Get(key string) (bool, interface{}) {
+	var data []byte
+	err := s.db.QueryRow(
+		`SELECT value FROM `+TableName+` WHERE object_id = ? AND path = ?`,
+		s.objectID, key,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		s.setErr(err)
+		return false, nil
+	}
+	return s.decode(key, data)
+}
+
+// GetBatch returns the values for the keys that are set.
+func (s *Store) GetBatch(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	for len(keys) != 0 {
+		batch := keys
+		if len(batch) > maxBatchParams {
+			batch = batch[:maxBatchParams]
+		}
+		keys = keys[len(batch):]
+
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, s.objectID)
+		for _, key := range batch {
+			args = append(args, key)
+		}
+		rows, err := s.db.Query(
+			`SELECT path, value FROM `+TableName+` WHERE object_id = ? AND path IN (?`+
+				strings.Repeat(", ?", len(batch)-1)+`)`,
+			args...,
+		)
+		if err != nil {
+			s.setErr(err)
+			return values
+		}
+		for rows.Next() {
+			var path string
+			var data []byte
+			if err := rows.Scan(&path, &data); err != nil {
+				s.setErr(err)
+				break
+			}
+			if found, value := s.decode(path, data); found {
+				values[path] = value
+			}
+		}
+		s.setErr(rows.Err())
+		_ = rows.Close()
+	}
+	return values
+}
+
+// Delete removes the value for the key and all keys below it.
+func (s *Store) Delete(key string) {
+	lo, hi := prefixRange(key)
+	_, err := s.db.Exec(
+		`DELETE FROM `+TableName+` WHERE object_id = ? AND (path = ? OR (path >= ? AND path < ?))`,
+		s.objectID, key, lo, hi,
+	)
+	s.setErr(err)
+}
+
+// List returns the sorted path segments of the keys directly below the key
+// path, with a range query over the key path prefix.
+// The remainder of the path is compared as a BLOB: substr counts characters
+// in TEXT but the prefix length is in bytes.
+func (s *Store) List(keyPath string) []string {
+	lo, hi := prefixRange(keyPath)
+	rows, err := s.db.Query(
+		`SELECT path FROM `+TableName+`
+		WHERE object_id = ? AND path >= ? AND path < ?
+		AND instr(substr(CAST(path AS BLOB), ?), CAST('/' AS BLOB)) = 0
+		ORDER BY path`,
+		s.objectID, lo, hi, len(lo)+1,
+	)
+	if err != nil {
+		s.setErr(err)
+		return nil
+	}
+	defer rows.Close()
+
+	var segs []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			s.setErr(err)
+			return segs
+		}
+		segs = append(segs, path[len(lo):])
+	}
+	s.setErr(rows.Err())
+	return segs
+}
+
+// decode decodes a value.
+func (s *Store) decode(key string, data []byte) (bool, interface{}) {
+	value, err := kv.DecodeValue(data)
+	if err != nil {
+		s.setErr(errors.Wrapf(err, "decode %s", key))
+		return false, nil
+	}
+	return true, value
+}
+
+// _ is a type assertion
+var (
+	_ kv.Lister      = ((*Store)(nil))
+	_ kv.BatchGetter = ((*Store)(nil))
+)
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	"github.com/paralin/protods/kv/kvtest"
+)
+
+func TestStore(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+
+	kvtest.TestStore(t, NewStore(db, "upper-1"))
+	// objects are stored separately.
+	kvtest.TestStore(t, NewStore(db, "upper-2"))
+}
+
+// TestStoreListMultibyte tests listing below key paths with multibyte
+// characters, where the byte and character lengths differ.
+func TestStoreListMultibyte(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+
+	store := NewStore(db, "upper-1")
+	store.Set("/日本語", kv.Object{})
+	store.Set("/日本語/a", int64(1))
+	store.Set("/日本語/ü", kv.Object{})
+	store.Set("/日本語/ü/b", int64(2))
+	store.Set("/日本語/ü/c", kv.Object{})
+	store.Set("/日本語/ü/c/d", int64(3))
+	if err := store.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for keyPath, expected := range map[string]string{
+		"":           "日本語",
+		"/日本語":       "a,ü",
+		"/日本語/ü":     "b,c",
+		"/日本語/ü/c":   "d",
+		"/日本語/ü/c/d": "",
+	} {
+		if segs := strings.Join(store.List(keyPath), ","); segs != expected {
+			t.Errorf("list %q: expected %q, got %q", keyPath, expected, segs)
+		}
+	}
+	if err := store.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
@@ -0,0 +1,159 @@
+package kv
+
+import (
+	"strings"
+	"sync"
+)
+
+// Tree is the store shared by the objects of a key/value backed object tree.
+// Values are loaded lazily when a getter is called. Prefetch loads subtrees
+// in batches, the prefetched values are served from a cache until they are
+// overwritten or Invalidate is called.
+type Tree struct {
+	store KeyValue
+
+	mtx   sync.Mutex
+	cache map[string]cachedValue
+}
+
+// cachedValue is a value in the prefetch cache.
+type cachedValue struct {
+	found bool
+	value interface{}
+}
+
+// Prefetcher is implemented by the generated objects to prefetch subtrees.
+type Prefetcher interface {
+	// PrefetchKeys returns the keys of the fields of the object.
+	PrefetchKeys() []string
+	// PrefetchChildren returns the present message and map fields.
+	// Called after the keys of the object have been loaded.
+	PrefetchChildren() []Prefetcher
+}
+
+// NewTree builds a new tree over the store.
+func NewTree(store KeyValue) *Tree {
+	return &Tree{store: store, cache: make(map[string]cachedValue)}
+}
+
+// GetStore returns the underlying store.
+func (t *Tree) GetStore() KeyValue {
+	return t.store
+}
+
+// Get returns the value for the key.
+func (t *Tree) Get(key string) (bool, interface{}) {
+	t.mtx.Lock()
+	cv, ok := t.cache[key]
+	t.mtx.Unlock()
+	if ok {
+		return cv.found, cv.value
+	}
+
+	return t.store.Get(key)
+}
+
+// Set stores the value for the key.
+func (t *Tree) Set(key string, value interface{}) {
+	t.store.Set(key, value)
+
+	t.mtx.Lock()
+	if _, ok := t.cache[key]; ok {
+		t.cache[key] = cachedValue{found: true, value: value}
+	}
+	t.mtx.Unlock()
+}
+
+// Delete removes the value for the key.
+func (t *Tree) Delete(key string) {
+	t.store.Delete(key)
+
+	t.mtx.Lock()
+	if _, ok := t.cache[key]; ok {
+		t.cache[key] = cachedValue{}
+	}
+	t.mtx.Unlock()
+}
+
+// List returns the path segments of the keys directly below the key path.
+// Returns nil if the store is not a Lister.
+func (t *Tree) List(keyPath string) []string {
+	lister, ok := t.store.(Lister)
+	if !ok {
+		return nil
+	}
+	return lister.List(keyPath)
+}
+
+// DeleteTree removes the value for the key and all keys below it.
+// Only the key itself is removed if the store is not a Lister.
+func (t *Tree) DeleteTree(keyPath string) {
+	for _, seg := range t.List(keyPath) {
+		t.DeleteTree(JoinKey(keyPath, seg))
+	}
+	t.store.Delete(keyPath)
+
+	t.mtx.Lock()
+	for key := range t.cache {
+		if key == keyPath || strings.HasPrefix(key, keyPath+"/") {
+			delete(t.cache, key)
+		}
+	}
+	t.mtx.Unlock()
+}
+
+// Prefetch loads the fields of the objects and the objects below them into
+// the cache, with one batch per level of the tree.
+func (t *Tree) Prefetch(objs ...Prefetcher) {
+	level := objs
+	for len(level) != 0 {
+		var keys []string
+		for _, obj := range level {
+			keys = append(keys, obj.PrefetchKeys()...)
+		}
+		t.load(keys)
+
+		var next []Prefetcher
+		for _, obj := range level {
+			next = append(next, obj.PrefetchChildren()...)
+		}
+		level = next
+	}
+}
+
+// Invalidate clears the prefetch cache.
+func (t *Tree) Invalidate() {
+	t.mtx.Lock()
+	t.cache = make(map[string]cachedValue)
+	t.mtx.Unlock()
+}
+
+// load loads the keys into the cache.
+func (t *Tree) load(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	loaded := make(map[string]cachedValue, len(keys))
+	if batch, ok := t.store.(BatchGetter); ok {
+		values := batch.GetBatch(keys)
+		for _, key := range keys {
+			value, found := values[key]
+			loaded[key] = cachedValue{found: found, value: value}
+		}
+	} else {
+		for _, key := range keys {
+			found, value := t.store.Get(key)
+			loaded[key] = cachedValue{found: found, value: value}
+		}
+	}
+
+	t.mtx.Lock()
+	for key, cv := range loaded {
+		t.cache[key] = cv
+	}
+	t.mtx.Unlock()
+}
+
+// _ is a type assertion
+var _ Lister = ((*Tree)(nil))
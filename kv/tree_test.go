@@ -0,0 +1,114 @@
+package kv_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/kv"
+	"github.com/paralin/protods/kv/kvtest"
+)
+
+// mapStore is an in-memory store counting the round trips.
+type mapStore struct {
+	values  map[string]interface{}
+	gets    int
+	batches int
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{values: make(map[string]interface{})}
+}
+
+func (s *mapStore) Set(key string, value interface{}) {
+	s.values[key] = value
+}
+
+func (s *mapStore) Get(key string) (bool, interface{}) {
+	s.gets++
+	value, ok := s.values[key]
+	return ok, value
+}
+
+func (s *mapStore) Delete(key string) {
+	delete(s.values, key)
+}
+
+func (s *mapStore) List(keyPath string) []string {
+	var segs []string
+	for key := range s.values {
+		if rest := strings.TrimPrefix(key, keyPath+"/"); rest != key && !strings.Contains(rest, "/") {
+			segs = append(segs, rest)
+		}
+	}
+	sort.Strings(segs)
+	return segs
+}
+
+func (s *mapStore) GetBatch(keys []string) map[string]interface{} {
+	s.batches++
+	values := make(map[string]interface{})
+	for _, key := range keys {
+		if value, ok := s.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// prefetcher prefetches fixed keys and children.
+type prefetcher struct {
+	keys     []string
+	children []kv.Prefetcher
+}
+
+func (p *prefetcher) PrefetchKeys() []string            { return p.keys }
+func (p *prefetcher) PrefetchChildren() []kv.Prefetcher { return p.children }
+
+func TestTreeStore(t *testing.T) {
+	kvtest.TestStore(t, kv.NewTree(newMapStore()))
+}
+
+// TestTreePrefetch tests prefetched values are loaded with one batch per
+// level and served from the cache until overwritten or invalidated.
+func TestTreePrefetch(t *testing.T) {
+	store := newMapStore()
+	store.Set("/id", "upper")
+	store.Set("/lower", kv.Object{})
+	store.Set("/lower/value", "lower")
+
+	tree := kv.NewTree(store)
+	tree.Prefetch(&prefetcher{
+		keys: []string{"/id", "/lower", "/score"},
+		children: []kv.Prefetcher{
+			&prefetcher{keys: []string{"/lower/value"}},
+		},
+	})
+	if store.batches != 2 {
+		t.Fatalf("expected 2 batches, got %d", store.batches)
+	}
+
+	if found, value := tree.Get("/lower/value"); !found || value != "lower" {
+		t.Fatalf("unexpected prefetched value: %v %#v", found, value)
+	}
+	if found, _ := tree.Get("/score"); found {
+		t.Fatal("expected missing /score to be cached as not found")
+	}
+	if store.gets != 0 {
+		t.Fatalf("expected cached values, got %d store gets", store.gets)
+	}
+
+	tree.Set("/id", "changed")
+	if found, value := tree.Get("/id"); !found || value != "changed" {
+		t.Fatalf("expected the cache to be updated by Set: %#v", value)
+	}
+
+	store.Set("/lower/value", "outside")
+	tree.Invalidate()
+	if _, value := tree.Get("/lower/value"); value != "outside" {
+		t.Fatalf("expected the value from the store after Invalidate: %#v", value)
+	}
+	if store.gets != 1 {
+		t.Fatalf("expected 1 store get after Invalidate, got %d", store.gets)
+	}
+}
@@ -0,0 +1,437 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+	"text/scanner"
+	"unicode"
+
+	"github.com/emicklei/proto"
+	"github.com/paralin/protods/generate/itypes"
+	"github.com/paralin/protods/generate/sql"
+	"github.com/paralin/protods/parser"
+)
+
+// Severity is the severity of a problem.
+type Severity string
+
+const (
+	// SeverityError indicates protods will generate incorrect or invalid code.
+	SeverityError Severity = "error"
+	// SeverityWarning indicates protods will skip the construct.
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifies the kind of problem.
+type Rule string
+
+const (
+	// RuleUnsupported is a schema construct protods cannot generate.
+	RuleUnsupported Rule = "unsupported"
+	// RuleNameCollision is a collision between generated identifiers.
+	RuleNameCollision Rule = "name-collision"
+	// RuleGoKeyword is a name that is a Go keyword, not a valid identifier or
+	// not the name protoc-gen-go will use.
+	RuleGoKeyword Rule = "go-keyword"
+	// RuleReservedName is a name or number conflicting with a reserved one.
+	RuleReservedName Rule = "reserved-name"
+)
+
+// Problem is a schema construct protods cannot generate correctly.
+type Problem struct {
+	// Position is the location of the construct in the proto source.
+	Position scanner.Position
+	// Severity is the severity of the problem.
+	Severity Severity
+	// Rule is the kind of problem.
+	Rule Rule
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the problem as file:line:col: severity: message (rule).
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Position.String(), p.Severity, p.Message, p.Rule)
+}
+
+// problemJSON is the JSON representation of a problem.
+type problemJSON struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Rule     Rule     `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// MarshalJSON marshals the problem to a flat JSON object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&problemJSON{
+		File:     p.Position.Filename,
+		Line:     p.Position.Line,
+		Column:   p.Position.Column,
+		Severity: p.Severity,
+		Rule:     p.Rule,
+		Message:  p.Message,
+	})
+}
+
+// reservedMethodNames are methods protoc-gen-go declares on message types.
+// Fields with these names are renamed with a trailing underscore.
+var reservedMethodNames = map[string]bool{
+	"Reset":        true,
+	"String":       true,
+	"ProtoMessage": true,
+	"Descriptor":   true,
+}
+
+// generatedTypeNames are the package level names the generators declare for
+// a message or map implementation type name.
+var generatedTypeNames = []struct {
+	desc string
+	name func(name string) string
+}{
+	{"ctrie type", func(name string) string { return name + "Ctrie" }},
+	{"descriptor", func(name string) string { return name + "Descriptor" }},
+	{"journal type", func(name string) string { return name + "Journal" }},
+	{"kv type", func(name string) string { return name + "KV" }},
+	{"observable type", func(name string) string { return name + "Observable" }},
+	{"read-only view type", func(name string) string { return "ReadOnly" + name }},
+	{"scaffold type", func(name string) string { return name + "Scaffold" }},
+	{"sql type", func(name string) string { return name + "SQL" }},
+	{"sync type", func(name string) string { return "Sync" + name }},
+}
+
+// linter accumulates problems.
+type linter struct {
+	problems []*Problem
+}
+
+// report adds a problem.
+func (l *linter) report(pos scanner.Position, sev Severity, rule Rule, format string, args ...interface{}) {
+	l.problems = append(l.problems, &Problem{
+		Position: pos,
+		Severity: sev,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint checks a proto file for constructs protods cannot generate correctly.
+// The problems are sorted by position.
+func Lint(pp *proto.Proto) ([]*Problem, error) {
+	pf, err := parser.Parse(pp)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{}
+	l.lintPackage(pp, pf)
+	l.lintElements(pp)
+	l.lintTopLevelNames(pp, pf)
+	for i := range pf.Messages {
+		l.lintMessage(&pf.Messages[i])
+	}
+	l.lintSQLNames(pf)
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		pi, pj := l.problems[i].Position, l.problems[j].Position
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+	return l.problems, nil
+}
+
+// lintPackage checks the package name is usable as a Go package name.
+func (l *linter) lintPackage(pp *proto.Proto, pf *parser.File) {
+	for _, element := range pp.Elements {
+		pkg, ok := element.(*proto.Package)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case token.IsKeyword(pf.PackageName):
+			l.report(pkg.Position, SeverityError, RuleGoKeyword, "package name %q is a Go keyword", pf.PackageName)
+		case !token.IsIdentifier(pf.PackageName):
+			l.report(pkg.Position, SeverityError, RuleGoKeyword, "package name %q is not a valid Go package name", pf.PackageName)
+		}
+	}
+}
+
+// lintElements checks for declarations the parser skips.
+func (l *linter) lintElements(pp *proto.Proto) {
+	for _, element := range pp.Elements {
+		switch ele := element.(type) {
+		case *proto.Enum:
+			l.report(ele.Position, SeverityWarning, RuleUnsupported, "enum %s is not supported", ele.Name)
+		case *proto.Message:
+			l.lintMessageElements(ele)
+		}
+	}
+}
+
+// lintMessageElements checks the raw elements of a message.
+func (l *linter) lintMessageElements(msg *proto.Message) {
+	var reserved []*proto.Reserved
+	for _, element := range msg.Elements {
+		switch ele := element.(type) {
+		case *proto.Message:
+			l.report(ele.Position, SeverityWarning, RuleUnsupported, "nested message %s.%s is not supported", msg.Name, ele.Name)
+		case *proto.Enum:
+			l.report(ele.Position, SeverityWarning, RuleUnsupported, "nested enum %s.%s is not supported", msg.Name, ele.Name)
+		case *proto.Oneof:
+			l.report(ele.Position, SeverityWarning, RuleUnsupported, "oneof %s.%s is not supported, its fields are skipped", msg.Name, ele.Name)
+		case *proto.Reserved:
+			reserved = append(reserved, ele)
+		}
+	}
+
+	if len(reserved) == 0 {
+		return
+	}
+
+	checkField := func(pos scanner.Position, name string, number int) {
+		for _, res := range reserved {
+			for _, rname := range res.FieldNames {
+				if rname == name {
+					l.report(pos, SeverityError, RuleReservedName, "field %s.%s uses reserved name %q", msg.Name, name, rname)
+				}
+			}
+			for _, rng := range res.Ranges {
+				if number >= rng.From && (rng.Max || number <= rng.To) {
+					l.report(pos, SeverityError, RuleReservedName, "field %s.%s uses reserved number %d", msg.Name, name, number)
+				}
+			}
+		}
+	}
+	for _, element := range msg.Elements {
+		switch ele := element.(type) {
+		case *proto.NormalField:
+			checkField(ele.Position, ele.Name, ele.Sequence)
+		case *proto.MapField:
+			checkField(ele.Position, ele.Name, ele.Sequence)
+		}
+	}
+}
+
+// lintTopLevelNames checks for collisions between package level identifiers.
+func (l *linter) lintTopLevelNames(pp *proto.Proto, pf *parser.File) {
+	// declared maps identifiers to their description.
+	declared := make(map[string]string)
+	declare := func(pos scanner.Position, ident, desc string) {
+		if prev, ok := declared[ident]; ok {
+			l.report(pos, SeverityError, RuleNameCollision, "%s %s collides with %s", desc, ident, prev)
+			return
+		}
+		declared[ident] = desc
+	}
+	declared["ScaffoldHooks"] = "scaffold hooks interface type"
+
+	// declareInterface declares an interface type and the types derived from it.
+	declareInterface := func(pos scanner.Position, ident, desc string) {
+		declare(pos, ident, desc)
+		declare(pos, itypes.ReaderName(ident), "reader part of the "+desc)
+		declare(pos, itypes.WriterName(ident), "writer part of the "+desc)
+		declare(pos, itypes.CtxName(ident), "context aware variant of the "+desc)
+	}
+	// declareGenerated declares the generator types for an implementation type.
+	declareGenerated := func(pos scanner.Position, ident, of string) {
+		for _, gen := range generatedTypeNames {
+			declare(pos, gen.name(ident), gen.desc+" for "+of)
+		}
+	}
+
+	for i := range pf.Messages {
+		msg := &pf.Messages[i]
+		switch {
+		case token.IsKeyword(msg.Name):
+			l.report(msg.Position, SeverityError, RuleGoKeyword, "message name %q is a Go keyword", msg.Name)
+		case !unicode.IsUpper([]rune(msg.Name)[0]):
+			l.report(
+				msg.Position,
+				SeverityError,
+				RuleGoKeyword,
+				"message name %q is not exported, protoc-gen-go will rename it to %q",
+				msg.Name,
+				parser.CamelCase(msg.Name),
+			)
+		}
+
+		declare(msg.Position, msg.Name, "message type")
+		declareInterface(msg.Position, msg.InterName, "interface type for message "+msg.Name)
+		declareGenerated(msg.Position, msg.Name, "message "+msg.Name)
+	}
+
+	// mapDecls maps the generated map interface name to the first declaration.
+	mapDecls := make(map[string]*proto.MapField)
+	for _, element := range pp.Elements {
+		msg, ok := element.(*proto.Message)
+		if !ok {
+			continue
+		}
+
+		for _, melement := range msg.Elements {
+			mele, ok := melement.(*proto.MapField)
+			if !ok {
+				continue
+			}
+
+			field := findField(pf, msg.Name, mele.Name)
+			if field == nil || field.Map == nil {
+				continue
+			}
+
+			mapName := field.Map.TypeName
+			first, ok := mapDecls[mapName]
+			if !ok {
+				mapDecls[mapName] = mele
+				mapDesc := "map<" + mele.KeyType + ", " + mele.Type + ">"
+				declareInterface(mele.Position, mapName, "map interface type for "+mapDesc)
+				declare(mele.Position, field.Map.ImplName, "map type for "+mapDesc)
+				declareGenerated(mele.Position, field.Map.ImplName, mapDesc)
+				continue
+			}
+
+			if first.KeyType != mele.KeyType || first.Type != mele.Type {
+				l.report(
+					mele.Position,
+					SeverityError,
+					RuleNameCollision,
+					"map<%s, %s> generates the same map type %s as map<%s, %s> at %s",
+					mele.KeyType,
+					mele.Type,
+					mapName,
+					first.KeyType,
+					first.Type,
+					first.Position.String(),
+				)
+			}
+		}
+	}
+}
+
+// lintMessage checks the fields of a message.
+func (l *linter) lintMessage(msg *parser.Message) {
+	// methods maps method and field names on the message type to a description.
+	methods := make(map[string]string)
+	declare := func(pos scanner.Position, ident, desc string) {
+		if prev, ok := methods[ident]; ok {
+			l.report(pos, SeverityError, RuleNameCollision, "%s %s on %s collides with %s", desc, ident, msg.Name, prev)
+			return
+		}
+		methods[ident] = desc
+	}
+
+	for name := range reservedMethodNames {
+		methods[name] = "protoc-gen-go method " + name
+	}
+	declare(msg.Position, "To"+msg.InterName, "generated method")
+
+	// declare the protoc-gen-go struct fields and getters first.
+	for i := range msg.Fields {
+		field := &msg.Fields[i]
+		goName := field.CamelName
+		if reservedMethodNames[goName] || strings.HasPrefix(goName, "XXX_") {
+			l.report(
+				field.Position,
+				SeverityError,
+				RuleReservedName,
+				"field %s.%s is renamed to %s_ by protoc-gen-go, protods would generate mismatching names",
+				msg.Name,
+				field.Name,
+				goName,
+			)
+			goName += "_"
+		}
+
+		declare(field.Position, goName, "struct field for "+field.Name)
+		declare(field.Position, "Get"+goName, "getter for field "+field.Name)
+	}
+
+	for i := range msg.Fields {
+		field := &msg.Fields[i]
+		l.lintFieldKind(msg, field)
+		if !field.IsSupported() {
+			continue
+		}
+
+		if field.GetterName != "Get"+field.CamelName {
+			declare(field.Position, field.GetterName, "generated getter for field "+field.Name)
+		}
+		if field.Kind != parser.FieldKindScalar {
+			declare(field.Position, itypes.ReaderGetterName(field), "generated reader getter for field "+field.Name)
+		}
+		declare(field.Position, field.SetterName, "generated setter for field "+field.Name)
+		if field.NewName != "" {
+			declare(field.Position, field.NewName, "generated constructor for field "+field.Name)
+		}
+	}
+}
+
+// lintSQLNames checks for collisions between the table and column names of
+// the sql generator.
+func (l *linter) lintSQLNames(pf *parser.File) {
+	for _, collision := range sql.NameCollisions(pf) {
+		l.report(collision.Position, SeverityError, RuleNameCollision, "sql generator: %s", collision.Message)
+	}
+}
+
+// lintFieldKind checks that the kind of the field is supported.
+func (l *linter) lintFieldKind(msg *parser.Message, field *parser.Field) {
+	switch {
+	case field.Repeated:
+		l.report(field.Position, SeverityWarning, RuleUnsupported, "repeated field %s.%s is not supported", msg.Name, field.Name)
+	case field.Map != nil && field.Map.Key != "string":
+		l.report(
+			field.Position,
+			SeverityWarning,
+			RuleUnsupported,
+			"map field %s.%s has unsupported key type %s, only string keys are supported",
+			msg.Name,
+			field.Name,
+			field.Map.Key,
+		)
+	case field.Map != nil && !field.Map.IsSupported():
+		l.report(
+			field.Position,
+			SeverityWarning,
+			RuleUnsupported,
+			"map field %s.%s has unsupported value type %s",
+			msg.Name,
+			field.Name,
+			field.Type,
+		)
+	case field.Map == nil && field.Kind == parser.FieldKindUnknown:
+		l.report(
+			field.Position,
+			SeverityWarning,
+			RuleUnsupported,
+			"field %s.%s has unsupported type %s",
+			msg.Name,
+			field.Name,
+			field.Type,
+		)
+	}
+}
+
+// findField finds a field by message and field name.
+func findField(pf *parser.File, msgName, fieldName string) *parser.Field {
+	msg := pf.GetMessage(msgName)
+	if msg == nil {
+		return nil
+	}
+
+	for i := range msg.Fields {
+		if msg.Fields[i].Name == fieldName {
+			return &msg.Fields[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paralin/protods/parser"
+)
+
+// lintSource parses and lints a proto source named test.proto.
+func lintSource(t *testing.T, src string) []*Problem {
+	t.Helper()
+	pp, err := parser.ParseProto("test.proto", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	problems, err := Lint(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return problems
+}
+
+func TestLintRules(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		// expected are the expected problems, formatted by Problem.String.
+		expected []string
+	}{{
+		name: "clean",
+		body: `message Foo {
+  string id = 1;
+  map<string, Foo> children = 2;
+}`,
+	}, {
+		name: "unsupported",
+		body: `enum Kind {
+  KIND_NONE = 0;
+}
+message Foo {
+  message Inner {}
+  oneof choice {
+    string a = 1;
+  }
+  repeated string tags = 2;
+  map<int32, string> byNum = 3;
+  Kind kind = 4;
+}`,
+		expected: []string{
+			`test.proto:3:1: warning: enum Kind is not supported (unsupported)`,
+			`test.proto:7:3: warning: nested message Foo.Inner is not supported (unsupported)`,
+			`test.proto:8:3: warning: oneof Foo.choice is not supported, its fields are skipped (unsupported)`,
+			`test.proto:11:12: warning: repeated field Foo.tags is not supported (unsupported)`,
+			`test.proto:12:3: warning: map field Foo.byNum has unsupported key type int32, only string keys are supported (unsupported)`,
+			`test.proto:13:3: warning: field Foo.kind has unsupported type Kind (unsupported)`,
+		},
+	}, {
+		name: "message collides with interface",
+		body: `message Foo {}
+message IFoo {}`,
+		expected: []string{
+			`test.proto:4:1: error: message type IFoo collides with interface type for message Foo (name-collision)`,
+		},
+	}, {
+		name: "field names collide",
+		body: `message Foo {
+  string foo_bar = 1;
+  string fooBar = 2;
+}`,
+		expected: []string{
+			`test.proto:5:3: error: struct field for fooBar FooBar on Foo collides with struct field for foo_bar (name-collision)`,
+			`test.proto:5:3: error: getter for field fooBar GetFooBar on Foo collides with getter for field foo_bar (name-collision)`,
+			`test.proto:5:3: error: generated setter for field fooBar SetFooBar on Foo collides with generated setter for field foo_bar (name-collision)`,
+		},
+	}, {
+		name: "message collides with interface parts",
+		body: `message Foo {}
+message IFooReader {}
+message IFooWriter {}
+message IFooCtx {}`,
+		expected: []string{
+			`test.proto:4:1: error: message type IFooReader collides with reader part of the interface type for message Foo (name-collision)`,
+			`test.proto:5:1: error: message type IFooWriter collides with writer part of the interface type for message Foo (name-collision)`,
+			`test.proto:6:1: error: message type IFooCtx collides with context aware variant of the interface type for message Foo (name-collision)`,
+		},
+	}, {
+		name: "message collides with generated types",
+		body: `message Foo {}
+message FooCtrie {}
+message FooScaffold {}
+message ScaffoldHooks {}`,
+		expected: []string{
+			`test.proto:4:1: error: message type FooCtrie collides with ctrie type for message Foo (name-collision)`,
+			`test.proto:5:1: error: message type FooScaffold collides with scaffold type for message Foo (name-collision)`,
+			`test.proto:6:1: error: message type ScaffoldHooks collides with scaffold hooks interface type (name-collision)`,
+		},
+	}, {
+		name: "message collides with prefixed generated types",
+		body: `message Foo {}
+message SyncFoo {}
+message ReadOnlyFoo {}`,
+		expected: []string{
+			`test.proto:4:1: error: message type SyncFoo collides with sync type for message Foo (name-collision)`,
+			`test.proto:5:1: error: message type ReadOnlyFoo collides with read-only view type for message Foo (name-collision)`,
+		},
+	}, {
+		name: "map types collide with messages",
+		body: `message Foo {
+  map<string, Foo> children = 1;
+}
+message IStringFooMapReader {}
+message StringFooMapKV {}`,
+		expected: []string{
+			`test.proto:4:3: error: reader part of the map interface type for map<string, Foo> IStringFooMapReader collides with message type (name-collision)`,
+			`test.proto:4:3: error: kv type for map<string, Foo> StringFooMapKV collides with message type (name-collision)`,
+		},
+	}, {
+		name: "shared map type",
+		body: `message Foo {
+  map<string, string> a = 1;
+}
+message Bar {
+  map<string, string> b = 1;
+}`,
+	}, {
+		name: "unexported message",
+		body: `message foo {}`,
+		expected: []string{
+			`test.proto:3:1: error: message name "foo" is not exported, protoc-gen-go will rename it to "Foo" (go-keyword)`,
+		},
+	}, {
+		name: "reserved method name",
+		body: `message Foo {
+  string reset = 1;
+}`,
+		expected: []string{
+			`test.proto:4:3: error: field Foo.reset is renamed to Reset_ by protoc-gen-go, protods would generate mismatching names (reserved-name)`,
+		},
+	}, {
+		name: "reserved field",
+		body: `message Foo {
+  reserved 2 to 4;
+  reserved "old";
+  string old = 1;
+  string id = 3;
+}`,
+		expected: []string{
+			`test.proto:6:3: error: field Foo.old uses reserved name "old" (reserved-name)`,
+			`test.proto:7:3: error: field Foo.id uses reserved number 3 (reserved-name)`,
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			problems := lintSource(t, "syntax = \"proto3\";\npackage test;\n"+c.body+"\n")
+			var actual []string
+			for _, problem := range problems {
+				actual = append(actual, problem.String())
+			}
+			if strings.Join(actual, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("expected problems:\n%s\nactual:\n%s", strings.Join(c.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintPackageKeyword(t *testing.T) {
+	problems := lintSource(t, "syntax = \"proto3\";\npackage func;\n")
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem, got %v", problems)
+	}
+	expected := `test.proto:2:1: error: package name "func" is a Go keyword (go-keyword)`
+	if problems[0].String() != expected {
+		t.Fatalf("unexpected problem: %s", problems[0].String())
+	}
+}
+
+func TestLintSQLNames(t *testing.T) {
+	problems := lintSource(t, `syntax = "proto3";
+package test;
+
+message Foo {
+  map<string, int64> bar = 1;
+}
+
+message FooBar {
+}
+`)
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem, got %v", problems)
+	}
+	expected := `test.proto:8:1: error: sql generator: table for message FooBar "foo_bar" collides with table for map field Foo.bar (name-collision)`
+	if problems[0].String() != expected {
+		t.Fatalf("unexpected problem: %s", problems[0].String())
+	}
+}
+
+func TestLintReaderGetter(t *testing.T) {
+	pp, err := parser.ParseProto("test.proto", strings.NewReader(`syntax = "proto3";
+package test;
+
+message Foo {
+  Bar bar = 1;
+  string bar_reader = 2;
+}
+
+message Bar {
+}
+`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	problems, err := Lint(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem, got %v", problems)
+	}
+	if !strings.Contains(problems[0].String(), "generated reader getter for field bar") {
+		t.Fatalf("unexpected problem: %s", problems[0].String())
+	}
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"encoding/json"
+	"text/scanner"
+)
+
+// positionJSON is the JSON representation of a position in the proto source.
+type positionJSON struct {
+	Filename string `json:"filename"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// newPositionJSON converts a position to its JSON representation.
+func newPositionJSON(pos scanner.Position) positionJSON {
+	return positionJSON{
+		Filename: pos.Filename,
+		Offset:   pos.Offset,
+		Line:     pos.Line,
+		Column:   pos.Column,
+	}
+}
+
+// toPosition converts the JSON representation back to a position.
+func (p positionJSON) toPosition() scanner.Position {
+	return scanner.Position{
+		Filename: p.Filename,
+		Offset:   p.Offset,
+		Line:     p.Line,
+		Column:   p.Column,
+	}
+}
+
+// MarshalJSON marshals the message with a snake_case position.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	return json.Marshal(&struct {
+		*message
+		Position positionJSON `json:"position"`
+	}{(*message)(&m), newPositionJSON(m.Position)})
+}
+
+// UnmarshalJSON unmarshals the message from MarshalJSON.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+	aux := struct {
+		*message
+		Position positionJSON `json:"position"`
+	}{message: (*message)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	m.Position = aux.Position.toPosition()
+	return nil
+}
+
+// MarshalJSON marshals the field with a snake_case position.
+func (f Field) MarshalJSON() ([]byte, error) {
+	type field Field
+	return json.Marshal(&struct {
+		*field
+		Position positionJSON `json:"position"`
+	}{(*field)(&f), newPositionJSON(f.Position)})
+}
+
+// UnmarshalJSON unmarshals the field from MarshalJSON.
+func (f *Field) UnmarshalJSON(data []byte) error {
+	type field Field
+	aux := struct {
+		*field
+		Position positionJSON `json:"position"`
+	}{field: (*field)(f)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	f.Position = aux.Position.toPosition()
+	return nil
+}
+
+// MarshalJSON marshals the map type with a snake_case position.
+func (m Map) MarshalJSON() ([]byte, error) {
+	type mapType Map
+	return json.Marshal(&struct {
+		*mapType
+		Position positionJSON `json:"position"`
+	}{(*mapType)(&m), newPositionJSON(m.Position)})
+}
+
+// UnmarshalJSON unmarshals the map type from MarshalJSON.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	type mapType Map
+	aux := struct {
+		*mapType
+		Position positionJSON `json:"position"`
+	}{mapType: (*mapType)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	m.Position = aux.Position.toPosition()
+	return nil
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/proto"
+)
+
+// parseString parses a proto source for tests.
+func parseString(t *testing.T, src string) *File {
+	t.Helper()
+	pparser := proto.NewParser(strings.NewReader(src))
+	pparser.Filename("test.proto")
+	pp, err := pparser.Parse()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pf, err := Parse(pp)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return pf
+}
+
+// TestFileJSON tests positions are encoded with snake_case keys and decoded
+// back.
+func TestFileJSON(t *testing.T) {
+	pf := parseString(t, `syntax = "proto3";
+package test;
+
+message Upper {
+  string id = 1;
+  map<string, Upper> children = 2;
+}
+`)
+
+	data, err := json.Marshal(pf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, key := range []string{"Filename", "Line", "Column", "Offset"} {
+		if strings.Contains(string(data), `"`+key+`"`) {
+			t.Fatalf("unexpected key %q in %s", key, string(data))
+		}
+	}
+	if !strings.Contains(string(data), `"position":{"filename":"test.proto"`) {
+		t.Fatalf("expected snake_case position in %s", string(data))
+	}
+
+	var out File
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err.Error())
+	}
+	field := out.Messages[0].Fields[1]
+	if field.Position != pf.Messages[0].Fields[1].Position {
+		t.Fatalf("field position: got %v want %v", field.Position, pf.Messages[0].Fields[1].Position)
+	}
+	if out.Maps[0].Position != pf.Maps[0].Position || out.Messages[0].Position != pf.Messages[0].Position {
+		t.Fatal("message or map position not decoded")
+	}
+	if field.Map == nil || field.Map.TypeName != "IStringUpperMap" {
+		t.Fatalf("map not decoded: %#v", field.Map)
+	}
+}
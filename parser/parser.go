@@ -2,7 +2,8 @@ package parser
 
 import (
 	"bytes"
-	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 
@@ -11,9 +12,34 @@ import (
 	"github.com/serenize/snaker"
 )
 
+// ReadProto reads and parses a proto file from disk.
+// Positions in the proto refer to the given path.
+func ReadProto(protoPath string) (*proto.Proto, error) {
+	f, err := os.Open(protoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseProto(protoPath, f)
+}
+
+// ParseProto parses a proto file from a reader.
+// Positions in the proto refer to the given filename.
+func ParseProto(filename string, r io.Reader) (*proto.Proto, error) {
+	pparser := proto.NewParser(r)
+	pparser.Filename(filename)
+	parsedProto, err := pparser.Parse()
+	if err != nil {
+		return nil, errors.Wrap(err, "parse proto")
+	}
+
+	return parsedProto, nil
+}
+
 // Parse parses the proto file.
 func Parse(pf *proto.Proto) (*File, error) {
-	f := &File{}
+	f := &File{Filename: pf.Filename}
 	var packageName string
 	var messages []*proto.Message
 	for _, element := range pf.Elements {
@@ -30,26 +56,50 @@ func Parse(pf *proto.Proto) (*File, error) {
 	}
 
 	f.PackageName = packageName
-	mapTypes := make(map[string]*Map)
-	messageTypes := make(map[string]*Message)
-	genMapName := func(mele *proto.MapField) string {
+	messageNames := make(map[string]bool)
+	for _, message := range messages {
+		messageNames[message.Name] = true
+	}
+
+	// resolveType resolves a proto type to a kind, Go type and message name.
+	resolveType := func(typ string) (FieldKind, string, string) {
+		if goType, ok := ScalarGoType(typ); ok {
+			return FieldKindScalar, goType, ""
+		}
+
+		msgName := strings.TrimPrefix(typ, ".")
+		msgName = strings.TrimPrefix(msgName, packageName+".")
+		if messageNames[msgName] {
+			return FieldKindMessage, "I" + msgName, msgName
+		}
+
+		return FieldKindUnknown, "", ""
+	}
+
+	genMapName := func(keyType, valueType string) string {
 		var outp bytes.Buffer
 		outp.WriteString("I")
-		outp.WriteString(snaker.SnakeToCamel(mele.KeyType))
-		outp.WriteString(snaker.SnakeToCamel(mele.Type))
+		outp.WriteString(snaker.SnakeToCamel(keyType))
+		outp.WriteString(snaker.SnakeToCamel(valueType))
 		outp.WriteString("Map")
 		return outp.String()
 	}
 
+	// fieldMaps maps message and field index to the map type name.
+	type fieldRef struct {
+		msg, field int
+	}
+	fieldMaps := make(map[fieldRef]string)
+	mapTypes := make(map[string]bool)
 	for _, message := range messages {
 		var msg Message
 		msg.Name = message.Name
 		msg.InterName = "I" + message.Name
+		msg.Position = message.Position
 		if message.Comment != nil {
 			msg.Comment = strings.TrimSpace(message.Comment.Message())
 		}
 
-		messageTypes[message.Name] = &msg
 		for _, melement := range message.Elements {
 			switch mele := melement.(type) {
 			case *proto.NormalField:
@@ -58,36 +108,69 @@ func Parse(pf *proto.Proto) (*File, error) {
 					comment = strings.TrimSpace(mele.Comment.Message())
 				}
 
-				msg.Fields = append(msg.Fields, Field{
+				field := Field{
 					Name:      mele.Name,
-					CamelName: snaker.SnakeToCamel(mele.Name),
+					CamelName: CamelCase(mele.Name),
 					Comment:   comment,
 					Type:      mele.Type,
-				})
+					Number:    mele.Sequence,
+					Repeated:  mele.Repeated,
+					KeyPath:   "/" + mele.Name,
+					Position:  mele.Position,
+				}
+				field.Kind, field.GoType, field.Message = resolveType(mele.Type)
+				field.GetterName = "Get" + field.CamelName
+				field.SetterName = "Set" + field.CamelName
+				if field.Kind == FieldKindMessage {
+					field.GetterName += "Inter"
+					field.NewName = "New" + field.CamelName
+				}
+				msg.Fields = append(msg.Fields, field)
 			case *proto.MapField:
 				var comment string
 				if mele.Comment != nil {
 					comment = strings.TrimSpace(mele.Comment.Message())
 				}
 
-				mapName := genMapName(mele)
-				mt, ok := mapTypes[mapName]
-				if !ok {
+				valueKind, valueGoType, valueMsg := resolveType(mele.Type)
+				valueName := mele.Type
+				if valueMsg != "" {
+					valueName = valueMsg
+				}
+				mapName := genMapName(mele.KeyType, valueName)
+				if !mapTypes[mapName] {
+					mapTypes[mapName] = true
+					valuePtr := valueGoType
+					if valueMsg != "" {
+						valuePtr = "*" + valueMsg
+					}
 					f.Maps = append(f.Maps, Map{
-						Key:      mele.KeyType,
-						Value:    mele.Type,
-						TypeName: mapName,
-						ValuePtr: mele.Type,
+						Key:          mele.KeyType,
+						Value:        valueGoType,
+						ValueKind:    valueKind,
+						ValueMessage: valueMsg,
+						ValuePtr:     valuePtr,
+						TypeName:     mapName,
+						ImplName:     mapName[1:],
+						Position:     mele.Position,
 					})
-					mt = &f.Maps[len(f.Maps)-1]
 				}
 
+				camelName := CamelCase(mele.Name)
+				fieldMaps[fieldRef{msg: len(f.Messages), field: len(msg.Fields)}] = mapName
 				msg.Fields = append(msg.Fields, Field{
-					Name:      mele.Name,
-					CamelName: snaker.SnakeToCamel(mele.Name),
-					Comment:   comment,
-					Type:      mele.Type,
-					Map:       mt,
+					Name:       mele.Name,
+					CamelName:  camelName,
+					Comment:    comment,
+					Type:       mele.Type,
+					Number:     mele.Sequence,
+					Kind:       FieldKindMap,
+					GoType:     mapName,
+					GetterName: "Get" + camelName + "Inter",
+					SetterName: "Set" + camelName,
+					NewName:    "New" + camelName,
+					KeyPath:    "/" + mele.Name,
+					Position:   mele.Position,
 				})
 			}
 		}
@@ -95,24 +178,61 @@ func Parse(pf *proto.Proto) (*File, error) {
 		f.Messages = append(f.Messages, msg)
 	}
 
+	sort.Slice(f.Maps, func(i int, j int) bool {
+		return strings.Compare(f.Maps[i].TypeName, f.Maps[j].TypeName) == -1
+	})
+
+	// link the map fields after the maps slice is final.
+	mapIndex := make(map[string]*Map, len(f.Maps))
+	for i := range f.Maps {
+		mapIndex[f.Maps[i].TypeName] = &f.Maps[i]
+	}
+	for ref, mapName := range fieldMaps {
+		f.Messages[ref.msg].Fields[ref.field].Map = mapIndex[mapName]
+	}
+
 	sort.Slice(f.Messages, func(i int, j int) bool {
 		return strings.Compare(f.Messages[i].Name, f.Messages[j].Name) == -1
 	})
 
-	sort.Slice(f.Maps, func(i int, j int) bool {
-		return strings.Compare(f.Maps[i].TypeName, f.Maps[j].TypeName) == -1
-	})
+	return f, nil
+}
+
+// CamelCase converts a snake_case proto name to the CamelCase name used by
+// protoc-gen-go for struct fields and getters, for example "lower_kv" to
+// "LowerKv". Unlike snaker it does not upper-case initialisms like "id".
+func CamelCase(s string) string {
+	if s == "" {
+		return ""
+	}
 
-	for k, ma := range f.Maps {
-		fmt.Println(ma.Value)
-		it, recog := messageTypes[ma.Value]
-		fmt.Println(recog)
-		if recog {
-			fmt.Println(it.InterName)
-			f.Maps[k].ValuePtr = "*" + ma.Value
-			f.Maps[k].Value = it.InterName
+	isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	t := make([]byte, 0, len(s)+1)
+	i := 0
+	if s[0] == '_' {
+		// need a capital letter, drop the '_'.
+		t = append(t, 'X')
+		i++
+	}
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '_' && i+1 < len(s) && isLower(s[i+1]) {
+			continue
+		}
+		if isDigit(c) {
+			t = append(t, c)
+			continue
+		}
+		if isLower(c) {
+			c ^= ' '
+		}
+		t = append(t, c)
+		for i+1 < len(s) && isLower(s[i+1]) {
+			i++
+			t = append(t, s[i])
 		}
 	}
 
-	return f, nil
+	return string(t)
 }
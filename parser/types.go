@@ -1,48 +1,207 @@
 package parser
 
+import (
+	"text/scanner"
+)
+
 // File represents a proto file.
 type File struct {
-	PackageName string
-	Messages    []Message
-	Maps        []Map
+	// Filename is the path of the proto file, if known.
+	Filename    string    `json:"filename,omitempty"`
+	PackageName string    `json:"package_name"`
+	Messages    []Message `json:"messages"`
+	Maps        []Map     `json:"maps"`
+}
+
+// GetMessage returns the message with the name, or nil if not found.
+func (f *File) GetMessage(name string) *Message {
+	for i := range f.Messages {
+		if f.Messages[i].Name == name {
+			return &f.Messages[i]
+		}
+	}
+
+	return nil
 }
 
 // Message is a known message type.
 type Message struct {
 	// Name is the name of the message.
-	Name string
+	Name string `json:"name"`
 	// InterName is the name of the interface type.
-	InterName string
+	InterName string `json:"inter_name"`
 	// Comment is the comment on the message.
-	Comment string
+	Comment string `json:"comment,omitempty"`
 	// Fields are the fields on the message.
-	Fields []Field
+	Fields []Field `json:"fields"`
+	// Position is the location of the message in the proto source.
+	Position scanner.Position `json:"position"`
+}
+
+// FieldKind is the kind of value a field holds.
+type FieldKind int
+
+const (
+	// FieldKindUnknown is a field with a type protods does not recognize.
+	// Examples include enums, nested and imported message types.
+	FieldKindUnknown FieldKind = iota
+	// FieldKindScalar is a field with a proto scalar type.
+	FieldKindScalar
+	// FieldKindMessage is a field with a message type declared in the file.
+	FieldKindMessage
+	// FieldKindMap is a map field.
+	FieldKindMap
+)
+
+// String returns the name of the field kind.
+func (k FieldKind) String() string {
+	switch k {
+	case FieldKindScalar:
+		return "scalar"
+	case FieldKindMessage:
+		return "message"
+	case FieldKindMap:
+		return "map"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText marshals the field kind to its name.
+func (k FieldKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals the field kind from its name.
+func (k *FieldKind) UnmarshalText(text []byte) error {
+	for _, kind := range []FieldKind{FieldKindScalar, FieldKindMessage, FieldKindMap} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	*k = FieldKindUnknown
+	return nil
 }
 
 // Field is a field in a message.
 type Field struct {
 	// Name is the snake_case name of the field.
-	Name string
+	Name string `json:"name"`
 	// CamelName is the CamelCase name of the field.
-	CamelName string
+	CamelName string `json:"camel_name"`
 	// Comment is any comment on the field.
-	Comment string
-	// Type is the Go type of the field.
-	Type string
+	Comment string `json:"comment,omitempty"`
+	// Type is the proto type of the field.
+	// For map fields this is the proto type of the map value.
+	Type string `json:"type"`
+	// Number is the field number.
+	Number int `json:"number"`
+	// Repeated indicates the field is a repeated field.
+	Repeated bool `json:"repeated,omitempty"`
+	// Kind is the kind of the field.
+	Kind FieldKind `json:"kind"`
+	// GoType is the Go type of the field in the interface type.
+	// Scalars use the Go type, messages and maps their interface type.
+	// Empty if the field is not supported.
+	GoType string `json:"go_type,omitempty"`
+	// Message is the message type name for message fields.
+	Message string `json:"message,omitempty"`
 	// Map indicates the field is a map type.
-	Map *Map
+	Map *Map `json:"map,omitempty"`
+	// GetterName is the name of the interface getter, for example GetSubject.
+	// Messages and maps use an Inter suffix to not clash with the proto getter.
+	GetterName string `json:"getter_name,omitempty"`
+	// SetterName is the name of the interface setter, for example SetSubject.
+	SetterName string `json:"setter_name,omitempty"`
+	// NewName is the name of the interface constructor for message and map
+	// fields, for example NewMapField. Empty for scalar fields.
+	NewName string `json:"new_name,omitempty"`
+	// KeyPath is the key of the field relative to the message in a key/value
+	// store, for example /lower_kv. Nested fields and map entries are stored
+	// below it, for example /lower_kv/test/value.
+	KeyPath string `json:"key_path"`
+	// Position is the location of the field in the proto source.
+	Position scanner.Position `json:"position"`
+}
+
+// IsSupported checks if protods can generate code for the field.
+// Unsupported fields are skipped by the generators.
+func (f *Field) IsSupported() bool {
+	if f.Repeated || f.GoType == "" {
+		return false
+	}
+
+	if f.Map != nil {
+		return f.Map.IsSupported()
+	}
+
+	return true
 }
 
 // Map is a map type.
 type Map struct {
 	// Key is the key type for the map.
 	// Original case
-	Key string
-	// Value is the value type for the map.
-	// Original case
-	Value string
+	Key string `json:"key"`
+	// Value is the Go value type for the map.
+	// Messages use the interface type.
+	Value string `json:"value"`
+	// ValueKind is the kind of the map value.
+	ValueKind FieldKind `json:"value_kind"`
+	// ValueMessage is the message type name of the value for message values.
+	ValueMessage string `json:"value_message,omitempty"`
 	// ValuePtr is the value type without the I prefix.
-	ValuePtr string
+	ValuePtr string `json:"value_ptr"`
 	// TypeName is the computed type name for the map interface.
-	TypeName string
+	TypeName string `json:"type_name"`
+	// ImplName is the name of the map type implementing the interface.
+	ImplName string `json:"impl_name"`
+	// Position is the location of the first field declaring the map.
+	Position scanner.Position `json:"position"`
+}
+
+// IsSupported checks if protods can generate code for the map type.
+func (m *Map) IsSupported() bool {
+	return m.Key == "string" &&
+		(m.ValueKind == FieldKindScalar || m.ValueKind == FieldKindMessage)
+}
+
+// scalarGoTypes maps proto scalar types to Go types.
+var scalarGoTypes = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"int64":    "int64",
+	"uint32":   "uint32",
+	"uint64":   "uint64",
+	"sint32":   "int32",
+	"sint64":   "int64",
+	"fixed32":  "uint32",
+	"fixed64":  "uint64",
+	"sfixed32": "int32",
+	"sfixed64": "int64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "[]byte",
+}
+
+// ScalarGoType returns the Go type for a proto scalar type.
+func ScalarGoType(protoType string) (string, bool) {
+	t, ok := scalarGoTypes[protoType]
+	return t, ok
+}
+
+// ZeroValue returns the Go zero value literal for a field Go type.
+func ZeroValue(goType string) string {
+	switch goType {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "float32", "float64", "int32", "int64", "uint32", "uint64":
+		return "0"
+	default:
+		return "nil"
+	}
 }
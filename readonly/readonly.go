@@ -0,0 +1,59 @@
+// Package readonly contains the error raised by the read-only views
+// generated with the readonly generator.
+//
+// The setters of the interface types do not return errors, so the setters
+// of a read-only view panic with an *Error. Recover converts the panic back
+// into an error.
+package readonly
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnly is the cause of errors raised by read-only views.
+var ErrReadOnly = errors.New("read-only view")
+
+// Error is raised when a read-only view is mutated.
+type Error struct {
+	// Path is the key path of the mutation, for example /lower_kv/test/value.
+	Path string
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return "set " + e.Path + ": " + ErrReadOnly.Error()
+}
+
+// Cause returns ErrReadOnly.
+func (e *Error) Cause() error {
+	return ErrReadOnly
+}
+
+// Unwrap returns ErrReadOnly.
+func (e *Error) Unwrap() error {
+	return ErrReadOnly
+}
+
+// Panic raises an *Error for the key path.
+func Panic(path string) {
+	panic(&Error{Path: path})
+}
+
+// Recover recovers a panic raised by a read-only view into err.
+// Other panics are raised again. Must be deferred:
+//
+//	defer readonly.Recover(&err)
+func Recover(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	e, ok := r.(*Error)
+	if !ok {
+		panic(r)
+	}
+	*err = e
+}
+
+// _ is a type assertion
+var _ error = ((*Error)(nil))
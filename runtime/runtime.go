@@ -0,0 +1,258 @@
+// Package runtime gets and sets the fields of objects by name through the
+// descriptor tables generated with the descriptor generator.
+//
+// Generic tooling like printers, copiers and diffing can use the
+// descriptors to work over any implementation of the interfaces without a
+// type switch:
+//
+//	val, err := runtime.GetField(obj, "lower_kv")
+package runtime
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is the kind of a field or map value.
+type Kind int
+
+const (
+	// KindScalar is a scalar value, for example a string.
+	KindScalar Kind = iota
+	// KindMessage is a message value, stored as its interface type.
+	KindMessage
+	// KindMap is a map value, stored as its interface type.
+	KindMap
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindScalar:
+		return "scalar"
+	case KindMessage:
+		return "message"
+	case KindMap:
+		return "map"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrUnknownType is returned when no descriptor matches an object.
+	ErrUnknownType = errors.New("unknown message type")
+	// ErrAmbiguousType is returned when more than one descriptor matches an
+	// object of a type not registered with Register.
+	ErrAmbiguousType = errors.New("ambiguous message type")
+	// ErrUnknownField is returned when a message has no field with a name.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrInvalidValue is returned when setting a value of the wrong type.
+	ErrInvalidValue = errors.New("invalid value type")
+)
+
+// MessageDescriptor describes a message type.
+type MessageDescriptor struct {
+	// Name is the message name, for example Upper.
+	Name string
+	// Fields are the supported fields, in declaration order.
+	Fields []*FieldDescriptor
+	// Is checks if an object implements the interface type of the message.
+	Is func(obj interface{}) bool
+}
+
+// FieldByName returns the field with the snake_case name, or nil.
+func (d *MessageDescriptor) FieldByName(name string) *FieldDescriptor {
+	for _, fd := range d.Fields {
+		if fd.Name == name {
+			return fd
+		}
+	}
+	return nil
+}
+
+// FieldByNumber returns the field with the number, or nil.
+func (d *MessageDescriptor) FieldByNumber(number int) *FieldDescriptor {
+	for _, fd := range d.Fields {
+		if fd.Number == number {
+			return fd
+		}
+	}
+	return nil
+}
+
+// FieldDescriptor describes a field of a message.
+type FieldDescriptor struct {
+	// Name is the snake_case name of the field.
+	Name string
+	// Number is the field number.
+	Number int
+	// Kind is the kind of the field.
+	Kind Kind
+	// GoType is the Go type of the field in the interface type.
+	GoType string
+	// KeyPath is the key of the field in the key/value layout, for example
+	// /lower_kv.
+	KeyPath string
+	// Message describes the message type for message fields.
+	Message *MessageDescriptor
+	// Map describes the map type for map fields.
+	Map *MapDescriptor
+
+	// Get returns the value of the field of obj.
+	// Message and map values are their interface types, or nil if unset.
+	Get func(obj interface{}) interface{}
+	// Set sets the value of the field of obj.
+	// Returns ErrInvalidValue if val is not of the field type.
+	Set func(obj, val interface{}) error
+	// New builds a new value for message and map fields with the
+	// constructor of obj. Nil for scalar fields.
+	New func(obj interface{}) interface{}
+}
+
+// MapDescriptor describes a map type.
+type MapDescriptor struct {
+	// Name is the name of the map implementation type, for example
+	// StringLowerMap.
+	Name string
+	// ValueKind is the kind of the map values.
+	ValueKind Kind
+	// ValueGoType is the Go type of the map values.
+	ValueGoType string
+	// ValueMessage describes the message type of message values.
+	ValueMessage *MessageDescriptor
+
+	// Get returns a value from the map m.
+	Get func(m interface{}, key string) interface{}
+	// Set sets a value in the map m.
+	// Returns ErrInvalidValue if val is not of the value type.
+	Set func(m interface{}, key string, val interface{}) error
+	// Range iterates over the map m.
+	Range func(m interface{}, cb func(key string, val interface{}) bool) bool
+}
+
+// InvalidValue returns an ErrInvalidValue error for the field or map.
+func InvalidValue(name string, val interface{}) error {
+	return errors.Wrapf(ErrInvalidValue, "%s: %T", name, val)
+}
+
+// registry is the set of registered message descriptors.
+var registry struct {
+	mtx   sync.RWMutex
+	descs []*MessageDescriptor
+	types map[reflect.Type]*MessageDescriptor
+}
+
+// Register registers a message descriptor for Lookup, with the concrete types
+// of objects implementing the message, for example (*Upper)(nil). Called by
+// the init functions of the generated code with the proto type, and again by
+// other implementations to register their types. Panics if a type is
+// registered for two descriptors.
+func Register(desc *MessageDescriptor, objs ...interface{}) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	found := false
+	for _, d := range registry.descs {
+		if d == desc {
+			found = true
+			break
+		}
+	}
+	if !found {
+		registry.descs = append(registry.descs, desc)
+	}
+
+	for _, obj := range objs {
+		typ := reflect.TypeOf(obj)
+		if prev, ok := registry.types[typ]; ok && prev != desc {
+			panic("runtime: " + typ.String() + " registered for both " + prev.Name + " and " + desc.Name)
+		}
+		if registry.types == nil {
+			registry.types = make(map[reflect.Type]*MessageDescriptor)
+		}
+		registry.types[typ] = desc
+	}
+}
+
+// Lookup returns the descriptor registered for the concrete type of obj.
+// Objects of other types are matched with the interface types of the
+// registered messages, returning ErrAmbiguousType if more than one matches,
+// for example if a message has no fields. The descriptor can also be used
+// directly with FieldByName.
+func Lookup(obj interface{}) (*MessageDescriptor, error) {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+
+	if desc, ok := registry.types[reflect.TypeOf(obj)]; ok {
+		return desc, nil
+	}
+
+	var matches []*MessageDescriptor
+	for _, d := range registry.descs {
+		if d.Is(obj) {
+			matches = append(matches, d)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, errors.Wrapf(ErrUnknownType, "%T", obj)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, d := range matches {
+			names[i] = d.Name
+		}
+		return nil, errors.Wrapf(ErrAmbiguousType, "%T matches %s", obj, strings.Join(names, ", "))
+	}
+}
+
+// lookupField returns the field of obj with the name.
+func lookupField(obj interface{}, name string) (*FieldDescriptor, error) {
+	desc, err := Lookup(obj)
+	if err != nil {
+		return nil, err
+	}
+	fd := desc.FieldByName(name)
+	if fd == nil {
+		return nil, errors.Wrapf(ErrUnknownField, "%s.%s", desc.Name, name)
+	}
+	return fd, nil
+}
+
+// GetField returns the value of the field of obj with the snake_case name.
+func GetField(obj interface{}, name string) (interface{}, error) {
+	fd, err := lookupField(obj, name)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Get(obj), nil
+}
+
+// SetField sets the value of the field of obj with the snake_case name.
+func SetField(obj interface{}, name string, val interface{}) error {
+	fd, err := lookupField(obj, name)
+	if err != nil {
+		return err
+	}
+	return fd.Set(obj, val)
+}
+
+// Range calls cb with each field of obj and its value, in declaration order,
+// until cb returns false. Returns false if cb returned false.
+func Range(obj interface{}, cb func(fd *FieldDescriptor, val interface{}) bool) (bool, error) {
+	desc, err := Lookup(obj)
+	if err != nil {
+		return false, err
+	}
+	for _, fd := range desc.Fields {
+		if !cb(fd, fd.Get(obj)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// named is implemented by the test objects.
+type named interface {
+	GetName() string
+}
+
+// object has a name.
+type object struct{ name string }
+
+// GetName returns the name.
+func (o *object) GetName() string { return o.name }
+
+// otherObject also has a name.
+type otherObject struct{ object }
+
+func TestLookup(t *testing.T) {
+	emptyDesc := &MessageDescriptor{Name: "Empty", Is: func(obj interface{}) bool { return true }}
+	namedDesc := &MessageDescriptor{
+		Name: "Named",
+		Is:   func(obj interface{}) bool { _, ok := obj.(named); return ok },
+		Fields: []*FieldDescriptor{{
+			Name: "name",
+			Get:  func(obj interface{}) interface{} { return obj.(named).GetName() },
+		}},
+	}
+	Register(emptyDesc)
+	Register(namedDesc, (*object)(nil))
+
+	// registered types are found by their concrete type.
+	val, err := GetField(&object{name: "test"}, "name")
+	if err != nil || val != "test" {
+		t.Fatalf("expected test, got %v %v", val, err)
+	}
+
+	// other types matching more than one interface type are ambiguous.
+	if _, err := Lookup(&otherObject{}); errors.Cause(err) != ErrAmbiguousType {
+		t.Fatalf("expected ambiguous type, got %v", err)
+	}
+	Register(namedDesc, (*otherObject)(nil))
+	if desc, err := Lookup(&otherObject{}); err != nil || desc != namedDesc {
+		t.Fatalf("expected Named, got %v %v", desc, err)
+	}
+
+	// registering a type for another descriptor panics.
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	Register(emptyDesc, (*object)(nil))
+}
@@ -0,0 +1,124 @@
+// Package sqldb is the runtime used by the code generated with the sql
+// generator, mapping each message to a table.
+package sqldb
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Querier runs queries, implemented by *sql.DB and *sql.Tx.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// DB runs the queries of the generated objects.
+//
+// The generated getters and setters do not return errors: the first error
+// is recorded and returned by Err.
+type DB struct {
+	q Querier
+
+	errMtx sync.Mutex
+	err    error
+}
+
+// New builds a new DB using a database or transaction.
+func New(q Querier) *DB {
+	return &DB{q: q}
+}
+
+// GetQuerier returns the underlying database or transaction.
+func (d *DB) GetQuerier() Querier {
+	return d.q
+}
+
+// CreateSchema runs the schema statements.
+func (d *DB) CreateSchema(stmts []string) error {
+	for _, stmt := range stmts {
+		if _, err := d.q.Exec(stmt); err != nil {
+			return errors.Wrap(err, "create schema")
+		}
+	}
+	return nil
+}
+
+// Err returns the first error encountered, if any.
+func (d *DB) Err() error {
+	d.errMtx.Lock()
+	defer d.errMtx.Unlock()
+	return d.err
+}
+
+// setErr records an error.
+func (d *DB) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	d.errMtx.Lock()
+	if d.err == nil {
+		d.err = err
+	}
+	d.errMtx.Unlock()
+}
+
+// Exec runs a statement.
+func (d *DB) Exec(query string, args ...interface{}) {
+	_, err := d.q.Exec(query, args...)
+	d.setErr(err)
+}
+
+// Insert runs an insert statement and returns the ID of the new row.
+func (d *DB) Insert(query string, args ...interface{}) (int64, error) {
+	res, err := d.q.Exec(query, args...)
+	if err != nil {
+		d.setErr(err)
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	d.setErr(err)
+	return id, err
+}
+
+// Get runs a query returning at most one row and scans it into dest.
+// Returns false if there is no row.
+func (d *DB) Get(query string, args []interface{}, dest ...interface{}) bool {
+	err := d.q.QueryRow(query, args...).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		d.setErr(err)
+		return false
+	}
+	return true
+}
+
+// Strings runs a query returning one string column and returns the values.
+// The rows are read before returning, so other queries can run while the
+// caller iterates.
+func (d *DB) Strings(query string, args ...interface{}) []string {
+	rows, err := d.q.Query(query, args...)
+	if err != nil {
+		d.setErr(err)
+		return nil
+	}
+	defer rows.Close()
+
+	var vals []string
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			d.setErr(err)
+			return vals
+		}
+		vals = append(vals, val)
+	}
+	d.setErr(rows.Err())
+	return vals
+}
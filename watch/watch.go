@@ -0,0 +1,167 @@
+// Package watch delivers change notifications to watchers of key paths,
+// used by the code generated with the watch generator.
+//
+// Changes are addressed by key path in the key/value layout, for example
+// /lower_kv/test/value. A watcher of a path is notified of changes to the
+// path and to any path below it, and of changes replacing a path above it,
+// such as setting the message containing the watched field.
+package watch
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/paralin/protods/diff"
+)
+
+// Hub dispatches changes to watchers, safe for concurrent use.
+type Hub struct {
+	mtx      sync.Mutex
+	nextID   uint64
+	watchers map[uint64]*watcher
+}
+
+// watcher is a registered callback.
+type watcher struct {
+	path string
+	cb   func(diff.Change)
+}
+
+// NewHub builds a new hub with no watchers.
+func NewHub() *Hub {
+	return &Hub{watchers: make(map[uint64]*watcher)}
+}
+
+// Match checks if a change at changePath is at, below or above the watched
+// path. The empty path matches all changes.
+func Match(path, changePath string) bool {
+	path = strings.TrimSuffix(path, "/")
+	changePath = strings.TrimSuffix(changePath, "/")
+	if path == "" || changePath == "" || path == changePath {
+		return true
+	}
+	return strings.HasPrefix(changePath, path+"/") || strings.HasPrefix(path, changePath+"/")
+}
+
+// Watch calls cb after each change at or below the path, or replacing a path
+// above it.
+// Callbacks are called on the goroutine making the change, in the order the
+// watchers were added, and must not block.
+func (h *Hub) Watch(path string, cb func(diff.Change)) *Subscription {
+	h.mtx.Lock()
+	id := h.nextID
+	h.nextID++
+	h.watchers[id] = &watcher{path: path, cb: cb}
+	h.mtx.Unlock()
+	return &Subscription{hub: h, id: id}
+}
+
+// WatchChan delivers the changes matching the path, see Watch, to a channel
+// with the buffer size. Changes are dropped if the buffer is full, see Dropped. The
+// channel is closed by Unsubscribe.
+func (h *Hub) WatchChan(path string, size int) (<-chan diff.Change, *Subscription) {
+	ch := make(chan diff.Change, size)
+	var sub *Subscription
+	var mtx sync.Mutex
+	closed := false
+	sub = h.Watch(path, func(c diff.Change) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- c:
+		default:
+			sub.mtx.Lock()
+			sub.dropped++
+			sub.mtx.Unlock()
+		}
+	})
+	sub.onRelease = func() {
+		mtx.Lock()
+		closed = true
+		close(ch)
+		mtx.Unlock()
+	}
+	return ch, sub
+}
+
+// Notify calls the watchers matching the path of the change.
+func (h *Hub) Notify(c diff.Change) {
+	h.mtx.Lock()
+	ids := make([]uint64, 0, len(h.watchers))
+	for id, w := range h.watchers {
+		if Match(w.path, c.Path) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	cbs := make([]func(diff.Change), len(ids))
+	for i, id := range ids {
+		cbs[i] = h.watchers[id].cb
+	}
+	h.mtx.Unlock()
+
+	for _, cb := range cbs {
+		cb(c)
+	}
+}
+
+// Len returns the number of watchers.
+func (h *Hub) Len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.watchers)
+}
+
+// Subscription is a handle to a watcher.
+type Subscription struct {
+	hub       *Hub
+	id        uint64
+	onRelease func()
+
+	mtx      sync.Mutex
+	released bool
+	dropped  uint64
+}
+
+// Unsubscribe removes the watcher. Safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.mtx.Lock()
+	if s.released {
+		s.mtx.Unlock()
+		return
+	}
+	s.released = true
+	s.mtx.Unlock()
+
+	s.hub.mtx.Lock()
+	delete(s.hub.watchers, s.id)
+	s.hub.mtx.Unlock()
+	if s.onRelease != nil {
+		s.onRelease()
+	}
+}
+
+// Dropped returns the number of changes dropped because the channel buffer
+// was full, for subscriptions made with WatchChan.
+func (s *Subscription) Dropped() uint64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.dropped
+}
+
+// ChangeKind returns the kind of a change replacing a message, map or map
+// entry, given if the old and new values are set.
+func ChangeKind(oldSet, newSet bool) diff.Kind {
+	switch {
+	case !oldSet && newSet:
+		return diff.Added
+	case oldSet && !newSet:
+		return diff.Removed
+	default:
+		return diff.Modified
+	}
+}